@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"github.com/HubbleNetwork/hubble-install/internal/boards"
+	"github.com/HubbleNetwork/hubble-install/internal/bundle"
+	"github.com/HubbleNetwork/hubble-install/internal/hubbledemo"
+	"github.com/HubbleNetwork/hubble-install/internal/netconfig"
+	"github.com/HubbleNetwork/hubble-install/internal/ui"
+)
+
+// runBundleCommand handles "hubble-install bundle <subcommand>" and returns the exit code
+func runBundleCommand(args []string) int {
+	if len(args) == 0 || args[0] != "create" {
+		ui.PrintError("Usage: hubble-install bundle create [flags]")
+		return 1
+	}
+
+	fs := flag.NewFlagSet("bundle create", flag.ExitOnError)
+	boardList := fs.String("boards", "", "Comma-separated board IDs to include (default: all boards)")
+	jlinkInstaller := fs.String("jlink", "", "Path to a SEGGER J-Link installer to include in the bundle")
+	output := fs.String("output", "", "Archive to write (default: hubble-bundle-<os>-<arch>.tar.gz)")
+	toolVersion := fs.String("tool-version", "", "pyhubbledemo version to bundle (default: the version pinned in this build; \"latest\" to unpin)")
+	lockFile := fs.String("lock-file", "", "Lock file pinning the exact pyhubbledemo environment (read if present, written otherwise)")
+	network := addNetworkFlags(fs)
+	fs.Parse(args[1:])
+
+	if err := netconfig.Configure(*network); err != nil {
+		ui.PrintError(fmt.Sprintf("Network configuration failed: %v", err))
+		return 1
+	}
+
+	hubbledemo.Configure(*toolVersion, *lockFile)
+
+	selected := boards.AvailableBoards
+	if *boardList != "" {
+		selected = nil
+		for _, id := range strings.Split(*boardList, ",") {
+			board, err := boards.GetBoard(strings.TrimSpace(id))
+			if err != nil {
+				ui.PrintError(err.Error())
+				return 1
+			}
+			selected = append(selected, *board)
+		}
+	}
+
+	if *output == "" {
+		*output = fmt.Sprintf("hubble-bundle-%s-%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ui.PrintInfo(fmt.Sprintf("Creating offline bundle for %s/%s", runtime.GOOS, runtime.GOARCH))
+	manifest, err := bundle.Create(ctx, bundle.CreateOptions{
+		Output:         *output,
+		Boards:         selected,
+		JLinkInstaller: *jlinkInstaller,
+	})
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Bundle creation failed: %v", err))
+		os.Remove(*output)
+		return 1
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Bundle written to %s", *output))
+	ui.PrintInfo(fmt.Sprintf("Boards: %s", strings.Join(manifest.Boards, ", ")))
+	if manifest.ToolVersion != "" {
+		ui.PrintInfo(fmt.Sprintf("pyhubbledemo: %s", manifest.ToolVersion))
+	}
+	fmt.Println()
+	ui.PrintInfo("On the offline machine, run:")
+	fmt.Printf("  hubble-install --bundle %s\n", *output)
+	return 0
+}
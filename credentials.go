@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/HubbleNetwork/hubble-install/internal/config"
+	"github.com/HubbleNetwork/hubble-install/internal/hubbleapi"
+	"github.com/HubbleNetwork/hubble-install/internal/ui"
+)
+
+// apiBaseURL returns the API location: the --api-url flag, then
+// HUBBLE_API_URL, then the default for the credentials' API environment
+func apiBaseURL(flagValue string, cfg *config.Config) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	if env := os.Getenv(hubbleapi.BaseURLEnv); env != "" {
+		return env, nil
+	}
+	if url, ok := hubbleapi.Environments[cfg.APIEnv]; ok {
+		return url, nil
+	}
+	return "", fmt.Errorf("no API URL is known for the %q environment; pass --api-url or set %s", cfg.APIEnv, hubbleapi.BaseURLEnv)
+}
+
+// checkCredentialsOnline asks the Hubble API whether the token is valid for
+// the organization. It returns an error only when the API rejected the
+// credentials; if the API can't be reached the flash step will report it.
+func checkCredentialsOnline(ctx context.Context, api *hubbleapi.Client) error {
+	ui.PrintInfo("Checking your credentials with the Hubble API...")
+
+	checkCtx, cancel := context.WithTimeout(ctx, timeoutCredentials)
+	defer cancel()
+
+	err := api.CheckCredentials(checkCtx)
+	switch {
+	case err == nil:
+		ui.PrintSuccess("Credentials accepted by the Hubble API")
+		return nil
+	case credentialsRejected(err):
+		return err
+	}
+
+	exitIfInterrupted(ctx)
+	ui.PrintWarning(fmt.Sprintf("Could not check your credentials online: %v", err))
+	ui.PrintInfo("Continuing; they will be checked again when the board is registered.")
+	return nil
+}
+
+// credentialsRejected reports whether the API refused the token or Org ID
+func credentialsRejected(err error) bool {
+	return errors.Is(err, hubbleapi.ErrUnauthorized) ||
+		errors.Is(err, hubbleapi.ErrForbidden) ||
+		errors.Is(err, hubbleapi.ErrOrgNotFound)
+}
+
+// printCredentialCheckHint explains how to fix credentials the API rejected
+func printCredentialCheckHint(err error) {
+	if errors.Is(err, hubbleapi.ErrOrgNotFound) {
+		ui.PrintInfo("Copy the Org ID shown next to your API token at https://dash.hubble.com/developer/api-tokens")
+	} else {
+		ui.PrintInfo("Create a new API token at https://dash.hubble.com/developer/api-tokens")
+	}
+}
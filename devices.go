@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HubbleNetwork/hubble-install/internal/config"
+	"github.com/HubbleNetwork/hubble-install/internal/hubbleapi"
+	"github.com/HubbleNetwork/hubble-install/internal/logging"
+	"github.com/HubbleNetwork/hubble-install/internal/netconfig"
+	"github.com/HubbleNetwork/hubble-install/internal/ui"
+)
+
+// runDevicesCommand handles "hubble-install devices list|show" and returns the exit code
+func runDevicesCommand(args []string) int {
+	usage := "Usage: hubble-install devices list [flags] | devices show [flags] <name or ID>"
+	if len(args) == 0 || (args[0] != "list" && args[0] != "show") {
+		ui.PrintError(usage)
+		return 1
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("devices "+action, flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print JSON instead of a table")
+	apiURL := fs.String("api-url", "", "Hubble API base URL (default: $"+hubbleapi.BaseURLEnv+" or the credentials' API environment)")
+	network := addNetworkFlags(fs)
+	fs.Parse(args[1:])
+
+	query := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if (action == "show") != (query != "") {
+		ui.PrintError(usage)
+		return 1
+	}
+
+	// Keep standard output for the result so it can be piped
+	if *asJSON {
+		ui.UseStderr()
+	}
+
+	if err := netconfig.Configure(*network); err != nil {
+		ui.PrintError(fmt.Sprintf("Network configuration failed: %v", err))
+		return 1
+	}
+
+	logging.AddSecret(config.EnvironmentSecrets()...)
+	cfg, _, err := config.PromptForConfig()
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Configuration failed: %v", err))
+		return 1
+	}
+	logging.AddSecret(cfg.OrgID, cfg.APIToken)
+
+	baseURL, err := apiBaseURL(*apiURL, cfg)
+	if err != nil {
+		ui.PrintError(err.Error())
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	listCtx, cancel := context.WithTimeout(ctx, timeoutDeviceList)
+	defer cancel()
+
+	devices, err := hubbleapi.New(baseURL, cfg.OrgID, cfg.APIToken).ListDevices(listCtx)
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Could not list devices: %v", err))
+		if credentialsRejected(err) {
+			printCredentialCheckHint(err)
+		}
+		return 1
+	}
+
+	if action == "list" {
+		if *asJSON {
+			return writeDevicesJSON(devices)
+		}
+		if len(devices) == 0 {
+			ui.PrintInfo("There are no devices in your organization yet.")
+			return 0
+		}
+		writeDeviceTable(devices)
+		return 0
+	}
+
+	matches := findDevices(devices, query)
+	switch {
+	case len(matches) == 0:
+		ui.PrintError(fmt.Sprintf("No device named %q or with that ID", query))
+		return 1
+	case len(matches) > 1:
+		ui.PrintError(fmt.Sprintf("%d devices are named %q; show one by ID:", len(matches), query))
+		writeDeviceTable(matches)
+		return 1
+	}
+	if *asJSON {
+		return writeDevicesJSON(matches[0])
+	}
+	writeDeviceDetails(matches[0])
+	return 0
+}
+
+// findDevices returns the device with the given ID, or else every device
+// with the given name (compared without regard to case)
+func findDevices(devices []hubbleapi.Device, query string) []hubbleapi.Device {
+	var byName []hubbleapi.Device
+	for _, d := range devices {
+		if d.ID == query {
+			return []hubbleapi.Device{d}
+		}
+		if strings.EqualFold(d.Name, query) {
+			byName = append(byName, d)
+		}
+	}
+	return byName
+}
+
+// writeDevicesJSON prints v as indented JSON on standard output
+func writeDevicesJSON(v any) int {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		ui.PrintError(err.Error())
+		return 1
+	}
+	return 0
+}
+
+// writeDeviceTable prints one row per device
+func writeDeviceTable(devices []hubbleapi.Device) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tID\tBOARD\tCREATED\tLAST SEEN")
+	for _, d := range devices {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", orDash(d.Name), d.ID, orDash(d.Board), formatDeviceTime(&d.CreatedAt), formatDeviceTime(d.LastSeen))
+	}
+	w.Flush()
+}
+
+// writeDeviceDetails prints every field of one device
+func writeDeviceDetails(d hubbleapi.Device) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", orDash(d.Name))
+	fmt.Fprintf(w, "ID:\t%s\n", d.ID)
+	fmt.Fprintf(w, "Board:\t%s\n", orDash(d.Board))
+	fmt.Fprintf(w, "Created:\t%s\n", formatDeviceTime(&d.CreatedAt))
+	fmt.Fprintf(w, "Last seen:\t%s\n", formatDeviceTime(d.LastSeen))
+	w.Flush()
+}
+
+// formatDeviceTime shows a time in the local zone, or "never" if unset
+func formatDeviceTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "never"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// orDash stands in for an empty table cell
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// existingDeviceNames returns the names of the devices already in the
+// organization. ok is false when there is no API client or the list could
+// not be fetched, in which case names can't be checked for duplicates.
+func existingDeviceNames(ctx context.Context, api *hubbleapi.Client) (names []string, ok bool) {
+	if api == nil {
+		return nil, false
+	}
+
+	listCtx, cancel := context.WithTimeout(ctx, timeoutDeviceList)
+	defer cancel()
+	devices, err := api.ListDevices(listCtx)
+	if err != nil {
+		exitIfInterrupted(ctx)
+		ui.PrintWarning(fmt.Sprintf("Could not list the devices in your organization: %v", err))
+		ui.PrintInfo("Device names won't be checked for duplicates.")
+		return nil, false
+	}
+
+	names = make([]string, 0, len(devices))
+	for _, d := range devices {
+		names = append(names, d.Name)
+	}
+	return names, true
+}
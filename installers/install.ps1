@@ -10,23 +10,28 @@ param(
 # Set error action preference
 $ErrorActionPreference = "Stop"
 
-# Accept credentials as parameter (base64 encoded org_id:api_key)
+# Accept credentials as parameter (base64 encoded org_id:api_key, or a
+# hubble2. token)
 if ($Credentials) {
     $ValidationFailed = $false
     
-    try {
-        # Validate base64 format and decode
-        $DecodedBytes = [System.Convert]::FromBase64String($Credentials)
-        $DecodedString = [System.Text.Encoding]::UTF8.GetString($DecodedBytes)
-        
-        # Validate format (should contain a colon)
-        if (-not $DecodedString.Contains(':')) {
+    # v2 credentials (hubble2.<payload>.<checksum>) are checked by the installer,
+    # which reports exactly what is wrong with them
+    if (-not $Credentials.StartsWith("hubble2.")) {
+        try {
+            # Validate base64 format and decode
+            $DecodedBytes = [System.Convert]::FromBase64String($Credentials)
+            $DecodedString = [System.Text.Encoding]::UTF8.GetString($DecodedBytes)
+
+            # Validate format (should contain a colon)
+            if (-not $DecodedString.Contains(':')) {
+                $ValidationFailed = $true
+            }
+        } catch {
             $ValidationFailed = $true
         }
-    } catch {
-        $ValidationFailed = $true
     }
-    
+
     if ($ValidationFailed) {
         Write-Host ""
         Write-Host "⚠️  We were unable to validate your credentials." -ForegroundColor Yellow
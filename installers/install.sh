@@ -6,12 +6,17 @@
 
 set -e
 
-# Accept credentials as first argument (base64 encoded org_id:api_key)
+# Accept credentials as first argument (base64 encoded org_id:api_key, or a
+# hubble2. token)
 if [ -n "$1" ]; then
     VALIDATION_FAILED=0
     
+    # v2 credentials (hubble2.<payload>.<checksum>) are checked by the installer,
+    # which reports exactly what is wrong with them
+    if [[ "$1" == hubble2.* ]]; then
+        :
     # Validate base64 format
-    if ! echo "$1" | base64 -d > /dev/null 2>&1; then
+    elif ! echo "$1" | base64 -d > /dev/null 2>&1; then
         VALIDATION_FAILED=1
     else
         # Decode and validate format (should contain a colon)
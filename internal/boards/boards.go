@@ -8,6 +8,12 @@ const (
 	FlashMethodUniflash = "uniflash" // Generate hex file for TI Uniflash
 )
 
+// Region is a span of target memory
+type Region struct {
+	Start uint32
+	Size  uint32
+}
+
 // Board represents a developer board that can be flashed
 type Board struct {
 	ID          string
@@ -15,6 +21,18 @@ type Board struct {
 	Description string
 	Vendor      string
 	FlashMethod string // "jlink" or "uniflash"
+
+	// Target memory map, used to check flashed and generated images
+	FlashStart uint32
+	FlashSize  uint32
+	RAMStart   uint32
+	RAMSize    uint32
+
+	// Non-volatile configuration areas outside main flash that images may
+	// also program, such as TI's CCFG
+	ConfigRegions []Region
+
+	JLinkDevice string // Target name for J-Link Commander (J-Link boards only)
 }
 
 // RequiresJLink returns true if this board requires SEGGER J-Link
@@ -22,6 +40,15 @@ func (b *Board) RequiresJLink() bool {
 	return b.FlashMethod == FlashMethodJLink
 }
 
+// FlashRegions returns every area a firmware image may program: main
+// flash and any configuration areas. It is empty if the map is unknown.
+func (b *Board) FlashRegions() []Region {
+	if b.FlashSize == 0 {
+		return nil
+	}
+	return append([]Region{{Start: b.FlashStart, Size: b.FlashSize}}, b.ConfigRegions...)
+}
+
 // GetDependencies returns the list of dependencies required for this board
 func (b *Board) GetDependencies() []string {
 	if b.RequiresJLink() {
@@ -42,6 +69,10 @@ var AvailableBoards = []Board{
 		Description: "Nordic Semiconductor nRF21540 Development Kit",
 		Vendor:      "Nordic",
 		FlashMethod: FlashMethodJLink,
+		FlashSize:   1024 << 10,
+		RAMStart:    0x20000000,
+		RAMSize:     256 << 10,
+		JLinkDevice: "nRF52840_xxAA",
 	},
 	{
 		ID:          "nrf52840dk",
@@ -49,6 +80,10 @@ var AvailableBoards = []Board{
 		Description: "Nordic Semiconductor nRF52840 Development Kit",
 		Vendor:      "Nordic",
 		FlashMethod: FlashMethodJLink,
+		FlashSize:   1024 << 10,
+		RAMStart:    0x20000000,
+		RAMSize:     256 << 10,
+		JLinkDevice: "nRF52840_xxAA",
 	},
 	{
 		ID:          "lp_em_cc2340r5",
@@ -56,6 +91,13 @@ var AvailableBoards = []Board{
 		Description: "Texas Instruments CC2340R5 LaunchPad",
 		Vendor:      "Texas Instruments",
 		FlashMethod: FlashMethodUniflash,
+		FlashSize:   512 << 10,
+		RAMStart:    0x20000000,
+		RAMSize:     36 << 10,
+		ConfigRegions: []Region{
+			{Start: 0x4E020000, Size: 0x800}, // CCFG
+			{Start: 0x4E040000, Size: 0x400}, // SCFG
+		},
 	},
 	{
 		ID:          "lp_em_cc2340r53",
@@ -63,6 +105,13 @@ var AvailableBoards = []Board{
 		Description: "Texas Instruments CC2340R53 LaunchPad",
 		Vendor:      "Texas Instruments",
 		FlashMethod: FlashMethodUniflash,
+		FlashSize:   512 << 10,
+		RAMStart:    0x20000000,
+		RAMSize:     64 << 10,
+		ConfigRegions: []Region{
+			{Start: 0x4E020000, Size: 0x800}, // CCFG
+			{Start: 0x4E040000, Size: 0x400}, // SCFG
+		},
 	},
 	// {
 	// 	ID:          "xg22_ek4108a",
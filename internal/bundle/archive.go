@@ -0,0 +1,240 @@
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// writeArchive packs the contents of srcDir into a gzipped tarball at destPath
+func writeArchive(srcDir, destPath string) error {
+	out, err := os.Create(destPath)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	err = filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+
+		// Symlinks are preserved (the bundled Python install relies on them)
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finalize archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to finalize archive: %w", err)
+	}
+
+	return nil
+}
+
+// extractArchive unpacks a gzipped tarball into destDir, rejecting entries
+// that would escape the destination directory
+func extractArchive(archivePath, destDir string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("not a gzip archive: %w", err)
+	}
+	defer gz.Close()
+
+	// Names are checked as written, and again after following the links
+	// extracted so far, since a chain of links can leave the directory even
+	// when each one looks contained
+	root, err := filepath.EvalSymlinks(destDir)
+	if err != nil {
+		return err
+	}
+	var links []string
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		target, err := safeJoin(destDir, header.Name)
+		if err != nil {
+			return err
+		}
+		if !within(root, target) {
+			return fmt.Errorf("archive entry %s escapes the bundle directory through a link", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode)&0755|0600)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(out, tr); err != nil {
+				out.Close()
+				return err
+			}
+			if err := out.Close(); err != nil {
+				return err
+			}
+
+		case tar.TypeSymlink:
+			// Only relative links that stay inside the bundle are allowed
+			if filepath.IsAbs(header.Linkname) {
+				return fmt.Errorf("archive entry %s links outside the bundle", header.Name)
+			}
+			if _, err := safeJoin(destDir, filepath.Join(filepath.Dir(header.Name), header.Linkname)); err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+			links = append(links, header.Name)
+
+		default:
+			return fmt.Errorf("unsupported archive entry type for %s", header.Name)
+		}
+	}
+
+	for _, name := range links {
+		if !within(root, filepath.Join(destDir, filepath.FromSlash(name))) {
+			return fmt.Errorf("archive entry %s links outside the bundle", name)
+		}
+	}
+	return nil
+}
+
+// safeJoin joins name onto dir and ensures the result stays within dir
+func safeJoin(dir, name string) (string, error) {
+	if strings.HasPrefix(name, "/") || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("archive entry %s has an absolute path", name)
+	}
+	target := filepath.Join(dir, filepath.FromSlash(name))
+	rel, err := filepath.Rel(dir, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("archive entry %s escapes the bundle directory", name)
+	}
+	return target, nil
+}
+
+// within reports whether path, with every link in it followed, is inside
+// root. root must already have its own links resolved. Links are followed one
+// component at a time like the OS does, since cleaning a path such as
+// "link/.." lexically gives a different answer; dangling links are followed
+// too, as they may be created later.
+func within(root, path string) bool {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	volume := filepath.VolumeName(path)
+	resolved := volume + string(filepath.Separator)
+	pending := strings.Split(path[len(volume):], string(filepath.Separator))
+	for hops := 0; len(pending) > 0; {
+		name := pending[0]
+		pending = pending[1:]
+		switch name {
+		case "", ".":
+			continue
+		case "..":
+			// resolved contains no links, so its parent is found lexically
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+
+		next := filepath.Join(resolved, name)
+		link, err := os.Readlink(next)
+		if err != nil {
+			// A directory, a file, or a path that doesn't exist yet
+			resolved = next
+			continue
+		}
+		if hops++; hops > 255 {
+			return false
+		}
+		if filepath.IsAbs(link) {
+			volume = filepath.VolumeName(link)
+			resolved = volume + string(filepath.Separator)
+			link = link[len(volume):]
+		}
+		pending = append(strings.Split(filepath.FromSlash(link), string(filepath.Separator)), pending...)
+	}
+
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(root, resolved)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,134 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// entry is one member of a test archive: a directory (name ends in "/"), a
+// symlink (link set) or a regular file
+type entry struct {
+	name string
+	link string
+	body string
+}
+
+// writeTestArchive writes entries to a gzipped tarball and returns its path
+func writeTestArchive(t *testing.T, entries []entry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(e.body))}
+		switch {
+		case strings.HasSuffix(e.name, "/"):
+			header.Typeflag, header.Mode, header.Size = tar.TypeDir, 0755, 0
+		case e.link != "":
+			header.Typeflag, header.Linkname, header.Size = tar.TypeSymlink, e.link, 0
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExtractArchive(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []entry
+		want    string // Substring of the error; empty for success
+	}{
+		{
+			name: "files, directories and contained links",
+			entries: []entry{
+				{name: "python/"},
+				{name: "python/bin/python3.11", body: "#!"},
+				{name: "python/bin/python3", link: "python3.11"},
+				{name: "python/lib", link: "../lib"},
+				{name: "lib/"},
+			},
+		},
+		{name: "parent directory", entries: []entry{{name: "../evil", body: "x"}}, want: "escapes the bundle directory"},
+		{name: "parent directory inside the path", entries: []entry{{name: "tools/../../evil", body: "x"}}, want: "escapes the bundle directory"},
+		{name: "absolute path", entries: []entry{{name: "/tmp/evil", body: "x"}}, want: "absolute path"},
+		{name: "absolute link", entries: []entry{{name: "evil", link: "/etc"}}, want: "links outside the bundle"},
+		{name: "link to the parent directory", entries: []entry{{name: "evil", link: "../outside"}}, want: "escapes the bundle directory"},
+		{name: "nested link to the parent directory", entries: []entry{{name: "a/b/evil", link: "../../.."}}, want: "escapes the bundle directory"},
+		{
+			name:    "chained links",
+			entries: []entry{{name: "here", link: "."}, {name: "up", link: "here/.."}},
+			want:    "up links outside the bundle",
+		},
+		{
+			name:    "file written through a link",
+			entries: []entry{{name: "here", link: "."}, {name: "up", link: "here/.."}, {name: "up/evil", body: "x"}},
+			want:    "escapes the bundle directory through a link",
+		},
+		{
+			name:    "dangling chained link",
+			entries: []entry{{name: "here", link: "."}, {name: "up", link: "here/../evil"}},
+			want:    "up links outside the bundle",
+		},
+		{
+			name:    "link loop",
+			entries: []entry{{name: "a", link: "b"}, {name: "b", link: "a"}},
+			want:    "links outside the bundle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, e := range tt.entries {
+				if e.link != "" && runtime.GOOS == "windows" {
+					t.Skip("creating symlinks needs extra privileges on Windows")
+				}
+			}
+			parent := t.TempDir()
+			dest := filepath.Join(parent, "bundle")
+			if err := os.Mkdir(dest, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			err := extractArchive(writeTestArchive(t, tt.entries), dest)
+
+			if _, statErr := os.Lstat(filepath.Join(parent, "evil")); statErr == nil {
+				t.Error("a file was written outside the bundle directory")
+			}
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("extractArchive() = %v", err)
+				}
+				data, err := os.ReadFile(filepath.Join(dest, "python", "bin", "python3"))
+				if err != nil || string(data) != "#!" {
+					t.Errorf("reading through the link = %q, %v", data, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("extractArchive() = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/HubbleNetwork/hubble-install/internal/installed"
+	"github.com/HubbleNetwork/hubble-install/internal/runner"
+	"github.com/HubbleNetwork/hubble-install/internal/ui"
+)
+
+// FormatVersion is the current bundle layout version
+const FormatVersion = 1
+
+// Paths inside a bundle archive
+const (
+	manifestFile = "manifest.json"
+	binDir       = "bin"
+	wheelsDir    = "wheels"
+	pythonDir    = "python"
+	jlinkDir     = "jlink"
+)
+
+// Manifest describes the contents of an offline bundle
+type Manifest struct {
+	FormatVersion  int      `json:"format_version"`
+	CreatedAt      string   `json:"created_at"`
+	OS             string   `json:"os"`
+	Arch           string   `json:"arch"`
+	Boards         []string `json:"boards"`
+	Dependencies   []string `json:"dependencies"`
+	ToolVersion    string   `json:"tool_version,omitempty"`    // pyhubbledemo version included in wheels/
+	JLinkInstaller string   `json:"jlink_installer,omitempty"` // file name inside jlink/, if supplied
+}
+
+// Bundle is an extracted offline bundle ready to be used for installation
+type Bundle struct {
+	Dir      string
+	Manifest Manifest
+}
+
+// Open extracts the bundle archive at path and validates it for this machine
+func Open(path string) (*Bundle, error) {
+	dir, err := extractDir()
+	if err != nil {
+		return nil, err
+	}
+
+	// Start from a clean directory so files from an older bundle can't leak in
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("failed to clear bundle directory: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create bundle directory: %w", err)
+	}
+
+	if err := extractArchive(path, dir); err != nil {
+		return nil, fmt.Errorf("failed to extract bundle: %w", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("bundle has no manifest: %w", err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("bundle manifest is invalid: %w", err)
+	}
+
+	if manifest.FormatVersion != FormatVersion {
+		return nil, fmt.Errorf("unsupported bundle format version %d (this installer supports %d)", manifest.FormatVersion, FormatVersion)
+	}
+	if manifest.OS != runtime.GOOS || manifest.Arch != runtime.GOARCH {
+		return nil, fmt.Errorf("bundle was created for %s/%s but this machine is %s/%s", manifest.OS, manifest.Arch, runtime.GOOS, runtime.GOARCH)
+	}
+
+	return &Bundle{Dir: dir, Manifest: manifest}, nil
+}
+
+// SupportsBoard reports whether the bundle was created for the given board
+func (b *Bundle) SupportsBoard(boardID string) bool {
+	for _, id := range b.Manifest.Boards {
+		if id == boardID {
+			return true
+		}
+	}
+	return false
+}
+
+// Activate configures this process (and every subprocess it starts) to use
+// only the bundle contents: bundled binaries come first on PATH and uv is
+// restricted to the bundled wheels and Python interpreter.
+func (b *Bundle) Activate() error {
+	bin := filepath.Join(b.Dir, binDir)
+	os.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	settings := map[string]string{
+		"UV_OFFLINE":            "1",
+		"UV_NO_INDEX":           "1",
+		"UV_FIND_LINKS":         filepath.Join(b.Dir, wheelsDir),
+		"UV_PYTHON_INSTALL_DIR": filepath.Join(b.Dir, pythonDir),
+		"UV_PYTHON_PREFERENCE":  "only-managed",
+		"UV_PYTHON_DOWNLOADS":   "never",
+	}
+	for key, value := range settings {
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+	}
+
+	if _, err := exec.LookPath("uv"); err != nil {
+		return fmt.Errorf("bundle does not contain a usable uv binary: %w", err)
+	}
+
+	return nil
+}
+
+// InstallDependencies installs missing dependencies from the bundle without network access
+func (b *Bundle) InstallDependencies(ctx context.Context, missing []string) error {
+	for _, dep := range missing {
+		switch dep {
+		case "uv":
+			// Provided by bin/ once the bundle is activated
+			if _, err := exec.LookPath("uv"); err != nil {
+				return fmt.Errorf("uv not found in bundle: %w", err)
+			}
+			ui.PrintSuccess("uv available from bundle")
+
+		case "nrfutil":
+			if err := b.installNRFUtil(ctx); err != nil {
+				return fmt.Errorf("failed to install nrfutil from bundle: %w", err)
+			}
+			ui.PrintSuccess("nrfutil installed from bundle")
+
+		case "segger-jlink":
+			if b.Manifest.JLinkInstaller == "" {
+				ui.PrintError("SEGGER J-Link is not installed and the bundle does not include its installer")
+				ui.PrintInfo("Re-create the bundle with --jlink <installer> or install J-Link manually")
+				return fmt.Errorf("J-Link must be installed before running this installer")
+			}
+			if err := b.installJLink(ctx); err != nil {
+				return fmt.Errorf("failed to install J-Link from bundle: %w", err)
+			}
+			ui.PrintSuccess("segger-jlink installed from bundle")
+		}
+	}
+
+	return nil
+}
+
+// installNRFUtil makes nrfutil available from the bundle
+func (b *Bundle) installNRFUtil(ctx context.Context) error {
+	// On Windows nrfutil is a standalone binary shipped in bin/
+	if runtime.GOOS == "windows" {
+		if _, err := exec.LookPath("nrfutil"); err != nil {
+			return fmt.Errorf("nrfutil.exe not found in bundle: %w", err)
+		}
+		return nil
+	}
+
+	// Elsewhere it is a Python tool installed from the bundled wheels
+	ui.PrintInfo("Installing nrfutil (via uv tool install, offline)...")
+	cmd := &runner.Command{Name: "uv", Args: []string{"tool", "install", "nrfutil"}, Stdout: os.Stdout, Stderr: os.Stderr}
+	if err := runner.New().Run(ctx, cmd); err != nil {
+		return err
+	}
+	installed.Note("nrfutil", installed.MethodUVTool, "")
+	return nil
+}
+
+// installJLink runs the J-Link installer shipped in the bundle
+func (b *Bundle) installJLink(ctx context.Context) error {
+	installer := filepath.Join(b.Dir, jlinkDir, b.Manifest.JLinkInstaller)
+	ui.PrintInfo(fmt.Sprintf("Running bundled J-Link installer: %s", b.Manifest.JLinkInstaller))
+
+	cmd := &runner.Command{Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr}
+	switch ext := strings.ToLower(filepath.Ext(installer)); ext {
+	case ".exe":
+		cmd.Name, cmd.Args = installer, []string{"/S", "/ACCEPTLICENSE=yes"}
+	case ".pkg":
+		cmd.Name, cmd.Args = "sudo", []string{"installer", "-pkg", installer, "-target", "/"}
+	case ".deb":
+		cmd.Name, cmd.Args = "sudo", []string{"dpkg", "-i", installer}
+	case ".rpm":
+		cmd.Name, cmd.Args = "sudo", []string{"rpm", "-i", installer}
+	default:
+		return fmt.Errorf("unsupported J-Link installer type: %s", ext)
+	}
+
+	if err := runner.New().Run(ctx, cmd); err != nil {
+		return err
+	}
+	installed.Note("segger-jlink", installed.MethodManual, installer)
+	return nil
+}
+
+// extractDir returns the directory bundles are extracted into. It is kept
+// across runs because uv tool environments reference the bundled Python.
+func extractDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+	return filepath.Join(cacheDir, "hubble-install", "bundle"), nil
+}
@@ -0,0 +1,219 @@
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/HubbleNetwork/hubble-install/internal/boards"
+	"github.com/HubbleNetwork/hubble-install/internal/hubbledemo"
+	"github.com/HubbleNetwork/hubble-install/internal/pinned"
+	"github.com/HubbleNetwork/hubble-install/internal/runner"
+	"github.com/HubbleNetwork/hubble-install/internal/ui"
+)
+
+// CreateOptions configures bundle creation
+type CreateOptions struct {
+	Output         string         // Path of the archive to write
+	Boards         []boards.Board // Boards the bundle must be able to provision
+	JLinkInstaller string         // Optional path to a SEGGER J-Link installer
+}
+
+// Create collects everything needed to provision the given boards on this
+// OS/architecture into a single archive. It must be run on a machine with
+// network access and uv installed.
+func Create(ctx context.Context, opts CreateOptions) (*Manifest, error) {
+	if len(opts.Boards) == 0 {
+		return nil, fmt.Errorf("no boards selected")
+	}
+
+	uvPath, err := exec.LookPath("uv")
+	if err != nil {
+		return nil, fmt.Errorf("uv not found in PATH (run hubble-install once on this machine to install it): %w", err)
+	}
+
+	staging, err := os.MkdirTemp("", "hubble-bundle-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	manifest := &Manifest{
+		FormatVersion: FormatVersion,
+		CreatedAt:     time.Now().UTC().Format(time.RFC3339),
+		OS:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+	}
+
+	// Collect the union of dependencies for the selected boards
+	depSet := map[string]bool{}
+	for _, board := range opts.Boards {
+		manifest.Boards = append(manifest.Boards, board.ID)
+		for _, dep := range board.GetDependencies() {
+			depSet[dep] = true
+		}
+	}
+	for dep := range depSet {
+		manifest.Dependencies = append(manifest.Dependencies, dep)
+	}
+	sort.Strings(manifest.Dependencies)
+
+	// uv binary
+	ui.PrintInfo("Adding uv...")
+	uvName := "uv"
+	if runtime.GOOS == "windows" {
+		uvName = "uv.exe"
+	}
+	if err := copyFile(uvPath, filepath.Join(staging, binDir, uvName), 0755); err != nil {
+		return nil, fmt.Errorf("failed to copy uv: %w", err)
+	}
+
+	// Managed Python interpreter for running the tools offline
+	ui.PrintInfo("Adding Python interpreter...")
+	pythonPath, err := stagePython(ctx, uvPath, filepath.Join(staging, pythonDir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to add Python: %w", err)
+	}
+
+	// Wheels for the pyhubbledemo version the installer would run (honoring
+	// --tool-version and --lock-file), and nrfutil where it is a Python tool
+	toolVersion, err := hubbledemo.Prepare(ctx, runner.New(), uvPath)
+	if err != nil {
+		return nil, err
+	}
+	wheels := filepath.Join(staging, wheelsDir)
+	ui.PrintInfo(fmt.Sprintf("Downloading wheels: %s...", hubbledemo.Spec()))
+	requirements := []string{hubbledemo.Spec()}
+	if lock := hubbledemo.LockFile(); lock != "" {
+		// The lock pins every dependency with hashes, which pip then checks
+		requirements = []string{"-r", lock}
+	}
+	if err := downloadWheels(ctx, uvPath, pythonPath, staging, requirements...); err != nil {
+		return nil, err
+	}
+	if depSet["nrfutil"] && runtime.GOOS != "windows" {
+		ui.PrintInfo("Downloading wheels: nrfutil...")
+		if err := downloadWheels(ctx, uvPath, pythonPath, staging, "nrfutil"); err != nil {
+			return nil, err
+		}
+	}
+	manifest.ToolVersion = wheelVersion(wheels, hubbledemo.Package)
+	if manifest.ToolVersion == "" {
+		manifest.ToolVersion = toolVersion
+	}
+
+	// On Windows nrfutil is a standalone binary rather than a Python tool
+	if depSet["nrfutil"] && runtime.GOOS == "windows" {
+		ui.PrintInfo("Downloading nrfutil...")
+		if err := pinned.Download(ctx, runner.New(), pinned.NRFUtilWindows, filepath.Join(staging, binDir, "nrfutil.exe")); err != nil {
+			return nil, fmt.Errorf("failed to download nrfutil: %w", err)
+		}
+	}
+
+	// J-Link can't be redistributed, so it is only included when supplied
+	if opts.JLinkInstaller != "" {
+		name := filepath.Base(opts.JLinkInstaller)
+		if err := copyFile(opts.JLinkInstaller, filepath.Join(staging, jlinkDir, name), 0755); err != nil {
+			return nil, fmt.Errorf("failed to add J-Link installer: %w", err)
+		}
+		manifest.JLinkInstaller = name
+	} else if depSet["segger-jlink"] {
+		ui.PrintWarning("No J-Link installer supplied (--jlink); target machines must already have J-Link installed")
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(staging, manifestFile), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	ui.PrintInfo(fmt.Sprintf("Writing %s...", opts.Output))
+	if err := writeArchive(staging, opts.Output); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// downloadWheels runs "pip download" with the staged interpreter, so the
+// wheels match the Python the bundle ships
+func downloadWheels(ctx context.Context, uvPath, pythonPath, staging string, requirements ...string) error {
+	args := []string{"tool", "run", "--python", pythonPath, "--from", "pip", "pip", "download",
+		"--dest", filepath.Join(staging, wheelsDir), "--only-binary=:all:"}
+	cmd := &runner.Command{
+		Name:   uvPath,
+		Args:   append(args, requirements...),
+		Env:    []string{"UV_PYTHON_INSTALL_DIR=" + filepath.Join(staging, pythonDir)},
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+	if err := runner.New().Run(ctx, cmd); err != nil {
+		return fmt.Errorf("failed to download wheels: %w", err)
+	}
+	return nil
+}
+
+// stagePython installs a uv-managed Python into dir and returns the interpreter path
+func stagePython(ctx context.Context, uvPath, dir string) (string, error) {
+	r := runner.New()
+	env := []string{"UV_PYTHON_INSTALL_DIR=" + dir}
+
+	install := &runner.Command{Name: uvPath, Args: []string{"python", "install"}, Env: env, Stdout: os.Stdout, Stderr: os.Stderr}
+	if err := r.Run(ctx, install); err != nil {
+		return "", err
+	}
+
+	find := &runner.Command{Name: uvPath, Args: []string{"python", "find", "--python-preference", "only-managed"}, Env: env}
+	output, err := r.Output(ctx, find)
+	if err != nil {
+		return "", fmt.Errorf("installed Python not found: %w", err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// wheelVersion returns the version of the named package among the wheels in dir
+func wheelVersion(dir, pkg string) string {
+	matches, _ := filepath.Glob(filepath.Join(dir, pkg+"-*.whl"))
+	if len(matches) == 0 {
+		return ""
+	}
+	// Wheel file names are {name}-{version}-{tags}.whl
+	parts := strings.Split(filepath.Base(matches[0]), "-")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[1]
+}
+
+// copyFile copies src to dest, creating parent directories as needed
+func copyFile(src, dest string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return err
+}
@@ -1,20 +1,57 @@
 package config
 
 import (
-	"encoding/base64"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
-	"github.com/HubbleNetwork/hubble-install/internal/boards"
 	"github.com/HubbleNetwork/hubble-install/internal/ui"
 )
 
+// Credential sources, recorded so a resumed installation can read the
+// credentials again from the same place
+const (
+	SourceCredentials = "HUBBLE_CREDENTIALS" // v1 or v2 credentials from the dashboard command
+	SourceEnvironment = "environment"        // HUBBLE_ORG_ID and HUBBLE_API_TOKEN
+	SourcePrompt      = "prompt"             // Entered interactively
+)
+
 // Config holds the Hubble configuration
 type Config struct {
 	OrgID    string
 	APIToken string
 	Board    string
+	Source   string // Where the credentials came from (one of the Source* constants)
+
+	// Set by v2 HUBBLE_CREDENTIALS; APIEnv defaults to DefaultAPIEnv
+	APIEnv       string
+	DevicePrefix string
+}
+
+// DetectSource returns the credential source PromptForConfig would use now
+func DetectSource() string {
+	if os.Getenv("HUBBLE_CREDENTIALS") != "" {
+		return SourceCredentials
+	}
+	if os.Getenv("HUBBLE_ORG_ID") != "" && os.Getenv("HUBBLE_API_TOKEN") != "" {
+		return SourceEnvironment
+	}
+	return SourcePrompt
+}
+
+// EnvironmentSecrets returns every credential value found in the environment,
+// including the decoded contents of HUBBLE_CREDENTIALS, so they can be
+// redacted from logs before any of them is used
+func EnvironmentSecrets() []string {
+	secrets := []string{os.Getenv("HUBBLE_ORG_ID"), os.Getenv("HUBBLE_API_TOKEN")}
+
+	if encoded := os.Getenv("HUBBLE_CREDENTIALS"); encoded != "" {
+		secrets = append(secrets, encoded)
+		secrets = append(secrets, credentialSecrets(encoded)...)
+	}
+
+	return secrets
 }
 
 // validateCredentials checks if the credentials have the expected format
@@ -67,40 +104,28 @@ func min(a, b int) int {
 // PromptForConfig prompts the user for all required configuration
 // Returns the config and a boolean indicating if credentials were pre-configured
 func PromptForConfig() (*Config, bool, error) {
-	config := &Config{}
+	config := &Config{APIEnv: DefaultAPIEnv}
 	preConfigured := false
 
-	// Check for base64 encoded credentials first (passed from install.sh)
-	// Format: org_id:api_key or org_id:api_key:board_id
+	// Check for credentials from the dashboard command first (passed from install.sh).
+	// A value that can't be read is an error rather than a reason to prompt, so
+	// a truncated paste is reported as such.
 	if encodedCreds := os.Getenv("HUBBLE_CREDENTIALS"); encodedCreds != "" {
-		decoded, err := base64.StdEncoding.DecodeString(encodedCreds)
-		if err == nil {
-			parts := strings.SplitN(string(decoded), ":", 3)
-			if len(parts) >= 2 {
-				config.OrgID = strings.TrimSpace(parts[0])
-				config.APIToken = strings.TrimSpace(parts[1])
-				if config.OrgID != "" && config.APIToken != "" {
-					// Validate credential format
-					if err := validateCredentials(config.OrgID, config.APIToken); err != nil {
-						return nil, false, fmt.Errorf("invalid credentials from HUBBLE_CREDENTIALS: %w", err)
-					}
-					// Parse optional board_id (third parameter)
-					if len(parts) == 3 {
-						boardID := strings.TrimSpace(parts[2])
-						if boardID != "" {
-							// Validate board ID exists and resolve to canonical ID
-							board, err := boards.GetBoard(boardID)
-							if err != nil {
-								return nil, false, fmt.Errorf("invalid board_id from HUBBLE_CREDENTIALS: %w", err)
-							}
-							config.Board = board.ID
-						}
-					}
-					preConfigured = true
-					return config, preConfigured, nil
-				}
-			}
+		creds, err := ParseCredentials(encodedCreds, time.Now())
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid HUBBLE_CREDENTIALS: %w", err)
+		}
+		config.OrgID = creds.OrgID
+		config.APIToken = creds.APIToken
+		config.Board = creds.Board
+		config.APIEnv = creds.APIEnv
+		config.DevicePrefix = creds.DevicePrefix
+		config.Source = SourceCredentials
+		preConfigured = true
+		if creds.ExpiresAt != nil {
+			ui.PrintInfo(fmt.Sprintf("Credentials valid until %s", creds.ExpiresAt.Local().Format("2006-01-02 15:04 MST")))
 		}
+		return config, preConfigured, nil
 	}
 
 	// Check environment variables
@@ -115,6 +140,7 @@ func PromptForConfig() (*Config, bool, error) {
 		if err := validateCredentials(config.OrgID, config.APIToken); err != nil {
 			return nil, false, fmt.Errorf("invalid credentials from environment: %w", err)
 		}
+		config.Source = SourceEnvironment
 		preConfigured = true
 		ui.PrintSuccess("Credentials found in environment")
 		return config, preConfigured, nil
@@ -122,7 +148,7 @@ func PromptForConfig() (*Config, bool, error) {
 
 	// Print info about where to find credentials
 	ui.PrintInfo("Get your credentials at: https://dash.hubble.com/developer/api-tokens")
-	fmt.Println()
+	ui.PrintBlank()
 
 	// Prompt for Org ID (if not in environment)
 	if envOrgID != "" {
@@ -161,6 +187,7 @@ func PromptForConfig() (*Config, bool, error) {
 		return nil, false, fmt.Errorf("invalid credentials: %w. Please check the format at https://dash.hubble.com/developer/api-tokens", err)
 	}
 
+	config.Source = SourcePrompt
 	ui.PrintSuccess("Credentials configured")
 
 	return config, preConfigured, nil
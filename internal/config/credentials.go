@@ -0,0 +1,214 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/HubbleNetwork/hubble-install/internal/boards"
+)
+
+// CredentialsPrefix starts a v2 HUBBLE_CREDENTIALS value:
+//
+//	hubble2.<base64url JSON payload>.<checksum>
+//
+// The checksum is the first 8 bytes of the SHA-256 of the payload segment, in
+// hex, so a value cut off anywhere is detected. Any other value is read as v1:
+// base64 of "org_id:api_token[:board]".
+const CredentialsPrefix = "hubble2."
+
+// checksumLength is the number of hex characters in a v2 checksum
+const checksumLength = 16
+
+// DefaultAPIEnv is the API environment used when the credentials don't name one
+const DefaultAPIEnv = "production"
+
+// Credentials are the decoded contents of HUBBLE_CREDENTIALS
+type Credentials struct {
+	Version      int        `json:"v"`
+	OrgID        string     `json:"org_id"`
+	APIToken     string     `json:"api_token"`
+	Board        string     `json:"board,omitempty"`
+	APIEnv       string     `json:"api_env,omitempty"`       // v2 only
+	DevicePrefix string     `json:"device_prefix,omitempty"` // v2 only
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`    // v2 only
+}
+
+// apiEnvPattern and devicePrefixPattern restrict the free-form v2 fields
+var (
+	apiEnvPattern       = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+	devicePrefixPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+)
+
+// ParseCredentials decodes a v1 or v2 HUBBLE_CREDENTIALS value. Errors say
+// which part of the value is wrong; nothing is guessed or filled in.
+func ParseCredentials(value string, now time.Time) (*Credentials, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, CredentialsPrefix) {
+		return parseV2(value, now)
+	}
+	if strings.HasPrefix(value, "hubble") && strings.Contains(value, ".") {
+		prefix, _, _ := strings.Cut(value, ".")
+		return nil, fmt.Errorf("unsupported credentials format %q; this installer understands %q (update the installer if the dashboard issued a newer format)", prefix, strings.TrimSuffix(CredentialsPrefix, "."))
+	}
+	return parseV1(value)
+}
+
+// parseV1 decodes base64 "org_id:api_token[:board]"
+func parseV1(value string) (*Credentials, error) {
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		var corrupt base64.CorruptInputError
+		if errors.As(err, &corrupt) && int(corrupt) >= len(value)-3 && len(value)%4 != 0 {
+			return nil, fmt.Errorf("value ends early (%d characters, base64 comes in groups of 4); it was probably cut off when pasted", len(value))
+		}
+		if errors.As(err, &corrupt) {
+			return nil, fmt.Errorf("not valid base64: unexpected character at position %d", int(corrupt)+1)
+		}
+		return nil, fmt.Errorf("not valid base64: %w", err)
+	}
+
+	parts := strings.SplitN(string(decoded), ":", 3)
+	if len(parts) < 2 {
+		return nil, errors.New("decoded value has no ':' separator; expected org_id:api_token or org_id:api_token:board")
+	}
+
+	c := &Credentials{
+		Version:  1,
+		OrgID:    strings.TrimSpace(parts[0]),
+		APIToken: strings.TrimSpace(parts[1]),
+		APIEnv:   DefaultAPIEnv,
+	}
+	if len(parts) == 3 {
+		c.Board = strings.TrimSpace(parts[2])
+	}
+	if err := c.validate(); err != nil {
+		if len(parts) == 2 && len(c.APIToken) < 32 {
+			return nil, fmt.Errorf("%w; the value was probably cut off when pasted", err)
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// parseV2 decodes and checks a "hubble2." token
+func parseV2(value string, now time.Time) (*Credentials, error) {
+	segments := strings.Split(strings.TrimPrefix(value, CredentialsPrefix), ".")
+	if len(segments) == 1 {
+		return nil, errors.New("checksum is missing; the value was probably cut off when pasted")
+	}
+	if len(segments) != 2 {
+		return nil, fmt.Errorf("expected 3 dot-separated parts, found %d", len(segments)+1)
+	}
+	payload, checksum := segments[0], segments[1]
+
+	if len(checksum) < checksumLength {
+		return nil, fmt.Errorf("checksum has %d of %d characters; the value was probably cut off when pasted", len(checksum), checksumLength)
+	}
+	if len(checksum) > checksumLength {
+		return nil, fmt.Errorf("checksum has %d characters, expected %d; extra text was pasted after the value", len(checksum), checksumLength)
+	}
+	if !strings.EqualFold(checksum, credentialsChecksum(payload)) {
+		return nil, errors.New("checksum does not match; part of the value was changed or lost when pasted")
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, fmt.Errorf("payload is not valid base64url: %w", err)
+	}
+
+	var c Credentials
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&c); err != nil {
+		return nil, fmt.Errorf("payload is not valid: %w", err)
+	}
+	if c.Version != 2 {
+		return nil, fmt.Errorf("payload declares version %d, expected 2", c.Version)
+	}
+
+	if c.APIEnv == "" {
+		c.APIEnv = DefaultAPIEnv
+	} else if !apiEnvPattern.MatchString(c.APIEnv) {
+		return nil, fmt.Errorf("api_env %q is not a valid environment name", c.APIEnv)
+	}
+	if c.DevicePrefix != "" && !devicePrefixPattern.MatchString(c.DevicePrefix) {
+		return nil, fmt.Errorf("device_prefix %q may only contain letters, digits, '-' and '_'", c.DevicePrefix)
+	}
+	if c.ExpiresAt != nil && !now.Before(*c.ExpiresAt) {
+		return nil, fmt.Errorf("credentials expired at %s; copy a new install command from the dashboard", c.ExpiresAt.Local().Format("2006-01-02 15:04 MST"))
+	}
+
+	return &c, c.validate()
+}
+
+// validate checks the fields shared by both formats
+func (c *Credentials) validate() error {
+	switch {
+	case c.OrgID == "":
+		return errors.New("org_id is empty")
+	case c.APIToken == "":
+		return errors.New("api_token is empty")
+	}
+	if err := validateCredentials(c.OrgID, c.APIToken); err != nil {
+		return err
+	}
+	if c.Board != "" {
+		// Resolve to the canonical board ID
+		board, err := boards.GetBoard(c.Board)
+		if err != nil {
+			return fmt.Errorf("invalid board: %w", err)
+		}
+		c.Board = board.ID
+	}
+	return nil
+}
+
+// Encode returns the v2 form of the credentials
+func (c Credentials) Encode() (string, error) {
+	c.Version = 2
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return CredentialsPrefix + payload + "." + credentialsChecksum(payload), nil
+}
+
+// credentialsChecksum returns the checksum of a v2 payload segment
+func credentialsChecksum(payload string) string {
+	sum := sha256.Sum256([]byte(payload))
+	return hex.EncodeToString(sum[:checksumLength/2])
+}
+
+// credentialSecrets returns the org ID and token in a HUBBLE_CREDENTIALS value
+// without validating it, so even a rejected value is redacted from logs
+func credentialSecrets(value string) []string {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, CredentialsPrefix) {
+		payload, _, _ := strings.Cut(strings.TrimPrefix(value, CredentialsPrefix), ".")
+		data, err := base64.RawURLEncoding.DecodeString(payload)
+		if err != nil {
+			return nil
+		}
+		var c Credentials
+		if json.Unmarshal(data, &c) != nil {
+			return []string{string(data)}
+		}
+		return []string{string(data), c.OrgID, c.APIToken}
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil
+	}
+	// org_id:api_key[:board_id] - the board is not a secret
+	parts := strings.SplitN(string(decoded), ":", 3)
+	return append([]string{string(decoded)}, parts[:min(len(parts), 2)]...)
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testOrgID = "0f61efd0-24a7-4a2e-ae0f-8549d14ed901"
+	testToken = "eb31d24113fadb77c6d89d65a8007c0eed3595e2255aaf1d7d81783900ab33be4332457a27861f67cc78fe930ea52941"
+)
+
+var testNow = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// v1 encodes a v1 value
+func v1(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+// v2 wraps a raw JSON payload in a v2 value with a valid checksum
+func v2(payload string) string {
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return CredentialsPrefix + encoded + "." + credentialsChecksum(encoded)
+}
+
+func TestCredentialsRoundTrip(t *testing.T) {
+	expires := testNow.Add(24 * time.Hour)
+	tests := []struct {
+		name string
+		in   Credentials
+		want Credentials
+	}{
+		{
+			name: "minimal",
+			in:   Credentials{OrgID: testOrgID, APIToken: testToken},
+			want: Credentials{Version: 2, OrgID: testOrgID, APIToken: testToken, APIEnv: DefaultAPIEnv},
+		},
+		{
+			name: "every field",
+			in: Credentials{OrgID: testOrgID, APIToken: testToken, Board: "nrf52840dk",
+				APIEnv: "staging", DevicePrefix: "lab_3-", ExpiresAt: &expires},
+			want: Credentials{Version: 2, OrgID: testOrgID, APIToken: testToken, Board: "nrf52840dk",
+				APIEnv: "staging", DevicePrefix: "lab_3-", ExpiresAt: &expires},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := tt.in.Encode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(value, CredentialsPrefix) {
+				t.Fatalf("Encode() = %q, want the v2 prefix", value)
+			}
+
+			got, err := ParseCredentials("  "+value+"\n", testNow)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("ParseCredentials(Encode()) = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCredentialsV1(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  Credentials
+	}{
+		{
+			name:  "org and token",
+			value: v1(testOrgID + ":" + testToken),
+			want:  Credentials{Version: 1, OrgID: testOrgID, APIToken: testToken, APIEnv: DefaultAPIEnv},
+		},
+		{
+			name:  "with board",
+			value: v1(testOrgID + ":" + testToken + ":lp_em_cc2340r5"),
+			want:  Credentials{Version: 1, OrgID: testOrgID, APIToken: testToken, Board: "lp_em_cc2340r5", APIEnv: DefaultAPIEnv},
+		},
+		{
+			name:  "surrounding whitespace",
+			value: " " + v1(" "+testOrgID+" : "+testToken+" ") + "\n",
+			want:  Credentials{Version: 1, OrgID: testOrgID, APIToken: testToken, APIEnv: DefaultAPIEnv},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCredentials(tt.value, testNow)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("ParseCredentials() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCredentialsErrors(t *testing.T) {
+	valid := v1(testOrgID + ":" + testToken)
+	encoded, err := Credentials{OrgID: testOrgID, APIToken: testToken}.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, checksum, _ := strings.Cut(strings.TrimPrefix(encoded, CredentialsPrefix), ".")
+	expired := testNow.Add(-time.Minute).Format(time.RFC3339)
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		// Format detection
+		{"newer format", "hubble3.abc.def", `unsupported credentials format "hubble3"`},
+
+		// v1
+		{"v1 cut off", valid[:len(valid)-5], "value ends early"},
+		{"v1 bad character", "ab$d" + valid[4:], "unexpected character at position 3"},
+		{"v1 no separator", v1(testOrgID + testToken), "no ':' separator"},
+		{"v1 empty org", v1(":" + testToken), "org_id is empty"},
+		{"v1 empty token", v1(testOrgID + ":"), "api_token is empty; the value was probably cut off"},
+		{"v1 short token", v1(testOrgID + ":eb31d241"), "api_token is too short"},
+		{"v1 short token cut off", v1(testOrgID + ":eb31d241"), "probably cut off when pasted"},
+		{"v1 org not a UUID", v1("org-1:" + testToken), "org_id must be a UUID"},
+		{"v1 unknown board", v1(testOrgID + ":" + testToken + ":nope"), "invalid board: board not found: nope"},
+
+		// v2 structure and checksum
+		{"v2 checksum missing", CredentialsPrefix + payload, "checksum is missing"},
+		{"v2 too many parts", encoded + ".extra", "expected 3 dot-separated parts, found 4"},
+		{"v2 checksum cut off", encoded[:len(encoded)-6], "checksum has 10 of 16 characters"},
+		{"v2 text after checksum", encoded + "ab", "checksum has 18 characters, expected 16; extra text was pasted"},
+		{"v2 payload changed", CredentialsPrefix + "x" + payload[1:] + "." + checksum, "checksum does not match"},
+		{"v2 payload not base64url", CredentialsPrefix + "a+b/" + "." + credentialsChecksum("a+b/"), "payload is not valid base64url"},
+
+		// v2 payload contents
+		{"v2 payload not JSON", v2(`not json`), "payload is not valid"},
+		{"v2 unknown field", v2(`{"v":2,"org_id":"` + testOrgID + `","api_token":"` + testToken + `","extra":1}`), `unknown field "extra"`},
+		{"v2 wrong version", v2(`{"v":3,"org_id":"` + testOrgID + `","api_token":"` + testToken + `"}`), "payload declares version 3, expected 2"},
+		{"v2 bad api_env", v2(`{"v":2,"org_id":"` + testOrgID + `","api_token":"` + testToken + `","api_env":"Prod!"}`), `api_env "Prod!" is not a valid environment name`},
+		{"v2 bad device_prefix", v2(`{"v":2,"org_id":"` + testOrgID + `","api_token":"` + testToken + `","device_prefix":"-lab"}`), `device_prefix "-lab" may only contain`},
+		{"v2 expired", v2(`{"v":2,"org_id":"` + testOrgID + `","api_token":"` + testToken + `","expires_at":"` + expired + `"}`), "credentials expired at"},
+		{"v2 empty org", v2(`{"v":2,"api_token":"` + testToken + `"}`), "org_id is empty"},
+		{"v2 empty token", v2(`{"v":2,"org_id":"` + testOrgID + `"}`), "api_token is empty"},
+		{"v2 unknown board", v2(`{"v":2,"org_id":"` + testOrgID + `","api_token":"` + testToken + `","board":"nope"}`), "invalid board"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCredentials(tt.value, testNow)
+			if err == nil {
+				t.Fatalf("ParseCredentials(%q) = %+v, want an error containing %q", tt.value, got, tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
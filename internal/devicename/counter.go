@@ -0,0 +1,83 @@
+package devicename
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// CountersPath returns the file holding the sequence counter of each template
+func CountersPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "hubble-install", "name-counters.json"), nil
+}
+
+// NextSeq returns the next sequence number for a template, starting at 1
+func NextSeq(template string) (int, error) {
+	counters, err := loadCounters()
+	if err != nil {
+		return 0, err
+	}
+	return counters[template] + 1, nil
+}
+
+// SaveSeq records that seq was used for a template. A lower value than the
+// one saved is ignored, so the counter never goes backwards.
+func SaveSeq(template string, seq int) error {
+	counters, err := loadCounters()
+	if err != nil {
+		return err
+	}
+	if counters[template] >= seq {
+		return nil
+	}
+	counters[template] = seq
+
+	path, err := CountersPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	data, err := json.MarshalIndent(counters, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash never loses every counter
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// loadCounters reads the saved counters; a missing file means none were used yet
+func loadCounters() (map[string]int, error) {
+	path, err := CountersPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]int{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	counters := map[string]int{}
+	if err := json.Unmarshal(data, &counters); err != nil {
+		return nil, fmt.Errorf("invalid counter file %s: %w", path, err)
+	}
+	return counters, nil
+}
@@ -0,0 +1,79 @@
+package devicename
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useTempConfig points the user config directory at a temporary directory
+// and returns the counter file path
+func useTempConfig(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("AppData", home)
+
+	path, err := CountersPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSequenceCounters(t *testing.T) {
+	useTempConfig(t)
+	const lab, bench = "lab-{seq:03}", "{board}-{seq}"
+
+	// Each step saves seq for a template (unless 0) and checks what comes next
+	steps := []struct {
+		template string
+		save     int
+		want     int
+	}{
+		{template: lab, want: 1},
+		{template: lab, save: 1, want: 2},
+		{template: lab, save: 5, want: 6},
+		{template: bench, want: 1},
+		{template: bench, save: 2, want: 3},
+		{template: lab, save: 3, want: 6}, // Never goes backwards
+		{template: lab, want: 6},
+	}
+
+	for i, step := range steps {
+		if step.save != 0 {
+			if err := SaveSeq(step.template, step.save); err != nil {
+				t.Fatalf("step %d: SaveSeq() = %v", i, err)
+			}
+		}
+		got, err := NextSeq(step.template)
+		if err != nil {
+			t.Fatalf("step %d: NextSeq() = %v", i, err)
+		}
+		if got != step.want {
+			t.Errorf("step %d: NextSeq(%q) = %d, want %d", i, step.template, got, step.want)
+		}
+	}
+}
+
+func TestCorruptCounterFile(t *testing.T) {
+	path := useTempConfig(t)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"lab-{seq}": "three"`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NextSeq("lab-{seq}"); err == nil || !strings.Contains(err.Error(), "invalid counter file") {
+		t.Errorf("NextSeq() = %v, want an invalid counter file error", err)
+	}
+	if err := SaveSeq("lab-{seq}", 1); err == nil {
+		t.Error("SaveSeq() overwrote a corrupt counter file")
+	}
+	if data, _ := os.ReadFile(path); string(data) != `{"lab-{seq}": "three"` {
+		t.Errorf("counter file changed to %q", data)
+	}
+}
@@ -0,0 +1,61 @@
+// Package devicename validates device names and picks unique defaults
+package devicename
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MaxLength is the longest device name accepted
+const MaxLength = 64
+
+// pattern allows letters, digits, spaces, '.', '-' and '_', starting with a letter or digit
+var pattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._ -]*$`)
+
+// Validate reports why a name can't be used for a device
+func Validate(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("device name is empty")
+	case len(name) > MaxLength:
+		return fmt.Errorf("device name is %d characters long; the limit is %d", len(name), MaxLength)
+	case strings.TrimSpace(name) != name:
+		return fmt.Errorf("device name %q starts or ends with a space", name)
+	case !pattern.MatchString(name):
+		return fmt.Errorf("device name %q may only contain letters, digits, spaces, '.', '-' and '_', and must start with a letter or digit", name)
+	}
+	return nil
+}
+
+// Contains reports whether name is already taken. Names are compared without
+// regard to case, since "Test" and "test" can't be told apart on the dashboard.
+func Contains(existing []string, name string) bool {
+	for _, e := range existing {
+		if strings.EqualFold(e, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Suggest returns "<base>-<n>" with n one past the highest number already
+// used for base, so "nrf52840dk-1" … "nrf52840dk-6" lead to "nrf52840dk-7"
+func Suggest(base string, existing []string) string {
+	highest := 0
+	for _, e := range existing {
+		if len(e) <= len(base)+1 || !strings.EqualFold(e[:len(base)+1], base+"-") {
+			continue
+		}
+		if n, err := strconv.Atoi(e[len(base)+1:]); err == nil && n > highest {
+			highest = n
+		}
+	}
+	for n := highest + 1; ; n++ {
+		name := base + "-" + strconv.Itoa(n)
+		if !Contains(existing, name) {
+			return name
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package devicename
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Template placeholders:
+//
+//	{board}         board ID, e.g. nrf52840dk
+//	{probe_serial}  serial number of the connected J-Link probe
+//	{user}          login name of the current user
+//	{date}          today's date as YYYYMMDD
+//	{seq} {seq:03}  sequence number kept per template, optionally zero-padded
+//	{n}             lowest number that makes the name unique in the organization
+var placeholderPattern = regexp.MustCompile(`\{([a-z_]+)(?::(\d+))?\}`)
+
+// Values are what a template's placeholders expand to
+type Values struct {
+	Board       string
+	ProbeSerial string // Empty if no probe was found
+	User        string
+	Date        time.Time
+	Seq         int // Next sequence number for the template
+}
+
+// Template is a parsed device naming template
+type Template struct {
+	text string
+}
+
+// ParseTemplate checks that every placeholder in text is known
+func ParseTemplate(text string) (*Template, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("naming template is empty")
+	}
+	for _, m := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+		switch m[1] {
+		case "board", "probe_serial", "user", "date", "n":
+			if m[2] != "" {
+				return nil, fmt.Errorf("naming template: {%s} does not take a width", m[1])
+			}
+		case "seq":
+		default:
+			return nil, fmt.Errorf("naming template: unknown placeholder {%s}", m[1])
+		}
+	}
+	// Braces that aren't part of a placeholder are almost certainly a typo
+	if rest := placeholderPattern.ReplaceAllString(text, ""); strings.ContainsAny(rest, "{}") {
+		return nil, fmt.Errorf("naming template %q has an unmatched or malformed placeholder", text)
+	}
+	return &Template{text: text}, nil
+}
+
+// IsTemplate reports whether text contains placeholders
+func IsTemplate(text string) bool {
+	return strings.ContainsAny(text, "{}")
+}
+
+// String returns the template text, which is also the key of its sequence counter
+func (t *Template) String() string {
+	return t.text
+}
+
+// Uses reports whether the template contains the named placeholder
+func (t *Template) Uses(name string) bool {
+	for _, m := range placeholderPattern.FindAllStringSubmatch(t.text, -1) {
+		if m[1] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Name expands the template. Names already in existing are skipped by
+// counting {seq} or {n} up; the sequence number used is returned so the
+// caller can save it once the name is accepted.
+func (t *Template) Name(v Values, existing []string) (name string, seq int, err error) {
+	if t.Uses("probe_serial") && v.ProbeSerial == "" {
+		return "", 0, fmt.Errorf("naming template uses {probe_serial} but no J-Link probe was found")
+	}
+
+	seq = max(v.Seq, 1)
+	n := 1
+	for range 10000 {
+		name = t.expand(v, seq, n)
+		if err := Validate(name); err != nil {
+			return "", 0, fmt.Errorf("naming template %q: %w", t.text, err)
+		}
+		if !Contains(existing, name) {
+			return name, seq, nil
+		}
+		switch {
+		case t.Uses("n"):
+			n++
+		case t.Uses("seq"):
+			seq++
+		default:
+			// Nothing to count up; let the caller decide about the duplicate
+			return name, seq, nil
+		}
+	}
+	return "", 0, fmt.Errorf("naming template %q: no unused name found", t.text)
+}
+
+// expand substitutes every placeholder
+func (t *Template) expand(v Values, seq, n int) string {
+	return placeholderPattern.ReplaceAllStringFunc(t.text, func(p string) string {
+		m := placeholderPattern.FindStringSubmatch(p)
+		switch m[1] {
+		case "board":
+			return v.Board
+		case "probe_serial":
+			return v.ProbeSerial
+		case "user":
+			return v.User
+		case "date":
+			return v.Date.Format("20060102")
+		case "n":
+			return strconv.Itoa(n)
+		case "seq":
+			width, _ := strconv.Atoi(m[2])
+			return fmt.Sprintf("%0*d", width, seq)
+		}
+		return p
+	})
+}
+
+// userPattern matches characters not allowed in a device name
+var userPattern = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// UserName turns a login name into something usable in a device name,
+// dropping a Windows domain ("CORP\jdoe" becomes "jdoe")
+func UserName(login string) string {
+	if i := strings.LastIndex(login, `\`); i >= 0 {
+		login = login[i+1:]
+	}
+	return strings.Trim(userPattern.ReplaceAllString(login, "-"), "-._")
+}
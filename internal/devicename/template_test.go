@@ -0,0 +1,119 @@
+package devicename
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTemplate(t *testing.T) {
+	tests := []struct {
+		text string
+		want string // Substring of the error; empty for a valid template
+	}{
+		{text: "{board}-{probe_serial}-{seq:03}"},
+		{text: "  lab-{user}-{date}-{n}  "},
+		{text: "plain-name"},
+		{text: "", want: "naming template is empty"},
+		{text: "   ", want: "naming template is empty"},
+		{text: "{board}-{serial}", want: "unknown placeholder {serial}"},
+		{text: "{Board}", want: "unmatched or malformed placeholder"},
+		{text: "{board:3}", want: "{board} does not take a width"},
+		{text: "{n:2}", want: "{n} does not take a width"},
+		{text: "{board}-{seq", want: "unmatched or malformed placeholder"},
+		{text: "board}-1", want: "unmatched or malformed placeholder"},
+		{text: "{seq:x}", want: "unmatched or malformed placeholder"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			tmpl, err := ParseTemplate(tt.text)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("ParseTemplate() = %v", err)
+				}
+				if tmpl.String() != strings.TrimSpace(tt.text) {
+					t.Errorf("String() = %q, want %q", tmpl.String(), strings.TrimSpace(tt.text))
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseTemplate() = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateName(t *testing.T) {
+	values := Values{
+		Board:       "nrf52840dk",
+		ProbeSerial: "683456789",
+		User:        "maker",
+		Date:        time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC),
+		Seq:         4,
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		values   *Values // Default: values
+		existing []string
+		want     string
+		wantSeq  int
+		wantErr  string
+	}{
+		{name: "every placeholder", template: "{board}-{probe_serial}-{user}-{date}-{seq}", want: "nrf52840dk-683456789-maker-20260307-4", wantSeq: 4},
+		{name: "padded sequence", template: "{board}-{seq:03}", want: "nrf52840dk-004", wantSeq: 4},
+		{name: "sequence starts at 1", template: "lab-{seq:02}", values: &Values{}, want: "lab-01", wantSeq: 1},
+		{name: "sequence skips taken names", template: "lab-{seq}", existing: []string{"lab-4", "LAB-5"}, want: "lab-6", wantSeq: 6},
+		{name: "n counts from 1", template: "{board}-{n}", existing: []string{"nrf52840dk-1", "nrf52840dk-2"}, want: "nrf52840dk-3", wantSeq: 4},
+		{name: "n is used before seq", template: "{seq}-{n}", existing: []string{"4-1"}, want: "4-2", wantSeq: 4},
+		{name: "taken name without a counter", template: "{user}-bench", existing: []string{"maker-bench"}, want: "maker-bench", wantSeq: 4},
+		{name: "missing probe", template: "{board}-{probe_serial}", values: &Values{Board: "nrf52840dk"}, wantErr: "no J-Link probe was found"},
+		{name: "invalid expansion", template: "{user}", values: &Values{User: "-bad"}, wantErr: "must start with a letter or digit"},
+		{name: "too long", template: strings.Repeat("x", 60) + "-{seq:05}", wantErr: "the limit is 64"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseTemplate(tt.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			v := values
+			if tt.values != nil {
+				v = *tt.values
+			}
+
+			name, seq, err := tmpl.Name(v, tt.existing)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Name() = %q, %v, want an error containing %q", name, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Name() = %v", err)
+			}
+			if name != tt.want || seq != tt.wantSeq {
+				t.Errorf("Name() = %q, %d, want %q, %d", name, seq, tt.want, tt.wantSeq)
+			}
+		})
+	}
+}
+
+func TestUserName(t *testing.T) {
+	tests := map[string]string{
+		"maker":          "maker",
+		`CORP\jdoe`:      "jdoe",
+		"Jane Doe":       "Jane-Doe",
+		"jane@example":   "jane-example",
+		".hidden_":       "hidden",
+		`CORP\ünïcode 1`: "n-code-1",
+	}
+	for login, want := range tests {
+		if got := UserName(login); got != want {
+			t.Errorf("UserName(%q) = %q, want %q", login, got, want)
+		}
+	}
+}
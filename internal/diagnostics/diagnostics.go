@@ -0,0 +1,317 @@
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/HubbleNetwork/hubble-install/internal/hubbledemo"
+	"github.com/HubbleNetwork/hubble-install/internal/logging"
+	"github.com/HubbleNetwork/hubble-install/internal/netconfig"
+	"github.com/HubbleNetwork/hubble-install/internal/platform"
+	"github.com/HubbleNetwork/hubble-install/internal/runner"
+)
+
+// Dependency is an external tool the installer relies on
+type Dependency struct {
+	Name    string `json:"name"`
+	Path    string `json:"path,omitempty"`
+	Version string `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Found reports whether the tool is on PATH
+func (d Dependency) Found() bool {
+	return d.Path != ""
+}
+
+// NetworkCheck is the result of reaching one download endpoint
+type NetworkCheck struct {
+	URL             string `json:"url"`
+	Error           string `json:"error,omitempty"`
+	TLSInterception bool   `json:"tls_interception,omitempty"`
+	Issuer          string `json:"issuer,omitempty"`
+}
+
+// Report describes the machine the installer runs on
+type Report struct {
+	InstallerVersion string         `json:"installer_version"`
+	GeneratedAt      time.Time      `json:"generated_at"`
+	OS               string         `json:"os"`
+	Arch             string         `json:"arch"`
+	Distro           string         `json:"distro,omitempty"`
+	ToolSpec         string         `json:"tool_spec"`
+	Dependencies     []Dependency   `json:"dependencies"`
+	PendingReboot    string         `json:"pending_reboot,omitempty"`
+	Network          []NetworkCheck `json:"network,omitempty"`
+	USBDevices       string         `json:"-"`
+	Environment      []string       `json:"-"`
+	LogDir           string         `json:"log_dir,omitempty"`
+}
+
+// versionArgs are the arguments that print each tool's version. Tools
+// without an entry (J-Link opens an interactive prompt) are only located.
+var versionArgs = map[string][]string{
+	"uv":      {"--version"},
+	"nrfutil": {"--version"},
+	"brew":    {"--version"},
+	"choco":   {"--version"},
+	"apt-get": {"--version"},
+	"dnf":     {"--version"},
+	"yum":     {"--version"},
+}
+
+// tools returns the tools worth reporting on the given platform
+func tools(goos string) []string {
+	switch goos {
+	case "windows":
+		return []string{"choco", "uv", "nrfutil", "JLink"}
+	case "darwin":
+		return []string{"brew", "uv", "nrfutil", "JLinkExe"}
+	default:
+		return []string{"apt-get", "dnf", "yum", "uv", "nrfutil", "JLinkExe"}
+	}
+}
+
+// Collect gathers the report. Network checks are skipped when checkNetwork is
+// false, and the reboot check when reboot is nil.
+func Collect(ctx context.Context, r runner.Runner, reboot platform.RebootChecker, version string, checkNetwork bool) *Report {
+	report := &Report{
+		InstallerVersion: version,
+		GeneratedAt:      time.Now().UTC(),
+		OS:               runtime.GOOS,
+		Arch:             runtime.GOARCH,
+		Distro:           distro(ctx, r),
+		ToolSpec:         hubbledemo.Spec(),
+		USBDevices:       usbDevices(ctx, r),
+		Environment:      Environment(),
+	}
+
+	for _, name := range tools(runtime.GOOS) {
+		report.Dependencies = append(report.Dependencies, dependency(ctx, r, name))
+	}
+
+	if reboot != nil {
+		if err := reboot.CheckPendingReboot(ctx); err != nil {
+			report.PendingReboot = err.Error()
+		}
+	}
+
+	if checkNetwork {
+		for _, result := range netconfig.Preflight(netconfig.PreflightURLs) {
+			check := NetworkCheck{URL: result.URL, TLSInterception: result.TLSInterception, Issuer: result.Issuer}
+			if result.Err != nil {
+				check.Error = result.Err.Error()
+			}
+			report.Network = append(report.Network, check)
+		}
+	}
+
+	if dir, err := logging.Dir(); err == nil {
+		report.LogDir = dir
+	}
+
+	return report
+}
+
+// dependency locates a tool and asks it for its version
+func dependency(ctx context.Context, r runner.Runner, name string) Dependency {
+	dep := Dependency{Name: name}
+
+	path, err := r.LookPath(name)
+	if err != nil {
+		return dep
+	}
+	dep.Path = path
+
+	args, ok := versionArgs[name]
+	if !ok {
+		return dep
+	}
+	output, err := r.Output(ctx, &runner.Command{Name: path, Args: args})
+	if err != nil {
+		dep.Error = err.Error()
+		return dep
+	}
+	dep.Version = firstLine(string(output))
+	return dep
+}
+
+// distro describes the operating system release
+func distro(ctx context.Context, r runner.Runner) string {
+	switch runtime.GOOS {
+	case "linux":
+		data, err := r.ReadFile("/etc/os-release")
+		if err != nil {
+			return ""
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if value, ok := strings.CutPrefix(line, "PRETTY_NAME="); ok {
+				return strings.Trim(value, `"`)
+			}
+		}
+	case "darwin":
+		output, err := r.Output(ctx, &runner.Command{Name: "sw_vers", Args: []string{"-productVersion"}})
+		if err == nil {
+			return "macOS " + strings.TrimSpace(string(output))
+		}
+	case "windows":
+		output, err := r.Output(ctx, &runner.Command{Name: "cmd", Args: []string{"/c", "ver"}})
+		if err == nil {
+			return strings.TrimSpace(string(output))
+		}
+	}
+	return ""
+}
+
+// usbDevices lists connected USB devices with the platform's own tool
+func usbDevices(ctx context.Context, r runner.Runner) string {
+	var cmd *runner.Command
+	switch runtime.GOOS {
+	case "linux":
+		cmd = &runner.Command{Name: "lsusb"}
+	case "darwin":
+		cmd = &runner.Command{Name: "system_profiler", Args: []string{"SPUSBDataType"}}
+	case "windows":
+		cmd = &runner.Command{Name: "powershell", Args: []string{"-NoProfile", "-Command",
+			"Get-PnpDevice -PresentOnly -Class USB | Format-Table -AutoSize Status,FriendlyName,InstanceId"}}
+	default:
+		return "USB listing not supported on " + runtime.GOOS
+	}
+
+	output, err := r.Output(ctx, cmd)
+	if err != nil {
+		return fmt.Sprintf("%s failed: %v", cmd.Name, err)
+	}
+	return string(output)
+}
+
+// JLinkProbeConnected reports whether the USB listing shows a SEGGER J-Link probe
+func (r *Report) JLinkProbeConnected() bool {
+	usb := strings.ToLower(r.USBDevices)
+	return strings.Contains(usb, "1366:") || strings.Contains(usb, "vid_1366") ||
+		strings.Contains(usb, "segger") || strings.Contains(usb, "j-link")
+}
+
+// environmentKeys are the variables that affect the installer and its tools
+var environmentKeys = []string{
+	"PATH", "HOME", "SHELL", "LANG",
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "ALL_PROXY",
+	"http_proxy", "https_proxy", "no_proxy", "all_proxy",
+	"SSL_CERT_FILE", "SSL_CERT_DIR", "REQUESTS_CA_BUNDLE", "CURL_CA_BUNDLE",
+	"chocolateyProxyLocation",
+}
+
+// environmentPrefixes select whole families of relevant variables
+var environmentPrefixes = []string{"HUBBLE_", "UV_", "PIP_"}
+
+// Environment returns the relevant environment variables with secrets masked
+func Environment() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if !relevant(key) {
+			continue
+		}
+		env = append(env, key+"="+redactValue(key, value))
+	}
+	sort.Strings(env)
+	return env
+}
+
+func relevant(key string) bool {
+	for _, k := range environmentKeys {
+		if key == k {
+			return true
+		}
+	}
+	for _, prefix := range environmentPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactValue masks credentials and passwords embedded in URLs
+func redactValue(key, value string) string {
+	upper := strings.ToUpper(key)
+	switch {
+	case value == "":
+		return value
+	case upper == "HUBBLE_CREDENTIALS" || upper == "HUBBLE_ORG_ID",
+		strings.Contains(upper, "TOKEN"), strings.Contains(upper, "PASSWORD"),
+		strings.Contains(upper, "SECRET"), strings.HasSuffix(upper, "_KEY"):
+		return logging.Redacted
+	}
+	// Masks registered secrets and the password of proxy and index URLs
+	// (user:password@), exactly as in the bundled logs
+	return logging.Redact(value)
+}
+
+// WriteText writes the report in the human-readable form shown by "doctor"
+func (r *Report) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "hubble-install %s\n", r.InstallerVersion)
+	fmt.Fprintf(w, "Platform:      %s/%s\n", r.OS, r.Arch)
+	if r.Distro != "" {
+		fmt.Fprintf(w, "System:        %s\n", r.Distro)
+	}
+	fmt.Fprintf(w, "Firmware tool: %s\n", r.ToolSpec)
+
+	fmt.Fprintln(w, "\nDependencies:")
+	for _, dep := range r.Dependencies {
+		switch {
+		case !dep.Found():
+			fmt.Fprintf(w, "  ✗ %-9s not found\n", dep.Name)
+		case dep.Error != "":
+			fmt.Fprintf(w, "  ⚠ %-9s %s (version check failed: %s)\n", dep.Name, dep.Path, dep.Error)
+		case dep.Version != "":
+			fmt.Fprintf(w, "  ✓ %-9s %s (%s)\n", dep.Name, dep.Version, dep.Path)
+		default:
+			fmt.Fprintf(w, "  ✓ %-9s %s\n", dep.Name, dep.Path)
+		}
+	}
+
+	fmt.Fprintln(w, "\nSystem state:")
+	if r.PendingReboot != "" {
+		fmt.Fprintf(w, "  ⚠ Reboot pending: %s\n", r.PendingReboot)
+	} else if r.OS == "darwin" {
+		// Only system extensions are checked on macOS
+		fmt.Fprintln(w, "  ✓ No system extension waiting for a reboot (macOS updates not checked)")
+	} else {
+		fmt.Fprintln(w, "  ✓ No reboot pending")
+	}
+	if r.JLinkProbeConnected() {
+		fmt.Fprintln(w, "  ✓ J-Link probe connected")
+	} else {
+		fmt.Fprintln(w, "  ⚠ No J-Link probe detected on USB (only needed for Nordic boards)")
+	}
+
+	if len(r.Network) > 0 {
+		fmt.Fprintln(w, "\nNetwork:")
+		for _, check := range r.Network {
+			switch {
+			case check.Error == "":
+				fmt.Fprintf(w, "  ✓ %s\n", check.URL)
+			case check.TLSInterception:
+				fmt.Fprintf(w, "  ✗ %s: TLS interception (issuer: %s)\n", check.URL, check.Issuer)
+			default:
+				fmt.Fprintf(w, "  ✗ %s: %s\n", check.URL, check.Error)
+			}
+		}
+	}
+
+	if r.LogDir != "" {
+		fmt.Fprintf(w, "\nLogs: %s\n", r.LogDir)
+	}
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(line)
+}
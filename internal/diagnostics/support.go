@@ -0,0 +1,130 @@
+package diagnostics
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/HubbleNetwork/hubble-install/internal/logging"
+	"github.com/HubbleNetwork/hubble-install/internal/state"
+)
+
+// supportLogs is the number of most recent run logs included in a support bundle
+const supportLogs = 5
+
+// WriteSupportBundle writes a tar.gz with the report, recent logs and saved
+// progress to path. Every file is passed through the log redactor, which
+// also masks URL passwords, so register secrets with logging.AddSecret first. It returns the archived file names.
+func WriteSupportBundle(path string, report *Report) ([]string, error) {
+	files := map[string][]byte{}
+
+	var doctor bytes.Buffer
+	report.WriteText(&doctor)
+	files["doctor.txt"] = doctor.Bytes()
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	files["report.json"] = data
+	files["usb-devices.txt"] = []byte(report.USBDevices)
+	files["environment.txt"] = []byte(strings.Join(report.Environment, "\n") + "\n")
+
+	logs, err := recentLogs(supportLogs)
+	if err != nil {
+		return nil, err
+	}
+	for _, log := range logs {
+		data, err := os.ReadFile(log)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read log: %w", err)
+		}
+		files["logs/"+filepath.Base(log)] = data
+	}
+
+	if statePath, err := state.Path(); err == nil {
+		if data, err := os.ReadFile(statePath); err == nil {
+			files["state.json"] = data
+		}
+	}
+
+	if err := writeTarGz(path, files); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// recentLogs returns up to n of the newest log files
+func recentLogs(n int) ([]string, error) {
+	dir, err := logging.Dir()
+	if err != nil {
+		return nil, err
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, "hubble-install-*.log"))
+	if err != nil {
+		return nil, err
+	}
+	// Timestamped names sort chronologically
+	sort.Strings(matches)
+	if len(matches) > n {
+		matches = matches[len(matches)-n:]
+	}
+	return matches, nil
+}
+
+// writeTarGz archives the redacted files under a single top-level directory
+func writeTarGz(path string, files map[string][]byte) error {
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	root := strings.TrimSuffix(filepath.Base(path), ".tar.gz")
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	now := time.Now()
+	for _, name := range names {
+		data := []byte(logging.Redact(string(files[name])))
+		header := &tar.Header{
+			Name:    root + "/" + name,
+			Mode:    0600,
+			Size:    int64(len(data)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package e2e
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detach starts cmd in a new session without a controlling terminal, so
+// prompts read the scenario's answers from standard input rather than
+// /dev/tty
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package e2e
+
+import "os/exec"
+
+// detach is a no-op: Windows prompts already read from standard input
+func detach(cmd *exec.Cmd) {}
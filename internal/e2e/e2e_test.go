@@ -0,0 +1,54 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestScenarios builds the installer and runs every scenario against it.
+// Run it with "make e2e" or "go test -tags e2e ./internal/e2e"; add
+// -run 'TestScenarios/TI_board' -v for one scenario with installer output.
+func TestScenarios(t *testing.T) {
+	h, err := New("../..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { h.Close() })
+
+	for _, s := range Scenarios() {
+		t.Run(s.Name, func(t *testing.T) {
+			if !s.AppliesTo(runtime.GOOS) {
+				t.Skipf("%s only", strings.Join(s.GOOS, ", "))
+			}
+
+			result, err := h.Run(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			errs := result.Check(s.Expect)
+			for _, err := range errs {
+				t.Error(err)
+			}
+			if len(errs) > 0 {
+				var calls []string
+				for _, call := range result.Calls {
+					calls = append(calls, call.String())
+				}
+				t.Logf("recorded calls:\n%s", indent(strings.Join(calls, "\n"), "  "))
+			}
+			if testing.Verbose() || len(errs) > 0 {
+				t.Logf("installer output:\n%s", indent(result.Output, "  | "))
+			}
+		})
+	}
+}
+
+// indent prefixes every line of s
+func indent(s, prefix string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	return prefix + strings.Join(lines, "\n"+prefix)
+}
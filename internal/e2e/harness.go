@@ -0,0 +1,564 @@
+package e2e
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HubbleNetwork/hubble-install/internal/hubbleapi"
+	"github.com/HubbleNetwork/hubble-install/internal/hubbleapi/hubbleapitest"
+	"github.com/HubbleNetwork/hubble-install/internal/hubbledemo"
+)
+
+// ConfigEnv points the shims at the scenario's ShimConfig file
+const ConfigEnv = "HUBBLE_E2E_CONFIG"
+
+// Rule scripts a shim's response to calls whose arguments start with Args
+type Rule struct {
+	Args    []string `json:"args"`
+	Stdout  string   `json:"stdout,omitempty"`
+	Stderr  string   `json:"stderr,omitempty"`
+	Exit    int      `json:"exit,omitempty"`
+	Creates []string `json:"creates,omitempty"` // Commands added to PATH by this call
+	Stdin   bool     `json:"stdin,omitempty"`   // Record what the call reads from stdin
+
+	Files map[string]string `json:"files,omitempty"` // Files written by this call, relative to the working directory
+}
+
+// ShimConfig is shared by all shims in one scenario
+type ShimConfig struct {
+	Log   string            `json:"log"`
+	Bin   string            `json:"bin"`
+	Shim  string            `json:"shim"`
+	Rules map[string][]Rule `json:"rules"`
+
+	// RecordEnv names environment variables whose values are recorded with each call
+	RecordEnv []string `json:"record_env,omitempty"`
+}
+
+// Match returns the first rule for name whose argument prefix matches args
+func (c *ShimConfig) Match(name string, args []string) (Rule, bool) {
+	for _, rule := range c.Rules[name] {
+		if hasPrefix(args, rule.Args) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Call is one recorded invocation of a shim
+type Call struct {
+	Name string            `json:"name"`
+	Args []string          `json:"args"`
+	Env  map[string]string `json:"env,omitempty"` // Recorded variables; expected values when set
+	// Stdin is recorded for rules with Stdin set; compared when expected
+	Stdin string `json:"stdin,omitempty"`
+}
+
+// String formats the call like a shell command line
+func (c Call) String() string {
+	return strings.TrimSpace(c.Name + " " + strings.Join(c.Args, " "))
+}
+
+// Scenario describes one end-to-end run of the installer
+type Scenario struct {
+	Name        string
+	GOOS        []string           // Host platforms the scenario applies to (empty: all)
+	Args        []string           // Installer flags, or a subcommand and its flags (passed unchanged)
+	Env         map[string]string  // Extra environment variables
+	Answers     []string           // Scripted answers to prompts, in order
+	Commands    []string           // Fake executables on PATH at start
+	Files       map[string]string  // Files to create, relative to the fake home directory
+	WorkFiles   map[string]string  // Files to create, relative to the working directory
+	SystemFiles map[string]string  // System files (e.g. var/run/reboot-required), relative to the system root
+	Rules       map[string][]Rule  // Scripted shim behavior
+	Devices     []hubbleapi.Device // Devices already registered in the fake organization
+	Expect      Expectation
+}
+
+// AppliesTo reports whether the scenario runs on the given platform
+func (s Scenario) AppliesTo(goos string) bool {
+	if len(s.GOOS) == 0 {
+		return true
+	}
+	for _, g := range s.GOOS {
+		if g == goos {
+			return true
+		}
+	}
+	return false
+}
+
+// Expectation is checked against a scenario's Result. Arguments may contain
+// {workdir}, which is replaced with the installer's working directory.
+type Expectation struct {
+	ExitCode  int
+	Steps     []string // Step titles, in order
+	Calls     []Call   // Calls that must appear, in order (others may be interleaved)
+	NotCalled []string // Commands that must not be run
+	Output    []string // Substrings that must appear in the output
+	Log       []string // Substrings that must appear in the run's log file
+	LogOmits  []string // Substrings that must not appear in the log (secrets)
+	JSON      bool     // Standard output must be one JSON value and a newline, nothing else
+
+	APIRequests []string // Requests to the fake API that must appear, in order ("GET /api/org/{org}/check")
+}
+
+// Result is the observed outcome of a scenario
+type Result struct {
+	ExitCode int
+	Output   string // Standard output and error, interleaved
+	Stdout   string
+	Steps    []string
+	Calls    []Call
+	Log      string
+	WorkDir  string
+
+	APIRequests []string // "METHOD /path" for each request to the fake API
+}
+
+// Harness builds the installer and the shim once and runs scenarios against them
+type Harness struct {
+	Dir    string
+	Binary string
+	Shim   string
+	System string // Root directory for system files, fixed at build time
+}
+
+// New builds the installer from the module at root into a temporary directory
+func New(root string) (*Harness, error) {
+	dir, err := os.MkdirTemp("", "hubble-e2e-")
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Harness{
+		Dir:    dir,
+		Binary: filepath.Join(dir, exeName("hubble-install")),
+		Shim:   filepath.Join(dir, exeName("shim")),
+		System: filepath.Join(dir, "system"),
+	}
+
+	// The installer reads system files such as /var/run/reboot-required
+	// below h.System instead of the real root
+	ldflags := "-X github.com/HubbleNetwork/hubble-install/internal/platform.systemRoot=" + h.System
+	builds := map[string][]string{
+		h.Binary: {root, "-ldflags", ldflags},
+		h.Shim:   {filepath.Join(root, "internal", "e2e", "shim")},
+	}
+	for out, build := range builds {
+		pkg := build[0]
+		args := append([]string{"build", "-o", out}, build[1:]...)
+		cmd := exec.Command("go", append(args, ".")...)
+		cmd.Dir = pkg
+		if output, err := cmd.CombinedOutput(); err != nil {
+			os.RemoveAll(dir)
+			return nil, fmt.Errorf("failed to build %s: %v\n%s", pkg, err, output)
+		}
+	}
+
+	return h, nil
+}
+
+// Close removes the harness directory
+func (h *Harness) Close() error {
+	return os.RemoveAll(h.Dir)
+}
+
+// Run executes the installer for one scenario in an isolated directory whose
+// PATH contains only the scenario's fake executables
+func (h *Harness) Run(s Scenario) (*Result, error) {
+	dir, err := os.MkdirTemp(h.Dir, "scenario-")
+	if err != nil {
+		return nil, err
+	}
+
+	bin := filepath.Join(dir, "bin")
+	home := filepath.Join(dir, "home")
+	work := filepath.Join(dir, "work")
+	for _, d := range []string{bin, home, work} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range s.Commands {
+		if err := CopyExecutable(h.Shim, filepath.Join(bin, exeName(name))); err != nil {
+			return nil, err
+		}
+	}
+
+	// The system root is shared by all scenarios, which run one at a time
+	if err := os.RemoveAll(h.System); err != nil {
+		return nil, err
+	}
+
+	for _, files := range []struct {
+		dir   string
+		files map[string]string
+	}{{home, s.Files}, {work, s.WorkFiles}, {h.System, s.SystemFiles}} {
+		for name, content := range files.files {
+			path := filepath.Join(files.dir, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return nil, err
+			}
+			if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	config := ShimConfig{
+		Log:   filepath.Join(dir, "calls.jsonl"),
+		Bin:   bin,
+		Shim:  h.Shim,
+		Rules: map[string][]Rule{},
+
+		RecordEnv: []string{hubbledemo.TokenEnv},
+	}
+	for name, rules := range s.Rules {
+		for _, rule := range rules {
+			for i, created := range rule.Creates {
+				rule.Creates[i] = exeName(created)
+			}
+			config.Rules[name] = append(config.Rules[name], rule)
+		}
+	}
+	configPath := filepath.Join(dir, "shims.json")
+	if err := writeJSON(configPath, config); err != nil {
+		return nil, err
+	}
+
+	answersPath := filepath.Join(dir, "answers.txt")
+	if err := os.WriteFile(answersPath, []byte(strings.Join(s.Answers, "\n")+"\n"), 0644); err != nil {
+		return nil, err
+	}
+
+	args := append([]string{"--answers", answersPath, "--skip-preflight"}, s.Args...)
+	subcommand := len(s.Args) > 0 && !strings.HasPrefix(s.Args[0], "-")
+	if subcommand {
+		// Subcommands have their own flags and read answers from stdin
+		args = s.Args
+	}
+	// Every scenario talks to a fake Hubble API that accepts the test credentials
+	api := hubbleapitest.NewServer(TestOrgID, TestAPIToken)
+	defer api.Close()
+	for _, d := range s.Devices {
+		api.AddDevice(d)
+	}
+
+	cmd := exec.Command(h.Binary, args...)
+	cmd.Dir = work
+	if subcommand && len(s.Answers) > 0 {
+		answers, err := os.Open(answersPath)
+		if err != nil {
+			return nil, err
+		}
+		defer answers.Close()
+		cmd.Stdin = answers
+		detach(cmd)
+	}
+	cmd.Env = []string{
+		"PATH=" + bin,
+		"HOME=" + home,
+		"USERPROFILE=" + home,
+		"LOCALAPPDATA=" + filepath.Join(home, "AppData", "Local"),
+		"XDG_CACHE_HOME=" + filepath.Join(home, ".cache"),
+		"XDG_CONFIG_HOME=" + filepath.Join(home, ".config"),
+		"TMPDIR=" + dir,
+		"NO_COLOR=1",
+		ConfigEnv + "=" + configPath,
+		hubbleapi.BaseURLEnv + "=" + api.URL,
+	}
+	for key, value := range s.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+
+	output, stdout, err := runWithTimeout(cmd, 2*time.Minute)
+	result := &Result{Output: output, Stdout: stdout, WorkDir: work, APIRequests: api.Requests()}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		result.ExitCode = exitErr.ExitCode()
+	} else if err != nil {
+		return nil, err
+	}
+
+	result.Steps = parseSteps(output)
+	if result.Log, err = readLogs(filepath.Join(home, ".cache", "hubble-install", "logs")); err != nil {
+		return nil, err
+	}
+	if result.Calls, err = readCalls(config.Log); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Check compares the result with the expectation and returns every mismatch
+func (r *Result) Check(exp Expectation) []error {
+	var errs []error
+
+	if r.ExitCode != exp.ExitCode {
+		errs = append(errs, fmt.Errorf("exit code %d, want %d", r.ExitCode, exp.ExitCode))
+	}
+
+	if exp.Steps != nil && strings.Join(r.Steps, "|") != strings.Join(exp.Steps, "|") {
+		errs = append(errs, fmt.Errorf("steps %q, want %q", r.Steps, exp.Steps))
+	}
+
+	// Expected calls must appear as an ordered subsequence of the recorded calls
+	next := 0
+	for _, call := range r.Calls {
+		if next < len(exp.Calls) && r.sameCall(call, exp.Calls[next]) {
+			next++
+		}
+	}
+	for _, missing := range exp.Calls[next:] {
+		errs = append(errs, fmt.Errorf("expected call not made (in order): %s", r.expand(missing)))
+	}
+
+	next = 0
+	for _, req := range r.APIRequests {
+		if next < len(exp.APIRequests) && req == expandOrg(exp.APIRequests[next]) {
+			next++
+		}
+	}
+	for _, missing := range exp.APIRequests[next:] {
+		errs = append(errs, fmt.Errorf("expected API request not made (in order): %s (got %q)", expandOrg(missing), r.APIRequests))
+	}
+
+	for _, name := range exp.NotCalled {
+		for _, call := range r.Calls {
+			if call.Name == name {
+				errs = append(errs, fmt.Errorf("unexpected call: %s", call))
+			}
+		}
+	}
+
+	for _, text := range exp.Output {
+		if !strings.Contains(r.Output, r.expandString(text)) {
+			errs = append(errs, fmt.Errorf("output does not contain %q", text))
+		}
+	}
+
+	if exp.JSON {
+		value, ok := strings.CutSuffix(r.Stdout, "\n")
+		if !ok || value != strings.TrimSpace(value) || !json.Valid([]byte(value)) {
+			errs = append(errs, fmt.Errorf("standard output is not just JSON: %q", r.Stdout))
+		}
+	}
+
+	for _, text := range exp.Log {
+		if !strings.Contains(r.Log, r.expandString(text)) {
+			errs = append(errs, fmt.Errorf("log does not contain %q", text))
+		}
+	}
+
+	for _, text := range exp.LogOmits {
+		if strings.Contains(r.Log, text) {
+			errs = append(errs, fmt.Errorf("log contains %q", text))
+		}
+	}
+
+	// Command lines are visible to every local user, so the token must never be on one
+	for _, call := range r.Calls {
+		if strings.Contains(call.String(), TestAPIToken) {
+			errs = append(errs, fmt.Errorf("API token passed on the command line of %s", call.Name))
+		}
+	}
+
+	return errs
+}
+
+// sameCall compares a recorded call with an expected one
+func (r *Result) sameCall(got, want Call) bool {
+	want = r.expand(want)
+	for key, value := range want.Env {
+		if got.Env[key] != value {
+			return false
+		}
+	}
+	if want.Stdin != "" && got.Stdin != want.Stdin {
+		return false
+	}
+	return got.Name == want.Name && strings.Join(got.Args, "\x00") == strings.Join(want.Args, "\x00")
+}
+
+// expand substitutes placeholders in an expected call
+func (r *Result) expand(c Call) Call {
+	args := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		args[i] = r.expandString(arg)
+	}
+	return Call{Name: c.Name, Args: args, Env: c.Env, Stdin: c.Stdin}
+}
+
+// expandOrg substitutes the test organization in an expected API request
+func expandOrg(s string) string {
+	return strings.ReplaceAll(s, "{org}", TestOrgID)
+}
+
+func (r *Result) expandString(s string) string {
+	return strings.ReplaceAll(s, "{workdir}", r.WorkDir)
+}
+
+// stepPattern matches the step headers printed by ui.PrintStep
+var stepPattern = regexp.MustCompile(`^\[\d+(?:/\d+)?\] (.+)$`)
+
+// parseSteps extracts step titles from the installer output
+func parseSteps(output string) []string {
+	var steps []string
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		if m := stepPattern.FindStringSubmatch(strings.TrimSpace(scanner.Text())); m != nil {
+			steps = append(steps, m[1])
+		}
+	}
+	return steps
+}
+
+// readCalls loads the shim call log
+func readCalls(path string) ([]Call, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var calls []Call
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		var call Call
+		if err := json.Unmarshal(scanner.Bytes(), &call); err != nil {
+			return nil, fmt.Errorf("corrupt call log: %w", err)
+		}
+		calls = append(calls, call)
+	}
+	return calls, scanner.Err()
+}
+
+// readLogs concatenates the installer log files in dir
+func readLogs(dir string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.log"))
+	if err != nil {
+		return "", err
+	}
+
+	var logs strings.Builder
+	for _, match := range matches {
+		data, err := os.ReadFile(match)
+		if err != nil {
+			return "", err
+		}
+		logs.Write(data)
+	}
+	return logs.String(), nil
+}
+
+// runWithTimeout runs cmd and returns its combined output and its standard
+// output alone
+func runWithTimeout(cmd *exec.Cmd, timeout time.Duration) (string, string, error) {
+	var output lockedBuilder
+	var stdout strings.Builder
+	cmd.Stdout = io.MultiWriter(&output, &stdout)
+	cmd.Stderr = &output
+
+	if err := cmd.Start(); err != nil {
+		return "", "", err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		return output.String(), stdout.String(), err
+	case <-time.After(timeout):
+		cmd.Process.Kill()
+		<-done
+		return output.String(), stdout.String(), fmt.Errorf("installer did not finish within %v", timeout)
+	}
+}
+
+// lockedBuilder is a strings.Builder that standard output and error can
+// write to concurrently
+type lockedBuilder struct {
+	mu sync.Mutex
+	b  strings.Builder
+}
+
+func (l *lockedBuilder) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.b.Write(p)
+}
+
+func (l *lockedBuilder) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.b.String()
+}
+
+// CopyExecutable copies the file at src to dest with execute permissions
+func CopyExecutable(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// writeJSON writes v to path as JSON
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// exeName adds the executable extension on Windows
+func exeName(name string) string {
+	if runtime.GOOS == "windows" {
+		return name + ".exe"
+	}
+	return name
+}
+
+// hasPrefix reports whether args starts with prefix
+func hasPrefix(args, prefix []string) bool {
+	if len(prefix) > len(args) {
+		return false
+	}
+	for i := range prefix {
+		if args[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
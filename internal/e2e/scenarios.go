@@ -0,0 +1,531 @@
+package e2e
+
+import (
+	"encoding/base64"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/HubbleNetwork/hubble-install/internal/config"
+	"github.com/HubbleNetwork/hubble-install/internal/hubbleapi"
+	"github.com/HubbleNetwork/hubble-install/internal/hubbledemo"
+)
+
+// Credentials used by the built-in scenarios. They are well-formed but not real.
+const (
+	TestOrgID    = "0f61efd0-24a7-4a2e-ae0f-8549d14ed901"
+	TestAPIToken = "eb31d24113fadb77c6d89d65a8007c0eed3595e2255aaf1d7d81783900ab33be4332457a27861f67cc78fe930ea52941"
+	TestVersion  = "1.0.0"
+)
+
+// credentials encodes HUBBLE_CREDENTIALS for the test org, optionally with a board
+func credentials(board string) string {
+	value := TestOrgID + ":" + TestAPIToken
+	if board != "" {
+		value += ":" + board
+	}
+	return base64.StdEncoding.EncodeToString([]byte(value))
+}
+
+// credentialsV2 encodes v2 HUBBLE_CREDENTIALS for the test org
+func credentialsV2(board, devicePrefix string) string {
+	value, err := config.Credentials{OrgID: TestOrgID, APIToken: TestAPIToken, Board: board, DevicePrefix: devicePrefix}.Encode()
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+// devices returns devices with the given names for the fake organization
+func devices(names ...string) []hubbleapi.Device {
+	var list []hubbleapi.Device
+	for _, name := range names {
+		list = append(list, hubbleapi.Device{Name: name})
+	}
+	return list
+}
+
+// hostCommands returns the fakes the installer needs before it will start on
+// the current platform (a supported package manager)
+func hostCommands(tools ...string) []string {
+	switch runtime.GOOS {
+	case "darwin":
+		tools = append(tools, "brew")
+	case "linux":
+		tools = append(tools, "apt-get")
+	}
+	return tools
+}
+
+// testHex is a small valid image for the TI boards: 16 bytes of flash and 8 bytes of CCFG
+const testHex = ":020000040000FA\n:10000000000102030405060708090A0B0C0D0E0F78\n:020000044E02AA\n:08000000AAAAAAAAAAAAAAAAA8\n:00000001FF\n"
+
+// hexRules scripts uv to write hexFile with content when generating an image
+func hexRules(hexFile, content string) []Rule {
+	rules := uvRules(0)
+	generate := Rule{Args: []string{"tool", "run", "--from", "pyhubbledemo==" + TestVersion, "python", "-c", hubbledemo.Launcher, "flash"}, Stdout: "Generating...\n", Files: map[string]string{hexFile: content}, Stdin: true}
+	return append(rules[:3], generate, rules[3])
+}
+
+// uvRules scripts uv so version resolution succeeds and flashing exits with flashExit
+func uvRules(flashExit int) []Rule {
+	return []Rule{
+		{Args: []string{"pip", "compile"}, Stdout: "pyhubbledemo==" + TestVersion + "\n"},
+		{Args: []string{"tool", "install", "nrfutil"}, Creates: []string{"nrfutil"}},
+		{Args: []string{"tool", "run", "--from", "pyhubbledemo==" + TestVersion, "hubbledemo", "--help"}, Stdout: "Usage: hubbledemo\n"},
+		{Args: []string{"tool", "run"}, Stdout: "Flashing...\n", Exit: flashExit, Stdin: true},
+	}
+}
+
+// flashCall is the expected hubbledemo invocation for a board. The token is
+// handed to the launcher on stdin, never on the command line.
+func flashCall(board string, extra ...string) Call {
+	args := []string{"tool", "run", "--from", "pyhubbledemo==" + TestVersion, "python", "-c", hubbledemo.Launcher,
+		"flash", board, "-o", TestOrgID}
+	return Call{Name: "uv", Args: append(args, extra...), Stdin: TestAPIToken + "\n"}
+}
+
+// Scenarios returns the built-in end-to-end scenarios
+func Scenarios() []Scenario {
+	unixOnly := []string{"linux", "darwin"}
+	versionArgs := []string{"--tool-version", TestVersion}
+	lastSeen := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	return []Scenario{
+		{
+			Name:     "flash nRF52840 DK with all dependencies present",
+			GOOS:     unixOnly,
+			Args:     versionArgs,
+			Env:      map[string]string{"HUBBLE_CREDENTIALS": credentials("nrf52840dk")},
+			Answers:  []string{"y", "y", "bench-01"},
+			Commands: hostCommands("uv", "nrfutil", "JLinkExe"),
+			Rules:    map[string][]Rule{"uv": uvRules(0)},
+			Expect: Expectation{
+				ExitCode:    0,
+				Steps:       []string{"Configuring credentials", "Selecting developer board", "Checking prerequisites", "Flashing board"},
+				Calls:       []Call{flashCall("nrf52840dk", "-n", "bench-01")},
+				Output:      []string{"Credentials accepted by the Hubble API", "All prerequisites satisfied", "Firmware tool: pyhubbledemo " + TestVersion},
+				APIRequests: []string{"GET /api/org/{org}/check"},
+			},
+		},
+		{
+			Name:     "v2 credentials carry the board and a device name prefix",
+			GOOS:     unixOnly,
+			Args:     versionArgs,
+			Env:      map[string]string{"HUBBLE_CREDENTIALS": credentialsV2("nrf52840dk", "lab-")},
+			Answers:  []string{"y", "y", "bench-04"},
+			Commands: hostCommands("uv", "nrfutil", "JLinkExe"),
+			Rules:    map[string][]Rule{"uv": uvRules(0)},
+			Expect: Expectation{
+				ExitCode: 0,
+				Steps:    []string{"Configuring credentials", "Selecting developer board", "Checking prerequisites", "Flashing board"},
+				Calls:    []Call{flashCall("nrf52840dk", "-n", "lab-bench-04")},
+				LogOmits: []string{TestAPIToken, credentialsV2("nrf52840dk", "lab-")},
+			},
+		},
+		{
+			Name:     "invalid and duplicate device names are asked again and the default is unused",
+			GOOS:     unixOnly,
+			Args:     versionArgs,
+			Env:      map[string]string{"HUBBLE_CREDENTIALS": credentials("nrf52840dk")},
+			Answers:  []string{"y", "y", "bad/name", "TEST", "n", ""},
+			Commands: hostCommands("uv", "nrfutil", "JLinkExe"),
+			Devices:  devices("test", "nrf52840dk-1", "nrf52840dk-2", "nrf52840dk-6"),
+			Rules:    map[string][]Rule{"uv": uvRules(0)},
+			Expect: Expectation{
+				ExitCode: 0,
+				Calls:    []Call{flashCall("nrf52840dk", "-n", "nrf52840dk-7")},
+				Output: []string{
+					`device name "bad/name" may only contain letters`,
+					`A device named "TEST" already exists in your organization`,
+					"Enter for nrf52840dk-7",
+				},
+				APIRequests: []string{"GET /api/org/{org}/check", "GET /api/org/{org}/devices"},
+			},
+		},
+		{
+			Name:     "naming template uses the probe serial and continues the saved sequence",
+			GOOS:     unixOnly,
+			Args:     append([]string{"--name-template", "{board}-{probe_serial}-{seq:03}"}, versionArgs...),
+			Env:      map[string]string{"HUBBLE_CREDENTIALS": credentials("nrf52840dk")},
+			Answers:  []string{"y", "y", ""},
+			Commands: hostCommands("uv", "nrfutil", "JLinkExe"),
+			Devices:  devices("nrf52840dk-683456789-005"),
+			Files: map[string]string{
+				".config/hubble-install/name-counters.json": `{"{board}-{probe_serial}-{seq:03}": 4}`,
+			},
+			Rules: map[string][]Rule{
+				"uv": uvRules(0),
+				"JLinkExe": {{Args: []string{"-NoGui", "1"},
+					Stdout: "J-Link[0]: Connection: USB, Serial number: 683456789, ProductName: J-Link OB-SAM3U128-V2-NordicSemi\n"}},
+			},
+			Expect: Expectation{
+				ExitCode: 0,
+				Calls: []Call{
+					{Name: "JLinkExe", Args: []string{"-NoGui", "1"}},
+					flashCall("nrf52840dk", "-n", "nrf52840dk-683456789-006"),
+				},
+				Output: []string{"Enter for nrf52840dk-683456789-006"},
+			},
+		},
+		{
+			Name:     "verification finds the device record, a valid image and the first packet",
+			GOOS:     unixOnly,
+			Args:     append([]string{"--verify", "--wait-for-packet", "30s"}, versionArgs...),
+			Env:      map[string]string{"HUBBLE_CREDENTIALS": credentials("nrf52840dk")},
+			Answers:  []string{"y", "y", "bench-05", "y"},
+			Commands: hostCommands("uv", "nrfutil", "JLinkExe"),
+			Devices:  []hubbleapi.Device{{Name: "bench-05", CreatedAt: time.Now(), LastSeen: &lastSeen}},
+			Rules: map[string][]Rule{
+				"uv":       uvRules(0),
+				"JLinkExe": {{Args: []string{"-NoGui", "1", "-Device", "nRF52840_xxAA"}, Stdout: "00000000 = 20040000 00000B4D\n"}},
+			},
+			Expect: Expectation{
+				ExitCode: 0,
+				Steps:    []string{"Configuring credentials", "Selecting developer board", "Checking prerequisites", "Flashing board", "Verifying device"},
+				Calls:    []Call{flashCall("nrf52840dk", "-n", "bench-05"), {Name: "JLinkExe", Args: []string{"-NoGui", "1", "-Device", "nRF52840_xxAA", "-If", "SWD", "-Speed", "4000", "-AutoConnect", "1", "-ExitOnError", "1"}}},
+				Output: []string{
+					"✓ Device registered: bench-05 (ID 00000000-0000-4000-8000-000000000001)",
+					"✓ Firmware image: vector table read back through the probe is valid",
+					"✓ First packet received",
+					`Your device "bench-05" is broadcasting`,
+				},
+			},
+		},
+		{
+			Name:     "verification fails when the flash reads back erased",
+			GOOS:     unixOnly,
+			Args:     append([]string{"--verify"}, versionArgs...),
+			Env:      map[string]string{"HUBBLE_CREDENTIALS": credentials("nrf52840dk")},
+			Answers:  []string{"y", "y", "bench-05", "y"},
+			Commands: hostCommands("uv", "nrfutil", "JLinkExe"),
+			Devices:  []hubbleapi.Device{{Name: "bench-05", CreatedAt: time.Now()}},
+			Rules: map[string][]Rule{
+				"uv":       uvRules(0),
+				"JLinkExe": {{Args: []string{"-NoGui", "1", "-Device"}, Stdout: "00000000 = FFFFFFFF FFFFFFFF\n"}},
+			},
+			Expect: Expectation{
+				ExitCode: 1,
+				Output:   []string{"✗ Firmware image: flash is erased; the image was not written", "Verification failed"},
+			},
+		},
+		{
+			Name:     "truncated credentials are reported instead of prompting",
+			Args:     versionArgs,
+			Env:      map[string]string{"HUBBLE_CREDENTIALS": credentialsV2("nrf52840dk", "")[:60]},
+			Answers:  []string{"y"},
+			Commands: hostCommands("uv", "nrfutil", "JLinkExe"),
+			Expect: Expectation{
+				ExitCode:  1,
+				Steps:     []string{"Configuring credentials"},
+				NotCalled: []string{"uv"},
+				Output:    []string{"invalid HUBBLE_CREDENTIALS: checksum is missing; the value was probably cut off when pasted"},
+			},
+		},
+		{
+			Name: "revoked token is rejected before anything is installed",
+			Args: versionArgs,
+			Env: map[string]string{
+				"HUBBLE_ORG_ID":    TestOrgID,
+				"HUBBLE_API_TOKEN": strings.Repeat("0", len(TestAPIToken)),
+			},
+			Answers:  []string{"y"},
+			Commands: hostCommands("uv"),
+			Expect: Expectation{
+				ExitCode:  1,
+				Steps:     []string{"Configuring credentials"},
+				NotCalled: []string{"uv", "brew", "apt-get"},
+				Output:    []string{"Credential check failed: the API token is invalid, expired or revoked"},
+			},
+		},
+		{
+			Name:     "install missing nrfutil through uv before flashing",
+			GOOS:     unixOnly,
+			Args:     versionArgs,
+			Env:      map[string]string{"HUBBLE_CREDENTIALS": credentials("nrf21540dk")},
+			Answers:  []string{"y", "y", "y", ""},
+			Commands: hostCommands("uv", "JLinkExe"),
+			Rules:    map[string][]Rule{"uv": uvRules(0)},
+			Expect: Expectation{
+				ExitCode: 0,
+				Steps:    []string{"Configuring credentials", "Selecting developer board", "Checking prerequisites", "Installing dependencies", "Flashing board"},
+				Calls: []Call{
+					{Name: "uv", Args: []string{"tool", "install", "nrfutil"}},
+					flashCall("nrf21540dk", "-n", "nrf21540dk-1"),
+				},
+				Output: []string{"nrfutil installed successfully"},
+			},
+		},
+		{
+			Name:     "select TI board interactively and generate hex file",
+			GOOS:     unixOnly,
+			Args:     versionArgs,
+			Env:      map[string]string{"HUBBLE_ORG_ID": TestOrgID, "HUBBLE_API_TOKEN": TestAPIToken},
+			Answers:  []string{"y", "3", "y", "ti-01"},
+			Commands: hostCommands("uv"),
+			Rules:    map[string][]Rule{"uv": hexRules("ti-01.hex", testHex)},
+			Expect: Expectation{
+				ExitCode:  0,
+				Steps:     []string{"Configuring credentials", "Selecting developer board", "Checking prerequisites", "Generating hex file"},
+				Calls:     []Call{flashCall("lp_em_cc2340r5", "-f", "{workdir}/ti-01.hex", "-n", "ti-01")},
+				NotCalled: []string{"JLinkExe", "nrfutil"},
+				Output: []string{
+					"Size: 24 bytes of data in 5 records",
+					"Address ranges: 0x00000000-0x0000000F, 0x4E020000-0x4E020007",
+					"SHA-256: ",
+				},
+			},
+		},
+		{
+			Name:      "existing hex file in the output directory is kept with the suffix policy",
+			GOOS:      unixOnly,
+			Args:      append([]string{"--output-dir", "firmware", "--overwrite", "suffix"}, versionArgs...),
+			Env:       map[string]string{"HUBBLE_CREDENTIALS": credentials("lp_em_cc2340r5")},
+			Answers:   []string{"y", "y", "ti-01"},
+			Commands:  hostCommands("uv"),
+			WorkFiles: map[string]string{"firmware/ti-01.hex": testHex},
+			Rules:     map[string][]Rule{"uv": hexRules("firmware/ti-01-2.hex", testHex)},
+			Expect: Expectation{
+				ExitCode: 0,
+				Calls:    []Call{flashCall("lp_em_cc2340r5", "-f", "{workdir}/firmware/ti-01-2.hex", "-n", "ti-01")},
+				Output:   []string{"firmware/ti-01-2.hex", "Details: ", "firmware/ti-01-2.json"},
+			},
+		},
+		{
+			Name:      "existing hex file stops generation with the fail policy",
+			GOOS:      unixOnly,
+			Args:      append([]string{"--overwrite", "fail"}, versionArgs...),
+			Env:       map[string]string{"HUBBLE_CREDENTIALS": credentials("lp_em_cc2340r5")},
+			Answers:   []string{"y", "y", "ti-01"},
+			Commands:  hostCommands("uv"),
+			WorkFiles: map[string]string{"ti-01.hex": testHex},
+			Rules:     map[string][]Rule{"uv": hexRules("ti-01.hex", testHex)},
+			Expect: Expectation{
+				ExitCode: 1,
+				Output:   []string{"ti-01.hex already exists; choose another --output-dir or device name, or pass --overwrite suffix"},
+			},
+		},
+		{
+			Name:     "half-written hex file is reported instead of success",
+			GOOS:     unixOnly,
+			Args:     versionArgs,
+			Env:      map[string]string{"HUBBLE_CREDENTIALS": credentials("lp_em_cc2340r5")},
+			Answers:  []string{"y", "y", "ti-02"},
+			Commands: hostCommands("uv"),
+			Rules:    map[string][]Rule{"uv": hexRules("ti-02.hex", testHex[:strings.LastIndex(testHex, ":")])},
+			Expect: Expectation{
+				ExitCode: 1,
+				Output:   []string{"Hex file generation failed", "ti-02.hex is invalid: no end-of-file record; the file is truncated"},
+			},
+		},
+		{
+			Name:     "missing J-Link stops before anything is installed",
+			GOOS:     []string{"linux"},
+			Args:     versionArgs,
+			Env:      map[string]string{"HUBBLE_CREDENTIALS": credentials("nrf52840dk")},
+			Answers:  []string{"y"},
+			Commands: hostCommands("uv"),
+			Rules:    map[string][]Rule{"uv": uvRules(0)},
+			Expect: Expectation{
+				ExitCode:  1,
+				Steps:     []string{"Configuring credentials", "Selecting developer board", "Checking prerequisites"},
+				NotCalled: []string{"uv"},
+				Output:    []string{"SEGGER J-Link was not found"},
+			},
+		},
+		{
+			Name:     "flash failure exits non-zero",
+			GOOS:     unixOnly,
+			Args:     versionArgs,
+			Env:      map[string]string{"HUBBLE_CREDENTIALS": credentials("nrf52840dk")},
+			Answers:  []string{"y", "y", ""},
+			Commands: hostCommands("uv", "nrfutil", "JLinkExe"),
+			Rules:    map[string][]Rule{"uv": uvRules(1)},
+			Expect: Expectation{
+				ExitCode: 1,
+				Calls:    []Call{flashCall("nrf52840dk", "-n", "nrf52840dk-1")},
+				Output:   []string{"Board flashing failed", "Run the installer again to retry from this step", "A log of this run was saved to"},
+				Log:      []string{"STEP   [4] Flashing board", "CMD    ", "OUT    Flashing...", "EXIT   uv exited with code 1"},
+				LogOmits: []string{TestOrgID, TestAPIToken, credentials("nrf52840dk")},
+			},
+		},
+		{
+			Name:     "registration interrupted mid-request is not repeated",
+			GOOS:     unixOnly,
+			Args:     versionArgs,
+			Env:      map[string]string{"HUBBLE_CREDENTIALS": credentials("nrf52840dk")},
+			Answers:  []string{"y", "y", "bench-03"},
+			Commands: hostCommands("uv", "nrfutil", "JLinkExe"),
+			Rules: map[string][]Rule{"uv": append(uvRules(0)[:3],
+				Rule{Args: []string{"tool", "run"}, Stderr: hubbledemo.StartMarker + "\nError: ('Connection aborted.', ConnectionResetError(104, 'Connection reset by peer'))\n", Exit: 1, Stdin: true})},
+			Expect: Expectation{
+				ExitCode: 1,
+				Calls:    []Call{flashCall("nrf52840dk", "-n", "bench-03")},
+				Output:   []string{"may have reached the server, so it was not retried", "may already have been registered"},
+				Log:      []string{"EXIT   uv exited with code 1"},
+			},
+		},
+		{
+			Name:     "resume after reboot reuses saved board and device name",
+			GOOS:     []string{"linux"},
+			Args:     versionArgs,
+			Env:      map[string]string{"HUBBLE_ORG_ID": TestOrgID, "HUBBLE_API_TOKEN": TestAPIToken},
+			Answers:  []string{"y", "y"},
+			Commands: hostCommands("uv", "nrfutil", "JLinkExe"),
+			Files: map[string]string{
+				".config/hubble-install/state.json": `{"format_version": 1, "saved_at": "2026-01-01T00:00:00Z",
+					"completed": ["credentials", "board", "dependencies"], "board": "nrf52840dk",
+					"device_name": "bench-02", "credential_source": "environment", "reboot_required": true}`,
+			},
+			Rules: map[string][]Rule{"uv": uvRules(0)},
+			Expect: Expectation{
+				ExitCode: 0,
+				Calls:    []Call{flashCall("nrf52840dk", "-n", "bench-02")},
+				Output:   []string{"A previous installation did not finish", "Using board from previous run: nRF52840 DK"},
+			},
+		},
+		{
+			Name:     "resume asks again for a saved device name that is not valid",
+			GOOS:     []string{"linux"},
+			Args:     versionArgs,
+			Env:      map[string]string{"HUBBLE_ORG_ID": TestOrgID, "HUBBLE_API_TOKEN": TestAPIToken},
+			Answers:  []string{"y", "y", "bench-03"},
+			Commands: hostCommands("uv", "nrfutil", "JLinkExe"),
+			Files: map[string]string{
+				".config/hubble-install/state.json": `{"format_version": 1, "saved_at": "2026-01-01T00:00:00Z",
+					"completed": ["credentials", "board", "dependencies"], "board": "nrf52840dk",
+					"device_name": "bench-02; rm -rf ~", "credential_source": "environment"}`,
+			},
+			Rules: map[string][]Rule{"uv": uvRules(0)},
+			Expect: Expectation{
+				ExitCode: 0,
+				Calls:    []Call{flashCall("nrf52840dk", "-n", "bench-03")},
+				Output:   []string{"Not reusing the device name from the previous run", "Using board from previous run: nRF52840 DK"},
+			},
+		},
+		{
+			Name:     "pending reboot after package updates stops before installing",
+			GOOS:     []string{"linux"},
+			Args:     versionArgs,
+			Commands: hostCommands("uv"),
+			SystemFiles: map[string]string{
+				"var/run/reboot-required":      "*** System restart required ***\n",
+				"var/run/reboot-required.pkgs": "linux-image-6.8.0-45-generic\nlibc6\nlibc6\n",
+			},
+			Expect: Expectation{
+				ExitCode:  2,
+				Steps:     []string{},
+				NotCalled: []string{"uv", "apt-get"},
+				Output:    []string{"SYSTEM REBOOT REQUIRED", "updated packages: linux-image-6.8.0-45-generic, libc6"},
+			},
+		},
+		{
+			Name:        "group added in this session asks for a new login",
+			GOOS:        []string{"linux"},
+			Args:        versionArgs,
+			Commands:    hostCommands("uv", "id"),
+			SystemFiles: map[string]string{"etc/group": "root:x:0:\ndialout:x:20:maker\nplugdev:x:46:maker\n"},
+			Rules: map[string][]Rule{"id": {
+				{Args: []string{"-un"}, Stdout: "maker\n"},
+				{Args: []string{"-Gn"}, Stdout: "maker plugdev\n"},
+			}},
+			Expect: Expectation{
+				ExitCode:  2,
+				NotCalled: []string{"uv"},
+				Output:    []string{"NEW LOGIN SESSION REQUIRED", "added to the dialout group", "log out and back in"},
+			},
+		},
+		{
+			Name: "devices list prints the organization's devices",
+			Args: []string{"devices", "list"},
+			Env:  map[string]string{"HUBBLE_ORG_ID": TestOrgID, "HUBBLE_API_TOKEN": TestAPIToken},
+			Devices: []hubbleapi.Device{
+				{Name: "bench-01", Board: "nrf52840dk", LastSeen: &lastSeen},
+				{Name: "ti-01", Board: "lp_em_cc2340r5"},
+			},
+			Expect: Expectation{
+				ExitCode:    0,
+				Output:      []string{"NAME", "LAST SEEN", "bench-01", "nrf52840dk", "ti-01", "lp_em_cc2340r5", "never"},
+				APIRequests: []string{"GET /api/org/{org}/devices"},
+			},
+		},
+		{
+			Name: "devices show prints one device as JSON",
+			Args: []string{"devices", "show", "--json", "bench-01"},
+			Env:  map[string]string{"HUBBLE_CREDENTIALS": credentials("")},
+			Devices: []hubbleapi.Device{
+				{ID: "7d3c9a52-5b1e-4f0a-9c41-2a8e6f1d0b37", Name: "bench-01", Board: "nrf52840dk", LastSeen: &lastSeen},
+				{Name: "bench-02", Board: "nrf52840dk"},
+			},
+			Expect: Expectation{
+				ExitCode: 0,
+				Output:   []string{`"id": "7d3c9a52-5b1e-4f0a-9c41-2a8e6f1d0b37"`, `"board": "nrf52840dk"`, `"last_seen": "2026-01-02T03:04:05Z"`},
+				JSON:     true,
+			},
+		},
+		{
+			Name:    "devices list --json keeps prompts off standard output",
+			Args:    []string{"devices", "list", "--json"},
+			Answers: []string{TestOrgID, TestAPIToken},
+			Devices: devices("bench-01"),
+			Expect: Expectation{
+				ExitCode: 0,
+				Output:   []string{"Get your credentials at", "Credentials configured", `"name": "bench-01"`},
+				JSON:     true,
+			},
+		},
+		{
+			Name:    "devices show asks for an ID when a name is taken twice",
+			Args:    []string{"devices", "show", "test"},
+			Env:     map[string]string{"HUBBLE_ORG_ID": TestOrgID, "HUBBLE_API_TOKEN": TestAPIToken},
+			Devices: devices("test", "Test", "bench-01"),
+			Expect: Expectation{
+				ExitCode: 1,
+				Output:   []string{`2 devices are named "test"; show one by ID`, "00000000-0000-4000-8000-000000000001", "00000000-0000-4000-8000-000000000002"},
+			},
+		},
+		{
+			Name:     "uninstall dry run lists recorded dependencies without removing them",
+			GOOS:     unixOnly,
+			Args:     []string{"uninstall", "--dry-run"},
+			Commands: []string{"uv"},
+			Files: map[string]string{".config/hubble-install/installed.json": `{"format_version": 1, "items": [
+					{"name": "nrfutil", "method": "uv-tool", "installed_at": "2026-01-01T00:00:00Z"},
+					{"name": "segger-jlink", "method": "manual", "path": "/tmp/JLink_Linux_x86_64.deb", "installed_at": "2026-01-01T00:00:00Z"}]}`},
+			Expect: Expectation{
+				ExitCode:  0,
+				NotCalled: []string{"uv"},
+				Output:    []string{"nrfutil (2026-01-01)", "Uninstall nrfutil: ", "tool uninstall nrfutil", "Dry run: nothing was removed"},
+			},
+		},
+		{
+			Name:     "uninstall removes only what the installer added",
+			GOOS:     unixOnly,
+			Args:     []string{"uninstall", "--yes"},
+			Commands: []string{"uv", "brew", "apt-get"},
+			Files: map[string]string{".config/hubble-install/installed.json": `{"format_version": 1, "items": [
+					{"name": "nrfutil", "method": "uv-tool", "installed_at": "2026-01-01T00:00:00Z"},
+					{"name": "segger-jlink", "method": "manual", "path": "/tmp/JLink_Linux_x86_64.deb", "installed_at": "2026-01-01T00:00:00Z"}]}`},
+			Expect: Expectation{
+				ExitCode: 0,
+				Calls: []Call{
+					{Name: "uv", Args: []string{"cache", "clean", "pyhubbledemo"}},
+					{Name: "uv", Args: []string{"tool", "uninstall", "nrfutil"}},
+				},
+				NotCalled: []string{"brew", "apt-get"},
+				Output:    []string{"Remove segger-jlink with your system's uninstaller (it was installed from JLink_Linux_x86_64.deb)", "Uninstall complete"},
+			},
+		},
+		{
+			Name:     "declining to start runs nothing",
+			Answers:  []string{"n"},
+			Commands: hostCommands("uv"),
+			Expect: Expectation{
+				ExitCode:  0,
+				Steps:     []string{},
+				NotCalled: []string{"uv", "apt-get", "brew"},
+				Output:    []string{"Installation cancelled"},
+			},
+		},
+	}
+}
@@ -0,0 +1,98 @@
+// Command shim stands in for an external tool (uv, JLinkExe, nrfutil, brew, ...)
+// during end-to-end runs. It is copied into a temporary PATH under each tool's
+// name, records every invocation and replays the behavior scripted by the
+// harness.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/HubbleNetwork/hubble-install/internal/e2e"
+)
+
+func main() {
+	name := strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+	args := os.Args[1:]
+
+	data, err := os.ReadFile(os.Getenv(e2e.ConfigEnv))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "shim %s: no harness config: %v\n", name, err)
+		os.Exit(127)
+	}
+
+	var config e2e.ShimConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		fmt.Fprintf(os.Stderr, "shim %s: invalid harness config: %v\n", name, err)
+		os.Exit(127)
+	}
+
+	call := e2e.Call{Name: name, Args: args}
+	for _, key := range config.RecordEnv {
+		if value, ok := os.LookupEnv(key); ok {
+			if call.Env == nil {
+				call.Env = map[string]string{}
+			}
+			call.Env[key] = value
+		}
+	}
+
+	rule, ok := config.Match(name, args)
+	if ok && rule.Stdin {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "shim %s: %v\n", name, err)
+			os.Exit(127)
+		}
+		call.Stdin = string(data)
+	}
+
+	if err := record(config.Log, call); err != nil {
+		fmt.Fprintf(os.Stderr, "shim %s: %v\n", name, err)
+		os.Exit(127)
+	}
+	if !ok {
+		return
+	}
+
+	io.WriteString(os.Stdout, rule.Stdout)
+	io.WriteString(os.Stderr, rule.Stderr)
+
+	for path, content := range rule.Files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "shim %s: %v\n", name, err)
+			os.Exit(127)
+		}
+	}
+
+	// Installing a tool makes it appear on PATH
+	for _, created := range rule.Creates {
+		if err := e2e.CopyExecutable(config.Shim, filepath.Join(config.Bin, created)); err != nil {
+			fmt.Fprintf(os.Stderr, "shim %s: %v\n", name, err)
+			os.Exit(127)
+		}
+	}
+
+	os.Exit(rule.Exit)
+}
+
+// record appends the call to the shared call log
+func record(path string, call e2e.Call) error {
+	line, err := json.Marshal(call)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open call log: %w", err)
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,223 @@
+// Package hex parses and checks Intel HEX firmware images
+package hex
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Record types
+const (
+	recordData            = 0x00
+	recordEOF             = 0x01
+	recordExtendedSegment = 0x02
+	recordStartSegment    = 0x03
+	recordExtendedLinear  = 0x04
+	recordStartLinear     = 0x05
+)
+
+// Range is a contiguous span of addresses, End exclusive
+type Range struct {
+	Start uint32
+	End   uint32
+}
+
+// Size returns the number of bytes in the range
+func (r Range) Size() uint32 {
+	return r.End - r.Start
+}
+
+func (r Range) String() string {
+	return fmt.Sprintf("0x%08X-0x%08X", r.Start, r.End-1)
+}
+
+// Image is a parsed Intel HEX file
+type Image struct {
+	Records   int     // Number of records, including the end-of-file record
+	DataBytes uint32  // Total bytes of data
+	Ranges    []Range // Addresses holding data, sorted and merged
+	SHA256    string  // Hex digest of the file as written
+	Entry     *uint32 // Start address, if the file records one
+}
+
+// Parse reads an Intel HEX file. Every record's checksum is verified, and a
+// file that ends before its end-of-file record is reported as truncated.
+func Parse(data []byte) (*Image, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, errors.New("file is empty")
+	}
+
+	sum := sha256.Sum256(data)
+	img := &Image{SHA256: hex.EncodeToString(sum[:])}
+
+	var base uint32 // From extended segment or linear address records
+	var ranges []Range
+	eof := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if eof {
+			return nil, fmt.Errorf("line %d: data after the end-of-file record", line)
+		}
+
+		rec, err := parseRecord(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		img.Records++
+
+		switch rec.kind {
+		case recordData:
+			start := uint64(base) + uint64(rec.address)
+			end := start + uint64(len(rec.data))
+			if end >= 1<<32 {
+				return nil, fmt.Errorf("line %d: data runs past the 32-bit address space", line)
+			}
+			ranges = append(ranges, Range{Start: uint32(start), End: uint32(end)})
+			img.DataBytes += uint32(len(rec.data))
+		case recordEOF:
+			eof = true
+		case recordExtendedSegment:
+			if len(rec.data) != 2 {
+				return nil, fmt.Errorf("line %d: extended segment address record has %d data bytes", line, len(rec.data))
+			}
+			base = (uint32(rec.data[0])<<8 | uint32(rec.data[1])) << 4
+		case recordExtendedLinear:
+			if len(rec.data) != 2 {
+				return nil, fmt.Errorf("line %d: extended linear address record has %d data bytes", line, len(rec.data))
+			}
+			base = (uint32(rec.data[0])<<8 | uint32(rec.data[1])) << 16
+		case recordStartSegment, recordStartLinear:
+			if len(rec.data) != 4 {
+				return nil, fmt.Errorf("line %d: start address record has %d data bytes", line, len(rec.data))
+			}
+			entry := uint32(rec.data[0])<<24 | uint32(rec.data[1])<<16 | uint32(rec.data[2])<<8 | uint32(rec.data[3])
+			if rec.kind == recordStartSegment {
+				// CS:IP
+				entry = (entry>>16)<<4 + entry&0xFFFF
+			}
+			img.Entry = &entry
+		default:
+			return nil, fmt.Errorf("line %d: unknown record type 0x%02X", line, rec.kind)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !eof {
+		return nil, errors.New("no end-of-file record; the file is truncated")
+	}
+	if img.DataBytes == 0 {
+		return nil, errors.New("file contains no data")
+	}
+
+	merged, err := mergeRanges(ranges)
+	if err != nil {
+		return nil, err
+	}
+	img.Ranges = merged
+	return img, nil
+}
+
+// record is one decoded line
+type record struct {
+	kind    byte
+	address uint16
+	data    []byte
+}
+
+// parseRecord decodes ":LLAAAATT<data>CC" and verifies its checksum
+func parseRecord(text string) (*record, error) {
+	if text[0] != ':' {
+		return nil, fmt.Errorf("record does not start with ':'")
+	}
+	raw, err := hex.DecodeString(text[1:])
+	if err != nil {
+		return nil, fmt.Errorf("record is not valid hex: %w", err)
+	}
+	if len(raw) < 5 {
+		return nil, fmt.Errorf("record is too short")
+	}
+	length := int(raw[0])
+	if len(raw) != length+5 {
+		return nil, fmt.Errorf("record declares %d data bytes but has %d", length, len(raw)-5)
+	}
+
+	var sum byte
+	for _, b := range raw {
+		sum += b
+	}
+	if sum != 0 {
+		return nil, fmt.Errorf("checksum mismatch (record ends in 0x%02X, expected 0x%02X)", raw[len(raw)-1], raw[len(raw)-1]-sum)
+	}
+
+	return &record{
+		kind:    raw[3],
+		address: uint16(raw[1])<<8 | uint16(raw[2]),
+		data:    raw[4 : 4+length],
+	}, nil
+}
+
+// mergeRanges sorts ranges and joins adjacent ones, rejecting overlaps
+func mergeRanges(ranges []Range) ([]Range, error) {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Start < ranges[j].Start })
+
+	var merged []Range
+	for _, r := range ranges {
+		if n := len(merged); n > 0 {
+			last := &merged[n-1]
+			if r.Start < last.End {
+				return nil, fmt.Errorf("data at 0x%08X is written more than once", r.Start)
+			}
+			if r.Start == last.End {
+				last.End = r.End
+				continue
+			}
+		}
+		merged = append(merged, r)
+	}
+	return merged, nil
+}
+
+// CheckFits reports data that lies outside every allowed region
+func (img *Image) CheckFits(regions []Range) error {
+	for _, r := range img.Ranges {
+		if !inside(r, regions) {
+			return fmt.Errorf("data at %s is outside the target's flash", r)
+		}
+	}
+	return nil
+}
+
+// inside reports whether r lies entirely within one of regions
+func inside(r Range, regions []Range) bool {
+	for _, region := range regions {
+		if r.Start >= region.Start && r.End <= region.End {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary describes the image in a few lines for the user
+func (img *Image) Summary() []string {
+	ranges := make([]string, len(img.Ranges))
+	for i, r := range img.Ranges {
+		ranges[i] = r.String()
+	}
+	return []string{
+		fmt.Sprintf("Size: %d bytes of data in %d records", img.DataBytes, img.Records),
+		fmt.Sprintf("Address ranges: %s", strings.Join(ranges, ", ")),
+		fmt.Sprintf("SHA-256: %s", img.SHA256),
+	}
+}
@@ -0,0 +1,204 @@
+package hex
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// rec formats one record with a correct checksum
+func rec(kind byte, address uint16, data ...byte) string {
+	raw := append([]byte{byte(len(data)), byte(address >> 8), byte(address), kind}, data...)
+	var sum byte
+	for _, b := range raw {
+		sum += b
+	}
+	return fmt.Sprintf(":%X%02X", raw, -sum)
+}
+
+// file joins records into a hex file
+func file(records ...string) []byte {
+	return []byte(strings.Join(records, "\n") + "\n")
+}
+
+var eof = rec(recordEOF, 0)
+
+func TestRecordChecksum(t *testing.T) {
+	// Well-known records from the Intel HEX specification
+	for _, line := range []string{
+		":10010000214601360121470136007EFE09D2190140",
+		":0400000500000000F7",
+		":00000001FF",
+	} {
+		if _, err := parseRecord(line); err != nil {
+			t.Errorf("parseRecord(%q) = %v", line, err)
+		}
+	}
+	if got := rec(recordData, 0x0100, 0x21, 0x46, 0x01, 0x36, 0x01, 0x21, 0x47, 0x01, 0x36, 0x00, 0x7E, 0xFE, 0x09, 0xD2, 0x19, 0x01); got != ":10010000214601360121470136007EFE09D2190140" {
+		t.Errorf("rec() = %s", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		records int
+		bytes   uint32
+		ranges  []Range
+		entry   *uint32
+	}{
+		{
+			name:    "adjacent records merge",
+			data:    file(rec(recordData, 0x0000, 1, 2, 3, 4), rec(recordData, 0x0004, 5, 6), eof),
+			records: 3,
+			bytes:   6,
+			ranges:  []Range{{0x0000, 0x0006}},
+		},
+		{
+			name:    "gaps and unsorted records",
+			data:    file(rec(recordData, 0x0100, 1, 2), rec(recordData, 0x0000, 3), eof),
+			records: 3,
+			bytes:   3,
+			ranges:  []Range{{0x0000, 0x0001}, {0x0100, 0x0102}},
+		},
+		{
+			name: "extended linear address",
+			data: file(
+				rec(recordData, 0x0000, 1, 2),
+				rec(recordExtendedLinear, 0, 0x4E, 0x02),
+				rec(recordData, 0x0010, 3, 4, 5),
+				eof,
+			),
+			records: 4,
+			bytes:   5,
+			ranges:  []Range{{0x00000000, 0x00000002}, {0x4E020010, 0x4E020013}},
+		},
+		{
+			name:    "extended segment address",
+			data:    file(rec(recordExtendedSegment, 0, 0x12, 0x34), rec(recordData, 0x0005, 1), eof),
+			records: 3,
+			bytes:   1,
+			ranges:  []Range{{0x12345, 0x12346}},
+		},
+		{
+			name:    "start linear address",
+			data:    file(rec(recordData, 0, 1), rec(recordStartLinear, 0, 0x00, 0x00, 0x01, 0x2D), eof),
+			records: 3,
+			bytes:   1,
+			ranges:  []Range{{0, 1}},
+			entry:   ptr(0x012D),
+		},
+		{
+			name:    "start segment address",
+			data:    file(rec(recordData, 0, 1), rec(recordStartSegment, 0, 0x10, 0x00, 0x00, 0x20), eof),
+			records: 3,
+			bytes:   1,
+			ranges:  []Range{{0, 1}},
+			entry:   ptr(0x10020),
+		},
+		{
+			name:    "CRLF line endings and blank lines",
+			data:    []byte(rec(recordData, 0, 1, 2) + "\r\n\r\n" + eof + "\r\n"),
+			records: 2,
+			bytes:   2,
+			ranges:  []Range{{0, 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := Parse(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if img.Records != tt.records || img.DataBytes != tt.bytes {
+				t.Errorf("records, bytes = %d, %d, want %d, %d", img.Records, img.DataBytes, tt.records, tt.bytes)
+			}
+			if !reflect.DeepEqual(img.Ranges, tt.ranges) {
+				t.Errorf("ranges = %v, want %v", img.Ranges, tt.ranges)
+			}
+			if !reflect.DeepEqual(img.Entry, tt.entry) {
+				t.Errorf("entry = %v, want %v", img.Entry, tt.entry)
+			}
+			sum := sha256.Sum256(tt.data)
+			if img.SHA256 != hex.EncodeToString(sum[:]) {
+				t.Errorf("SHA256 = %s, want the digest of the file", img.SHA256)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	data := rec(recordData, 0, 1, 2, 3, 4)
+	badChecksum := data[:len(data)-2] + "00"
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"empty", []byte(" \n"), "file is empty"},
+		{"bad checksum", file(badChecksum, eof), "line 1: checksum mismatch (record ends in 0x00, expected 0xF2)"},
+		{"missing colon", file(data[1:], eof), "line 1: record does not start with ':'"},
+		{"not hex", file(":0G", eof), "line 1: record is not valid hex"},
+		{"odd length", file(data[:len(data)-1], eof), "line 1: record is not valid hex"},
+		{"too short", file(":0000", eof), "line 1: record is too short"},
+		{"length mismatch", file(":05000000010203FA", eof), "line 1: record declares 5 data bytes but has 3"},
+		{"no end-of-file record", file(data), "no end-of-file record; the file is truncated"},
+		{"cut off mid-record", []byte(data + "\n" + eof[:5]), "line 2: record is too short"},
+		{"data after end of file", file(data, eof, data), "line 3: data after the end-of-file record"},
+		{"no data", file(eof), "file contains no data"},
+		{"unknown record type", file(rec(0x06, 0, 1), eof), "line 1: unknown record type 0x06"},
+		{"bad extended linear", file(rec(recordExtendedLinear, 0, 1), eof), "line 1: extended linear address record has 1 data bytes"},
+		{"bad extended segment", file(rec(recordExtendedSegment, 0, 1, 2, 3), eof), "line 1: extended segment address record has 3 data bytes"},
+		{"bad start address", file(rec(recordStartLinear, 0, 1, 2), eof), "line 1: start address record has 2 data bytes"},
+		{"past 4 GiB", file(rec(recordExtendedLinear, 0, 0xFF, 0xFF), rec(recordData, 0xFFFE, 1, 2, 3), eof), "line 2: data runs past the 32-bit address space"},
+		{"overlap", file(rec(recordData, 0x0000, 1, 2, 3, 4), rec(recordData, 0x0002, 5), eof), "data at 0x00000002 is written more than once"},
+		{"duplicate record", file(data, data, eof), "data at 0x00000000 is written more than once"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := Parse(tt.data)
+			if err == nil {
+				t.Fatalf("Parse() = %+v, want an error containing %q", img, tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckFits(t *testing.T) {
+	flash := []Range{{0x00000000, 0x00080000}, {0x4E020000, 0x4E020800}}
+	tests := []struct {
+		name   string
+		ranges []Range
+		want   string
+	}{
+		{"inside one region", []Range{{0x0000, 0x1000}}, ""},
+		{"inside each region", []Range{{0x0000, 0x1000}, {0x4E020000, 0x4E020800}}, ""},
+		{"fills the region exactly", []Range{{0x00000000, 0x00080000}}, ""},
+		{"runs past the end", []Range{{0x0007F000, 0x00080001}}, "data at 0x0007F000-0x00080000 is outside the target's flash"},
+		{"between regions", []Range{{0x20000000, 0x20000010}}, "data at 0x20000000-0x2000000F is outside"},
+		{"spans two regions", []Range{{0x0007FFFF, 0x4E020001}}, "is outside"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Image{Ranges: tt.ranges}).CheckFits(flash)
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("CheckFits() = %v", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Errorf("CheckFits() = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func ptr(v uint32) *uint32 {
+	return &v
+}
@@ -0,0 +1,220 @@
+// Package hubbleapi is a client for the Hubble platform API
+package hubbleapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HubbleNetwork/hubble-install/internal/netconfig"
+	"github.com/HubbleNetwork/hubble-install/internal/retry"
+)
+
+// DefaultBaseURL is the production API
+const DefaultBaseURL = "https://api.hubble.com"
+
+// BaseURLEnv overrides the API location, e.g. for a local stub server
+const BaseURLEnv = "HUBBLE_API_URL"
+
+// Environments maps the API environment named in v2 credentials to its base URL
+var Environments = map[string]string{
+	"production": DefaultBaseURL,
+}
+
+// Errors returned for requests the API refused
+var (
+	ErrUnauthorized = errors.New("the API token is invalid, expired or revoked")
+	ErrForbidden    = errors.New("the API token does not have permission for this organization")
+	ErrOrgNotFound  = errors.New("no organization with this Org ID is visible to the API token; check the Org ID")
+	ErrNotFound     = errors.New("not found")
+)
+
+// RateLimitError is returned when the API kept answering 429 Too Many Requests
+type RateLimitError struct {
+	RetryAfter time.Duration // What the last response asked for; zero if it didn't say
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("Hubble API rate limit reached; try again in %s", e.RetryAfter.Round(time.Second))
+	}
+	return "Hubble API rate limit reached; try again later"
+}
+
+// StatusError is an unexpected API response
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("Hubble API returned %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("Hubble API returned %d", e.StatusCode)
+}
+
+// Rate limit handling: a 429 response is retried after its Retry-After
+// delay, up to rateLimitRetries times, waiting at most maxRetryAfter each time
+const (
+	rateLimitRetries  = 3
+	defaultRetryAfter = time.Second
+	maxRetryAfter     = 30 * time.Second
+)
+
+// Client calls the API for one organization
+type Client struct {
+	BaseURL string
+	OrgID   string
+	Token   string
+	HTTP    *http.Client
+	Sleeper retry.Sleeper // Waits out rate limits; nil uses a timer
+}
+
+// New returns a client that honors the configured proxy and CA bundle
+func New(baseURL, orgID, token string) *Client {
+	return &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		OrgID:   orgID,
+		Token:   token,
+		HTTP:    netconfig.Client(30 * time.Second),
+	}
+}
+
+// CheckCredentials calls a lightweight authenticated endpoint to confirm
+// that the token is valid and may access the organization
+func (c *Client) CheckCredentials(ctx context.Context) error {
+	return c.orgErr(c.do(ctx, http.MethodGet, c.orgPath("check"), nil, nil))
+}
+
+// orgPath returns the path of an endpoint below the organization
+func (c *Client) orgPath(elem ...string) string {
+	return "/api/org/" + url.PathEscape(c.OrgID) + "/" + strings.Join(elem, "/")
+}
+
+// do sends a request with an optional JSON body and decodes a JSON response
+// into out. Rate-limited requests are repeated after the delay the API asks for.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := c.send(ctx, method, path, data, out)
+		var limited *RateLimitError
+		if !errors.As(err, &limited) || attempt == rateLimitRetries || limited.RetryAfter > maxRetryAfter {
+			return err
+		}
+		wait := limited.RetryAfter
+		if wait <= 0 {
+			wait = defaultRetryAfter
+		}
+		if err := c.sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// send makes a single attempt at a request
+func (c *Client) send(ctx context.Context, method, path string, data []byte, out any) error {
+	var reader io.Reader
+	if data != nil {
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Accept", "application/json")
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case resp.StatusCode == http.StatusForbidden:
+		return ErrForbidden
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return &RateLimitError{RetryAfter: retryAfter(resp.Header.Get("Retry-After"), time.Now())}
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return &StatusError{StatusCode: resp.StatusCode, Message: errorMessage(resp.Body)}
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response from %s: %w", path, err)
+	}
+	return nil
+}
+
+// sleep waits for d or until ctx is done
+func (c *Client) sleep(ctx context.Context, d time.Duration) error {
+	if c.Sleeper != nil {
+		return c.Sleeper.Sleep(ctx, d)
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(value); err == nil && when.After(now) {
+		return when.Sub(now)
+	}
+	return 0
+}
+
+// errorMessage extracts the message from an error response body
+func errorMessage(body io.Reader) string {
+	data, _ := io.ReadAll(io.LimitReader(body, 4<<10))
+	var parsed struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &parsed) == nil {
+		if parsed.Message != "" {
+			return parsed.Message
+		}
+		if parsed.Error != "" {
+			return parsed.Error
+		}
+	}
+	return strings.TrimSpace(string(data))
+}
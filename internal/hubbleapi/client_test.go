@@ -0,0 +1,310 @@
+package hubbleapi_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/HubbleNetwork/hubble-install/internal/hubbleapi"
+	"github.com/HubbleNetwork/hubble-install/internal/hubbleapi/hubbleapitest"
+	"github.com/HubbleNetwork/hubble-install/internal/runner"
+)
+
+const (
+	testOrg   = "org-1"
+	testToken = "token-1"
+)
+
+// newClient starts a fake API and returns a client for it whose rate-limit
+// waits are recorded by the returned Fake instead of slept
+func newClient(t *testing.T, orgID, token string) (*hubbleapi.Client, *hubbleapitest.Server, *runner.Fake) {
+	t.Helper()
+	server := hubbleapitest.NewServer(testOrg, testToken)
+	t.Cleanup(server.Close)
+
+	sleeper := runner.NewFake()
+	client := hubbleapi.New(server.URL, orgID, token)
+	client.Sleeper = sleeper
+	return client, server, sleeper
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"0", 0},
+		{"5", 5 * time.Second},
+		{"120", 2 * time.Minute},
+		{"-1", 0},
+		{"soon", 0},
+		{now.Add(10 * time.Second).Format(http.TimeFormat), 10 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := hubbleapi.RetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("RetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		limited    int
+		retryAfter string
+		wantSlept  time.Duration
+		wantCalls  int
+		wantWait   time.Duration // RetryAfter of the returned error; -1 for success
+	}{
+		{name: "retried after the requested delay", limited: 2, retryAfter: "3", wantSlept: 6 * time.Second, wantCalls: 3, wantWait: -1},
+		{name: "default delay without Retry-After", limited: 1, wantSlept: time.Second, wantCalls: 2, wantWait: -1},
+		{name: "gives up after three retries", limited: 10, retryAfter: "1", wantSlept: 3 * time.Second, wantCalls: 4, wantWait: time.Second},
+		{name: "too long a delay is not waited out", limited: 1, retryAfter: "60", wantCalls: 1, wantWait: time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server, sleeper := newClient(t, testOrg, testToken)
+			server.RateLimit(tt.limited, tt.retryAfter)
+
+			err := client.CheckCredentials(context.Background())
+
+			var limited *hubbleapi.RateLimitError
+			switch {
+			case tt.wantWait < 0 && err != nil:
+				t.Fatalf("CheckCredentials() = %v", err)
+			case tt.wantWait >= 0 && !errors.As(err, &limited):
+				t.Fatalf("CheckCredentials() = %v, want a RateLimitError", err)
+			case tt.wantWait >= 0 && limited.RetryAfter != tt.wantWait:
+				t.Errorf("RetryAfter = %v, want %v", limited.RetryAfter, tt.wantWait)
+			}
+			if got := sleeper.Slept(); got != tt.wantSlept {
+				t.Errorf("slept %v, want %v", got, tt.wantSlept)
+			}
+			if got := len(server.Requests()); got != tt.wantCalls {
+				t.Errorf("made %d requests, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRateLimitWaitCancelled(t *testing.T) {
+	server := hubbleapitest.NewServer(testOrg, testToken)
+	defer server.Close()
+	server.RateLimit(1, "30")
+
+	// No Sleeper: the client waits on a timer, which the deadline interrupts
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	client := hubbleapi.New(server.URL, testOrg, testToken)
+	if err := client.CheckCredentials(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("CheckCredentials() = %v, want context.DeadlineExceeded", err)
+	}
+	if got := len(server.Requests()); got != 1 {
+		t.Errorf("made %d requests, want 1", got)
+	}
+}
+
+func TestErrorMapping(t *testing.T) {
+	tests := []struct {
+		name  string
+		org   string
+		token string
+		call  func(*hubbleapi.Client) error
+		want  error
+	}{
+		{
+			name:  "revoked token",
+			org:   testOrg,
+			token: "revoked",
+			call:  func(c *hubbleapi.Client) error { return c.CheckCredentials(context.Background()) },
+			want:  hubbleapi.ErrUnauthorized,
+		},
+		{
+			name:  "unknown organization",
+			org:   "other-org",
+			token: testToken,
+			call:  func(c *hubbleapi.Client) error { return c.CheckCredentials(context.Background()) },
+			want:  hubbleapi.ErrOrgNotFound,
+		},
+		{
+			name:  "unknown organization when listing",
+			org:   "other-org",
+			token: testToken,
+			call: func(c *hubbleapi.Client) error {
+				_, err := c.ListDevices(context.Background())
+				return err
+			},
+			want: hubbleapi.ErrOrgNotFound,
+		},
+		{
+			name:  "unknown device",
+			org:   testOrg,
+			token: testToken,
+			call: func(c *hubbleapi.Client) error {
+				_, err := c.GetDevice(context.Background(), "missing")
+				return err
+			},
+			want: hubbleapi.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _, _ := newClient(t, tt.org, tt.token)
+			if err := tt.call(client); !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestForbidden(t *testing.T) {
+	server := newStatusServer(t, http.StatusForbidden)
+	client := hubbleapi.New(server, testOrg, testToken)
+	if err := client.CheckCredentials(context.Background()); !errors.Is(err, hubbleapi.ErrForbidden) {
+		t.Errorf("CheckCredentials() = %v, want ErrForbidden", err)
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	server := newStatusServer(t, http.StatusInternalServerError)
+	client := hubbleapi.New(server, testOrg, testToken)
+
+	err := client.CheckCredentials(context.Background())
+	var status *hubbleapi.StatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusInternalServerError || status.Message != "boom" {
+		t.Errorf("CheckCredentials() = %#v, want a 500 StatusError with the message", err)
+	}
+}
+
+func TestListDevicesPagination(t *testing.T) {
+	client, server, _ := newClient(t, testOrg, testToken)
+	server.SetPageSize(3)
+	var want []string
+	for i := range 8 {
+		name := fmt.Sprintf("device-%d", i)
+		server.AddDevice(hubbleapi.Device{Name: name})
+		want = append(want, name)
+	}
+
+	devices, err := client.ListDevices(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range devices {
+		got = append(got, d.Name)
+		if d.Key != "" {
+			t.Errorf("listing returned the key of %s", d.Name)
+		}
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("devices = %v, want %v", got, want)
+	}
+	if pages := len(server.Requests()); pages != 3 {
+		t.Errorf("fetched %d pages, want 3", pages)
+	}
+
+	page, err := client.ListDevicesPage(context.Background(), "6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Devices) != 2 || page.Next != "" {
+		t.Errorf("last page = %+v, want 2 devices and no continuation token", page)
+	}
+}
+
+func TestListDevicesEmpty(t *testing.T) {
+	client, _, _ := newClient(t, testOrg, testToken)
+	devices, err := client.ListDevices(context.Background())
+	if err != nil || len(devices) != 0 {
+		t.Errorf("ListDevices() = %v, %v, want no devices", devices, err)
+	}
+}
+
+func TestDeviceLifecycle(t *testing.T) {
+	client, server, _ := newClient(t, testOrg, testToken)
+	ctx := context.Background()
+
+	created, err := client.CreateDevice(ctx, "desk-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ID == "" || created.Key == "" || created.Name != "desk-1" {
+		t.Errorf("created = %+v, want an ID, key and the name", created)
+	}
+
+	got, err := client.GetDevice(ctx, created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "desk-1" || got.Key != "" {
+		t.Errorf("GetDevice() = %+v", got)
+	}
+
+	renamed, err := client.RenameDevice(ctx, created.ID, "desk-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed.ID != created.ID || renamed.Name != "desk-2" {
+		t.Errorf("RenameDevice() = %+v", renamed)
+	}
+	if devices := server.Devices(); len(devices) != 1 || devices[0].Name != "desk-2" {
+		t.Errorf("server devices after rename = %+v", devices)
+	}
+
+	if err := client.DeleteDevice(ctx, created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if devices := server.Devices(); len(devices) != 0 {
+		t.Errorf("server devices after delete = %+v", devices)
+	}
+	if err := client.DeleteDevice(ctx, created.ID); !errors.Is(err, hubbleapi.ErrNotFound) {
+		t.Errorf("deleting again = %v, want ErrNotFound", err)
+	}
+	if _, err := client.RenameDevice(ctx, created.ID, "desk-3"); !errors.Is(err, hubbleapi.ErrNotFound) {
+		t.Errorf("renaming a deleted device = %v, want ErrNotFound", err)
+	}
+
+	want := []string{
+		"POST /api/org/org-1/devices",
+		"GET /api/org/org-1/devices/" + created.ID,
+		"PATCH /api/org/org-1/devices/" + created.ID,
+		"DELETE /api/org/org-1/devices/" + created.ID,
+		"DELETE /api/org/org-1/devices/" + created.ID,
+		"PATCH /api/org/org-1/devices/" + created.ID,
+	}
+	if got := server.Requests(); !slices.Equal(got, want) {
+		t.Errorf("requests = %q, want %q", got, want)
+	}
+}
+
+func TestCreateDeviceUnauthorized(t *testing.T) {
+	client, server, _ := newClient(t, testOrg, "revoked")
+	if _, err := client.CreateDevice(context.Background(), "desk-1"); !errors.Is(err, hubbleapi.ErrUnauthorized) {
+		t.Errorf("CreateDevice() = %v, want ErrUnauthorized", err)
+	}
+	if devices := server.Devices(); len(devices) != 0 {
+		t.Errorf("server devices = %+v, want none", devices)
+	}
+}
+
+// newStatusServer answers every request with status and a "boom" message,
+// for responses the fake API never sends
+func newStatusServer(t *testing.T, status int) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprint(w, `{"message":"boom"}`)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
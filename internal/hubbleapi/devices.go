@@ -0,0 +1,116 @@
+package hubbleapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Device is a device registered in the organization
+type Device struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Board     string     `json:"board,omitempty"`
+	Key       string     `json:"key,omitempty"` // Base64 device key; only returned when the device is created
+	CreatedAt time.Time  `json:"created_at"`
+	LastSeen  *time.Time `json:"last_seen,omitempty"` // Last packet received; nil if none yet
+}
+
+// DevicePage is one page of a device listing
+type DevicePage struct {
+	Devices []Device `json:"devices"`
+	Next    string   `json:"continuation_token,omitempty"` // Empty on the last page
+}
+
+// pageSize is how many devices are requested per page
+const pageSize = 100
+
+// maxPages stops a listing whose continuation tokens never run out
+const maxPages = 1000
+
+// CreateDevice registers a new device and returns it with its key
+func (c *Client) CreateDevice(ctx context.Context, name string) (*Device, error) {
+	var created struct {
+		Devices []Device `json:"devices"`
+	}
+	body := map[string]any{"n_devices": 1, "names": []string{name}}
+	if err := c.do(ctx, http.MethodPost, c.orgPath("devices"), body, &created); err != nil {
+		return nil, fmt.Errorf("creating device %q: %w", name, c.orgErr(err))
+	}
+	if len(created.Devices) != 1 {
+		return nil, fmt.Errorf("creating device %q: API returned %d devices", name, len(created.Devices))
+	}
+	return &created.Devices[0], nil
+}
+
+// ListDevicesPage returns one page of devices. Pass an empty token for the
+// first page and DevicePage.Next for the following ones.
+func (c *Client) ListDevicesPage(ctx context.Context, token string) (*DevicePage, error) {
+	query := url.Values{"page_size": {strconv.Itoa(pageSize)}}
+	if token != "" {
+		query.Set("continuation_token", token)
+	}
+
+	var page DevicePage
+	if err := c.do(ctx, http.MethodGet, c.orgPath("devices")+"?"+query.Encode(), nil, &page); err != nil {
+		return nil, fmt.Errorf("listing devices: %w", c.orgErr(err))
+	}
+	return &page, nil
+}
+
+// ListDevices returns every device in the organization, following pagination
+func (c *Client) ListDevices(ctx context.Context) ([]Device, error) {
+	var devices []Device
+	token := ""
+	for range maxPages {
+		page, err := c.ListDevicesPage(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, page.Devices...)
+		if page.Next == "" {
+			return devices, nil
+		}
+		token = page.Next
+	}
+	return nil, fmt.Errorf("listing devices: more than %d pages", maxPages)
+}
+
+// GetDevice returns one device by ID
+func (c *Client) GetDevice(ctx context.Context, id string) (*Device, error) {
+	var device Device
+	if err := c.do(ctx, http.MethodGet, c.orgPath("devices", url.PathEscape(id)), nil, &device); err != nil {
+		return nil, fmt.Errorf("getting device %s: %w", id, err)
+	}
+	return &device, nil
+}
+
+// RenameDevice changes a device's name and returns the updated device
+func (c *Client) RenameDevice(ctx context.Context, id, name string) (*Device, error) {
+	var device Device
+	body := map[string]string{"name": name}
+	if err := c.do(ctx, http.MethodPatch, c.orgPath("devices", url.PathEscape(id)), body, &device); err != nil {
+		return nil, fmt.Errorf("renaming device %s: %w", id, err)
+	}
+	return &device, nil
+}
+
+// DeleteDevice removes a device from the organization
+func (c *Client) DeleteDevice(ctx context.Context, id string) error {
+	if err := c.do(ctx, http.MethodDelete, c.orgPath("devices", url.PathEscape(id)), nil, nil); err != nil {
+		return fmt.Errorf("deleting device %s: %w", id, err)
+	}
+	return nil
+}
+
+// orgErr reports a 404 from an organization-level endpoint as an unknown organization
+func (c *Client) orgErr(err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return ErrOrgNotFound
+	}
+	return err
+}
@@ -0,0 +1,4 @@
+package hubbleapi
+
+// RetryAfter exposes retryAfter to the external tests
+var RetryAfter = retryAfter
@@ -0,0 +1,253 @@
+// Package hubbleapitest runs an in-process fake of the Hubble platform API
+// for end-to-end tests and local development
+package hubbleapitest
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HubbleNetwork/hubble-install/internal/hubbleapi"
+)
+
+// Server is a fake Hubble API that accepts one token for one organization
+type Server struct {
+	*httptest.Server
+
+	OrgID string
+	Token string
+
+	mu         sync.Mutex
+	requests   []string
+	devices    []hubbleapi.Device
+	nextID     int
+	pageSize   int
+	limited    int
+	retryAfter string
+}
+
+// NewServer starts a fake API for the given organization and token
+func NewServer(orgID, token string) *Server {
+	s := &Server{OrgID: orgID, Token: token}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Requests returns "METHOD /path" for every request received, in order
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// AddDevice registers a device as if it had been created earlier. The ID,
+// key and creation time are filled in unless set.
+func (s *Server) AddDevice(d hubbleapi.Device) hubbleapi.Device {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	created := s.create(d.Name)
+	if d.ID != "" {
+		created.ID = d.ID
+	}
+	if !d.CreatedAt.IsZero() {
+		created.CreatedAt = d.CreatedAt
+	}
+	created.Board = d.Board
+	created.LastSeen = d.LastSeen
+	s.devices[len(s.devices)-1] = created
+	return created
+}
+
+// Devices returns the registered devices, oldest first
+func (s *Server) Devices() []hubbleapi.Device {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]hubbleapi.Device(nil), s.devices...)
+}
+
+// SetPageSize caps the number of devices per page, to exercise pagination
+func (s *Server) SetPageSize(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pageSize = n
+}
+
+// RateLimit answers the next n requests with 429 and the given Retry-After
+// header (empty to omit it)
+func (s *Server) RateLimit(n int, retryAfter string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limited = n
+	s.retryAfter = retryAfter
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+
+	if s.limited > 0 {
+		s.limited--
+		if s.retryAfter != "" {
+			w.Header().Set("Retry-After", s.retryAfter)
+		}
+		writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer "+s.Token {
+		writeError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	org, rest, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/org/"), "/")
+	if !ok || !strings.HasPrefix(r.URL.Path, "/api/org/") {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if org != s.OrgID {
+		writeError(w, http.StatusNotFound, "organization not found")
+		return
+	}
+
+	switch {
+	case rest == "check" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]string{"org_id": s.OrgID})
+	case rest == "devices" && r.Method == http.MethodPost:
+		s.createDevices(w, r)
+	case rest == "devices" && r.Method == http.MethodGet:
+		s.listDevices(w, r)
+	case strings.HasPrefix(rest, "devices/"):
+		s.serveDevice(w, r, strings.TrimPrefix(rest, "devices/"))
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// createDevices handles POST /devices
+func (s *Server) createDevices(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		NDevices int      `json:"n_devices"`
+		Names    []string `json:"names"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.NDevices < 1 || len(req.Names) > req.NDevices {
+		writeError(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+
+	created := make([]hubbleapi.Device, 0, req.NDevices)
+	for i := range req.NDevices {
+		name := ""
+		if i < len(req.Names) {
+			name = req.Names[i]
+		}
+		created = append(created, s.create(name))
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"devices": created})
+}
+
+// listDevices handles GET /devices, paging with an offset as the continuation token
+func (s *Server) listDevices(w http.ResponseWriter, r *http.Request) {
+	size, err := strconv.Atoi(r.URL.Query().Get("page_size"))
+	if err != nil || size < 1 {
+		size = 100
+	}
+	if s.pageSize > 0 && s.pageSize < size {
+		size = s.pageSize
+	}
+	start := 0
+	if token := r.URL.Query().Get("continuation_token"); token != "" {
+		if start, err = strconv.Atoi(token); err != nil || start < 0 || start > len(s.devices) {
+			writeError(w, http.StatusBadRequest, "invalid continuation token")
+			return
+		}
+	}
+
+	end := min(start+size, len(s.devices))
+	page := hubbleapi.DevicePage{Devices: make([]hubbleapi.Device, 0, end-start)}
+	for _, d := range s.devices[start:end] {
+		d.Key = "" // Keys are only returned on creation
+		page.Devices = append(page.Devices, d)
+	}
+	if end < len(s.devices) {
+		page.Next = strconv.Itoa(end)
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// serveDevice handles GET, PATCH and DELETE /devices/{id}
+func (s *Server) serveDevice(w http.ResponseWriter, r *http.Request, id string) {
+	i := s.find(id)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "device not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		d := s.devices[i]
+		d.Key = ""
+		writeJSON(w, http.StatusOK, d)
+	case http.MethodPatch:
+		var req struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request")
+			return
+		}
+		s.devices[i].Name = req.Name
+		d := s.devices[i]
+		d.Key = ""
+		writeJSON(w, http.StatusOK, d)
+	case http.MethodDelete:
+		s.devices = append(s.devices[:i], s.devices[i+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// create adds a device with a deterministic ID and key; s.mu must be held
+func (s *Server) create(name string) hubbleapi.Device {
+	s.nextID++
+	id := fmt.Sprintf("00000000-0000-4000-8000-%012d", s.nextID)
+	key := sha256.Sum256([]byte(id))
+	d := hubbleapi.Device{
+		ID:        id,
+		Name:      name,
+		Key:       base64.StdEncoding.EncodeToString(key[:]),
+		CreatedAt: time.Date(2025, 1, 1, 0, 0, s.nextID, 0, time.UTC),
+	}
+	s.devices = append(s.devices, d)
+	return d
+}
+
+// find returns the index of the device with the given ID, or -1; s.mu must be held
+func (s *Server) find(id string) int {
+	for i, d := range s.devices {
+		if d.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// writeJSON sends v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError sends an error response in the API's format
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
@@ -0,0 +1,299 @@
+package hubbledemo
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/HubbleNetwork/hubble-install/internal/retry"
+	"github.com/HubbleNetwork/hubble-install/internal/runner"
+)
+
+// Package is the PyPI package that provides the hubbledemo CLI
+const Package = "pyhubbledemo"
+
+// DefaultVersion is the pyhubbledemo version this build is pinned to.
+// Release builds set it with:
+//
+//	-ldflags "-X github.com/HubbleNetwork/hubble-install/internal/hubbledemo.DefaultVersion=x.y.z"
+//
+// When empty (development builds) the latest release is resolved at run time.
+var DefaultVersion = ""
+
+// lockHeader is written at the top of generated lock files
+const lockHeader = `# pyhubbledemo lock file generated by hubble-install
+# Pass it back with --lock-file to reproduce this exact tool environment.
+`
+
+var (
+	requestedVersion = DefaultVersion
+	overridden       bool // requestedVersion came from --tool-version
+	lockFile         string
+
+	// Resolved state, filled in by Prepare
+	prepared bool
+	version  string
+	useLock  bool
+)
+
+// Configure selects the pyhubbledemo version and optional lock file for all runs.
+// An empty version keeps DefaultVersion; "latest" explicitly disables pinning.
+// If lockPath exists it takes precedence; otherwise it is written by Prepare.
+func Configure(toolVersion, lockPath string) {
+	if toolVersion != "" {
+		requestedVersion = toolVersion
+		overridden = true
+	}
+	if requestedVersion == "latest" {
+		requestedVersion = ""
+	}
+	lockFile = lockPath
+	prepared = false
+}
+
+// Prepare resolves the exact pyhubbledemo version that will be run, reading
+// or writing the lock file as configured, and downloads it into uv's cache.
+// Network failures are retried here, so the later run that registers the
+// device doesn't fail on package downloads. It returns the version.
+func Prepare(ctx context.Context, r runner.Runner, uvPath string) (string, error) {
+	if prepared {
+		return version, nil
+	}
+	if err := selectVersion(ctx, r, uvPath); err != nil {
+		return "", err
+	}
+
+	// Spec and RunArgs use the selected version from here on
+	prepared = true
+	if err := retry.Download.Do(ctx, r, "Downloading "+Package, func(ctx context.Context) error {
+		return fetch(ctx, r, uvPath)
+	}); err != nil {
+		prepared = false
+		return "", fmt.Errorf("failed to download %s: %w", spec(version), err)
+	}
+
+	return version, nil
+}
+
+// selectVersion sets version (and useLock) from the lock file or by resolving
+// the requested version
+func selectVersion(ctx context.Context, r runner.Runner, uvPath string) error {
+	// An existing lock file pins the whole environment, including hashes
+	if lockFile != "" {
+		if data, err := r.ReadFile(lockFile); err == nil {
+			v := parseVersion(string(data))
+			if v == "" {
+				return fmt.Errorf("lock file %s does not pin %s", lockFile, Package)
+			}
+			if overridden && requestedVersion != v {
+				return fmt.Errorf("lock file %s pins %s %s but --tool-version requested %s", lockFile, Package, v, requestedVersion)
+			}
+			version, useLock = v, true
+			return nil
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read lock file: %w", err)
+		}
+	}
+
+	var compiled string
+	err := retry.Download.Do(ctx, r, "Resolving "+spec(requestedVersion), func(ctx context.Context) error {
+		var err error
+		compiled, err = resolve(ctx, r, uvPath, spec(requestedVersion))
+		return err
+	})
+	if err != nil {
+		if requestedVersion == "" || ctx.Err() != nil {
+			return fmt.Errorf("failed to resolve %s: %w", Package, err)
+		}
+		// Pinned version is still usable without the resolved hashes
+		version = requestedVersion
+		return nil
+	}
+
+	version = parseVersion(compiled)
+	if version == "" {
+		return fmt.Errorf("resolution did not include %s", Package)
+	}
+
+	if lockFile != "" {
+		if err := writeLock(r, lockFile, lockHeader+compiled); err != nil {
+			return fmt.Errorf("failed to write lock file: %w", err)
+		}
+		useLock = true
+	}
+
+	return nil
+}
+
+// Run runs a hubbledemo command that registers a device (flashing or hex
+// generation). It is only repeated when the failure shows the registration
+// request never reached the Hubble API, so a device is not registered twice.
+// hubbledemo does not say when it sends that request, so once Launcher
+// reports StartMarker any failure counts as ambiguous; only failures of uv
+// itself (resolving or installing the tool) are retried. cmd must run
+// Launcher (see FlashArgs), which reads apiToken from stdin.
+func Run(ctx context.Context, r runner.Runner, cmd *runner.Command, apiToken string) error {
+	capture := retry.NewCapture(cmd.Stderr).StartAt(StartMarker)
+	attempt := *cmd
+	attempt.Stderr = capture
+	return retry.Registration.Do(ctx, r, "Device registration", func(ctx context.Context) error {
+		// Every attempt reads the token afresh
+		attempt.Stdin = strings.NewReader(apiToken + "\n")
+		return capture.Err(r.Run(ctx, &attempt))
+	})
+}
+
+// Version returns the prepared version, or "unknown" before Prepare succeeds
+func Version() string {
+	if !prepared || version == "" {
+		return "unknown"
+	}
+	return version
+}
+
+// LockFile returns the lock file pinning the prepared environment, or ""
+// when runs are not locked
+func LockFile() string {
+	if prepared && useLock {
+		return lockFile
+	}
+	return ""
+}
+
+// Spec returns the requirement used to run the tool (e.g. "pyhubbledemo==1.2.3")
+func Spec() string {
+	if prepared && version != "" {
+		return spec(version)
+	}
+	return spec(requestedVersion)
+}
+
+// RunArgs returns the uv arguments that run hubbledemo with the given arguments
+func RunArgs(args ...string) []string {
+	return append(toolArgs("hubbledemo"), args...)
+}
+
+// toolArgs returns the uv arguments that run command in the tool environment
+func toolArgs(command ...string) []string {
+	uvArgs := []string{"tool", "run"}
+	if Spec() == Package {
+		// Unpinned: refresh so every machine gets the current release
+		uvArgs = append(uvArgs, "--refresh")
+	}
+	uvArgs = append(uvArgs, "--from", Spec())
+	if useLock {
+		uvArgs = append(uvArgs, "--with-requirements", lockFile)
+	}
+	return append(uvArgs, command...)
+}
+
+// TokenEnv is the conventional variable for the API token, used in the
+// command printed for flashing later
+const TokenEnv = "HUBBLE_API_TOKEN"
+
+// Launcher runs the hubbledemo console script with "-t <token>" appended to
+// its arguments inside the Python process. The token is read from stdin, so
+// it is never on a command line, where every local user can see it (ps,
+// process accounting). This works with every pyhubbledemo release, as all of
+// them take -t. It prints StartMarker to stderr right before handing over to
+// hubbledemo.
+const Launcher = `import sys
+from importlib.metadata import entry_points
+token = sys.stdin.readline().strip()
+try:
+    scripts = entry_points(group="console_scripts", name="hubbledemo")
+except TypeError:  # Python < 3.10
+    scripts = [e for e in entry_points().get("console_scripts", []) if e.name == "hubbledemo"]
+sys.argv = ["hubbledemo"] + sys.argv[1:] + ["-t", token]
+main = next(iter(scripts)).load()
+print("` + StartMarker + `", file=sys.stderr, flush=True)
+sys.exit(main())`
+
+// StartMarker is printed by Launcher once hubbledemo is installed and about
+// to run. Anything that fails after it may already have registered a device.
+const StartMarker = "Starting hubbledemo"
+
+// FlashArgs returns the uv arguments for "hubbledemo flash". When hexFile is
+// set the image is written to that file instead of being flashed. The API
+// token is not part of them: Run hands it to Launcher on stdin.
+func FlashArgs(board, orgID, deviceName, hexFile string) []string {
+	args := append(toolArgs("python", "-c", Launcher), "flash", board, "-o", orgID)
+	if hexFile != "" {
+		args = append(args, "-f", hexFile)
+	}
+	if deviceName != "" {
+		args = append(args, "-n", deviceName)
+	}
+	return args
+}
+
+// spec builds a requirement string for the given version
+func spec(v string) string {
+	if v == "" {
+		return Package
+	}
+	return Package + "==" + v
+}
+
+// resolve runs "uv pip compile" for the requirement and returns the fully
+// pinned, hashed requirements it produces
+func resolve(ctx context.Context, r runner.Runner, uvPath, requirement string) (string, error) {
+	capture := retry.NewCapture(os.Stderr)
+	cmd := &runner.Command{
+		Name:   uvPath,
+		Args:   []string{"pip", "compile", "-", "--generate-hashes", "--universal", "--no-header", "--quiet"},
+		Stdin:  strings.NewReader(requirement + "\n"),
+		Stderr: capture,
+	}
+	output, err := r.Output(ctx, cmd)
+	if err != nil {
+		return "", capture.Err(err)
+	}
+	return string(output), nil
+}
+
+// fetch runs "hubbledemo --help" so uv downloads the tool and its
+// dependencies without touching the Hubble API
+func fetch(ctx context.Context, r runner.Runner, uvPath string) error {
+	capture := retry.NewCapture(os.Stderr)
+	cmd := &runner.Command{
+		Name:   uvPath,
+		Args:   RunArgs("--help"),
+		Env:    []string{"PYTHONWARNINGS=ignore"},
+		Stderr: capture,
+	}
+	return capture.Err(r.Run(ctx, cmd))
+}
+
+// writeLock writes the lock file contents
+func writeLock(r runner.Runner, path, contents string) error {
+	f, err := r.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write([]byte(contents)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// parseVersion finds the pinned pyhubbledemo version in requirements text
+func parseVersion(requirements string) string {
+	scanner := bufio.NewScanner(strings.NewReader(requirements))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, Package+"==") {
+			continue
+		}
+		v := strings.TrimPrefix(line, Package+"==")
+		v = strings.TrimSpace(strings.TrimSuffix(v, "\\"))
+		if i := strings.IndexAny(v, " ;"); i >= 0 {
+			v = v[:i]
+		}
+		return v
+	}
+	return ""
+}
@@ -0,0 +1,78 @@
+package hubbledemo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/HubbleNetwork/hubble-install/internal/runner"
+)
+
+func TestFlashArgs(t *testing.T) {
+	defer func() {
+		requestedVersion, overridden = DefaultVersion, false
+		Configure("", "")
+	}()
+
+	// Releases from before and after pyhubbledemo could read the token from
+	// the environment are all run the same way
+	for _, version := range []string{"0.9.0", "1.0.0", "2.3.0", "latest"} {
+		t.Run(version, func(t *testing.T) {
+			requestedVersion, overridden = DefaultVersion, false
+			Configure(version, "")
+
+			args := FlashArgs("nrf52dk", "org-1", "desk-1", "/tmp/out.hex")
+			launcher := slices.Index(args, "python")
+			if launcher < 0 || args[launcher+1] != "-c" || args[launcher+2] != Launcher {
+				t.Fatalf("args = %q, want the launcher run with python -c", args)
+			}
+			want := []string{"flash", "nrf52dk", "-o", "org-1", "-f", "/tmp/out.hex", "-n", "desk-1"}
+			if got := args[launcher+3:]; !slices.Equal(got, want) {
+				t.Errorf("hubbledemo args = %q, want %q", got, want)
+			}
+			if !slices.Contains(args, Spec()) {
+				t.Errorf("args = %q, want %s", args, Spec())
+			}
+			if slices.Contains(args, "-t") {
+				t.Errorf("args = %q, want no -t", args)
+			}
+		})
+	}
+}
+
+func TestRunPassesTokenOnStdin(t *testing.T) {
+	f := runner.NewFake()
+	var stdins []string
+	f.Handle("uv", func(f *runner.Fake, cmd *runner.Command) error {
+		data, err := io.ReadAll(cmd.Stdin)
+		if err != nil {
+			return err
+		}
+		stdins = append(stdins, string(data))
+		if len(stdins) == 1 {
+			fmt.Fprintln(cmd.Stderr, "Failed to establish a new connection: Name or service not known")
+			return &runner.ExitError{Code: 1}
+		}
+		return nil
+	})
+
+	cmd := &runner.Command{Name: "uv", Args: FlashArgs("nrf52dk", "org-1", "desk-1", "")}
+	if err := Run(context.Background(), f, cmd, "secret-token"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The retry must hand the token over again
+	if want := []string{"secret-token\n", "secret-token\n"}; !slices.Equal(stdins, want) {
+		t.Errorf("stdin = %q, want %q", stdins, want)
+	}
+	for _, call := range f.Calls() {
+		if strings.Contains(call.String(), "secret-token") || slices.ContainsFunc(call.Env, func(kv string) bool {
+			return strings.Contains(kv, "secret-token")
+		}) {
+			t.Errorf("call %s exposes the token", call)
+		}
+	}
+}
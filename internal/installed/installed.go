@@ -0,0 +1,152 @@
+package installed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/HubbleNetwork/hubble-install/internal/ui"
+)
+
+// formatVersion is bumped when the record layout changes incompatibly
+const formatVersion = 1
+
+// How an item was installed, which determines how it is removed
+const (
+	MethodBrew             = "brew"              // brew install <name>
+	MethodHomebrewScript   = "homebrew-script"   // Official Homebrew install script
+	MethodChoco            = "choco"             // choco install <name>
+	MethodChocolateyScript = "chocolatey-script" // Official Chocolatey install script
+	MethodUVTool           = "uv-tool"           // uv tool install <name>
+	MethodUVScript         = "uv-script"         // astral.sh install script; Path is the uv binary
+	MethodDownload         = "download"          // Downloaded into Path, a directory owned by the installer
+	MethodManual           = "manual"            // Removed by the user; Path is the installer that was run
+)
+
+// Item is one dependency the installer added to the machine
+type Item struct {
+	Name        string    `json:"name"`
+	Method      string    `json:"method"`
+	Path        string    `json:"path,omitempty"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// Record lists everything the installer added, in installation order.
+// Dependencies that were already present are never recorded.
+type Record struct {
+	FormatVersion int    `json:"format_version"`
+	Items         []Item `json:"items"`
+}
+
+// mu serializes updates from installers that work in parallel
+var mu sync.Mutex
+
+// Path returns the location of the record
+func Path() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "hubble-install", "installed.json"), nil
+}
+
+// Load reads the record. A missing file is an empty record.
+func Load() (*Record, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Record{FormatVersion: formatVersion}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var r Record
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("invalid install record %s: %w", path, err)
+	}
+	if r.FormatVersion != formatVersion {
+		return nil, fmt.Errorf("install record %s has unsupported format version %d", path, r.FormatVersion)
+	}
+	return &r, nil
+}
+
+// Add records that the installer installed name. Recording the same name
+// again replaces the earlier entry.
+func Add(name, method, path string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	r, err := Load()
+	if err != nil {
+		return err
+	}
+	r.remove(name)
+	r.Items = append(r.Items, Item{Name: name, Method: method, Path: path, InstalledAt: time.Now().UTC()})
+	return r.Save()
+}
+
+// Note records name like Add, warning instead of failing when the record
+// can't be written: the installation itself succeeded
+func Note(name, method, path string) {
+	if err := Add(name, method, path); err != nil {
+		ui.PrintWarning(fmt.Sprintf("Could not record %s for uninstall: %v", name, err))
+	}
+}
+
+// Remove drops name from the record once it has been uninstalled
+func Remove(name string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	r, err := Load()
+	if err != nil {
+		return err
+	}
+	r.remove(name)
+	return r.Save()
+}
+
+func (r *Record) remove(name string) {
+	items := r.Items[:0]
+	for _, item := range r.Items {
+		if item.Name != name {
+			items = append(items, item)
+		}
+	}
+	r.Items = items
+}
+
+// Save writes the record atomically, readable only by the current user
+func (r *Record) Save() error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to save %s: %w", path, err)
+	}
+	return nil
+}
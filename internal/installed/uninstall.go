@@ -0,0 +1,156 @@
+package installed
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/HubbleNetwork/hubble-install/internal/pinned"
+	"github.com/HubbleNetwork/hubble-install/internal/runner"
+)
+
+// Step is one action taken by "hubble-install uninstall"
+type Step struct {
+	Description string
+	Item        string          // Record entry dropped once the step succeeds; empty for cleanup
+	Command     *runner.Command // Command to run, if any
+	Paths       []string        // Files or directories to delete
+	Manual      string          // Instructions when the item can only be removed by hand
+	LastResort  bool            // Only taken when every earlier step succeeded
+	Script      string          // Pinned artifact downloaded, verified and appended to Command's arguments
+
+	summary string // Shown instead of long script commands
+}
+
+// chocolateyUninstall follows Chocolatey's documented removal: delete the
+// install directory and the environment variables pointing at it
+const chocolateyUninstall = `$dir = $env:ChocolateyInstall; if (-not $dir) { $dir = 'C:\ProgramData\chocolatey' }
+Remove-Item -Recurse -Force $dir -ErrorAction SilentlyContinue
+foreach ($scope in 'Machine', 'User') {
+	[Environment]::SetEnvironmentVariable('ChocolateyInstall', $null, $scope)
+	$path = [Environment]::GetEnvironmentVariable('PATH', $scope)
+	if ($path) {
+		$kept = ($path -split ';') | Where-Object { $_ -and $_ -notlike "$dir*" }
+		[Environment]::SetEnvironmentVariable('PATH', ($kept -join ';'), $scope)
+	}
+}`
+
+// Plan returns the steps that undo the record, newest item first so tools are
+// removed before the package managers that installed them. They are followed
+// by the installer's own state, logs and extracted bundles. uvPath, when
+// set, also clears the cached pyhubbledemo environments.
+func Plan(r *Record, uvPath string) ([]Step, error) {
+	var steps []Step
+
+	// Clear the cache while uv is still installed
+	if uvPath != "" {
+		steps = append(steps, Step{
+			Description: "Remove cached pyhubbledemo environments",
+			Command:     &runner.Command{Name: uvPath, Args: []string{"cache", "clean", "pyhubbledemo"}},
+		})
+	}
+
+	for i := len(r.Items) - 1; i >= 0; i-- {
+		steps = append(steps, removeStep(r.Items[i], uvPath))
+	}
+
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+	steps = append(steps, Step{
+		Description: "Remove installer logs and extracted bundles",
+		Paths:       []string{filepath.Join(cacheDir, "hubble-install")},
+	})
+
+	// The record lives here, so keep it if anything could not be removed
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	steps = append(steps, Step{
+		Description: "Remove saved progress and the install record",
+		Paths:       []string{filepath.Join(configDir, "hubble-install")},
+		LastResort:  true,
+	})
+
+	return steps, nil
+}
+
+// removeStep returns the step that undoes one recorded installation
+func removeStep(item Item, uvPath string) Step {
+	if uvPath == "" {
+		uvPath = "uv"
+	}
+
+	step := Step{Description: "Uninstall " + item.Name, Item: item.Name}
+
+	switch item.Method {
+	case MethodBrew:
+		step.Command = &runner.Command{Name: "brew", Args: []string{"uninstall", item.Name}}
+	case MethodHomebrewScript:
+		// The official Homebrew uninstall script
+		step.Script = pinned.HomebrewUninstall
+		step.Command = &runner.Command{
+			Name:   "/bin/bash",
+			Env:    []string{"NONINTERACTIVE=1"},
+			Stdin:  os.Stdin,
+			Stdout: os.Stdout,
+			Stderr: os.Stderr,
+		}
+		step.summary = "run the official Homebrew uninstall script"
+	case MethodChoco:
+		step.Command = &runner.Command{Name: "choco", Args: []string{"uninstall", item.Name, "-y"}}
+	case MethodChocolateyScript:
+		step.Command = &runner.Command{Name: "powershell", Args: []string{"-NoProfile", "-NonInteractive", "-Command", chocolateyUninstall}}
+		step.summary = "delete the Chocolatey directory and its environment variables"
+	case MethodUVTool:
+		step.Command = &runner.Command{Name: uvPath, Args: []string{"tool", "uninstall", item.Name}}
+	case MethodUVScript:
+		if !filepath.IsAbs(item.Path) {
+			return unknownPath(step, item)
+		}
+		uvx := "uvx"
+		if runtime.GOOS == "windows" {
+			uvx = "uvx.exe"
+		}
+		step.Paths = []string{item.Path, filepath.Join(filepath.Dir(item.Path), uvx)}
+	case MethodDownload:
+		if !filepath.IsAbs(item.Path) {
+			return unknownPath(step, item)
+		}
+		step.Paths = []string{item.Path}
+	default:
+		step.Manual = fmt.Sprintf("Remove %s with your system's uninstaller", item.Name)
+		if item.Path != "" {
+			step.Manual += fmt.Sprintf(" (it was installed from %s)", filepath.Base(item.Path))
+		}
+	}
+
+	return step
+}
+
+// unknownPath turns a step that would delete files into manual instructions
+// when the record has no absolute path, so nothing is deleted relative to the
+// working directory
+func unknownPath(step Step, item Item) Step {
+	step.Manual = fmt.Sprintf("Remove %s by hand: where it was installed was not recorded", item.Name)
+	return step
+}
+
+// String describes what the step does, for dry runs
+func (s Step) String() string {
+	switch {
+	case s.Manual != "":
+		return s.Manual
+	case s.summary != "":
+		return fmt.Sprintf("%s: %s", s.Description, s.summary)
+	case s.Command != nil:
+		return fmt.Sprintf("%s: %s", s.Description, strings.TrimSpace(s.Command.Name+" "+strings.Join(s.Command.Args, " ")))
+	case len(s.Paths) > 0:
+		return fmt.Sprintf("%s: delete %s", s.Description, strings.Join(s.Paths, ", "))
+	}
+	return s.Description
+}
@@ -0,0 +1,54 @@
+package installed
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestPlanPaths(t *testing.T) {
+	uv := filepath.Join(t.TempDir(), "bin", "uv")
+	nrfutil := filepath.Join(t.TempDir(), "nrfutil")
+	uvx := "uvx"
+	if runtime.GOOS == "windows" {
+		uvx = "uvx.exe"
+	}
+
+	tests := []struct {
+		name   string
+		item   Item
+		paths  []string // Paths the step deletes
+		manual bool
+	}{
+		{name: "uv script", item: Item{Name: "uv", Method: MethodUVScript, Path: uv}, paths: []string{uv, filepath.Join(filepath.Dir(uv), uvx)}},
+		{name: "uv script without a path", item: Item{Name: "uv", Method: MethodUVScript}, manual: true},
+		{name: "uv script with a relative path", item: Item{Name: "uv", Method: MethodUVScript, Path: "uv"}, manual: true},
+		{name: "download", item: Item{Name: "nrfutil", Method: MethodDownload, Path: nrfutil}, paths: []string{nrfutil}},
+		{name: "download without a path", item: Item{Name: "nrfutil", Method: MethodDownload}, manual: true},
+		{name: "download with a relative path", item: Item{Name: "nrfutil", Method: MethodDownload, Path: filepath.Join(".", "nrfutil")}, manual: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps, err := Plan(&Record{FormatVersion: formatVersion, Items: []Item{tt.item}}, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			step := steps[0]
+			if step.Item != tt.item.Name {
+				t.Fatalf("first step = %+v, want the step for %s", step, tt.item.Name)
+			}
+
+			if tt.manual {
+				if len(step.Paths) > 0 || step.Command != nil || !strings.Contains(step.Manual, "by hand") {
+					t.Errorf("step = %+v, want manual instructions only", step)
+				}
+				return
+			}
+			if strings.Join(step.Paths, "|") != strings.Join(tt.paths, "|") {
+				t.Errorf("paths = %q, want %q", step.Paths, tt.paths)
+			}
+		})
+	}
+}
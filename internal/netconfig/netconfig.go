@@ -0,0 +1,215 @@
+package netconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/HubbleNetwork/hubble-install/internal/logging"
+)
+
+// Settings holds the network configuration shared by the installer and its subprocesses
+type Settings struct {
+	Proxy    string // Proxy URL used for HTTP and HTTPS (empty: use the environment)
+	NoProxy  string // Comma-separated hosts that bypass the proxy
+	CABundle string // PEM file with extra trusted CA certificates
+	IndexURL string // Python package index mirror used by uv/pip
+
+	// DownloadMirror serves the pinned third-party downloads (installers and
+	// install scripts) under their manifest file names
+	DownloadMirror string
+}
+
+// DownloadMirrorEnv is the default for Settings.DownloadMirror
+const DownloadMirrorEnv = "HUBBLE_DOWNLOAD_MIRROR"
+
+// current is the active configuration, set once by Configure
+var current Settings
+
+// rootCAs is the system pool plus any certificates from Settings.CABundle
+var rootCAs *x509.CertPool
+
+// Configure applies the network settings to this process and exports them so
+// that every subprocess (uv, pip, brew, curl, choco) sees the same configuration.
+// It must be called before any HTTP request is made.
+func Configure(s Settings) error {
+	// Flags fall back to the conventional environment variables
+	if s.Proxy == "" {
+		s.Proxy = firstEnv("HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy")
+	}
+	if s.NoProxy == "" {
+		s.NoProxy = firstEnv("NO_PROXY", "no_proxy")
+	}
+	if s.CABundle == "" {
+		s.CABundle = os.Getenv("HUBBLE_CA_BUNDLE")
+	}
+	if s.DownloadMirror == "" {
+		s.DownloadMirror = os.Getenv(DownloadMirrorEnv)
+	}
+
+	env := map[string]string{}
+
+	if s.Proxy != "" {
+		for _, key := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy"} {
+			env[key] = s.Proxy
+		}
+
+		// Chocolatey's install script and package downloads take the
+		// credentials separately from the proxy URL
+		location := s.Proxy
+		if u, err := url.Parse(s.Proxy); err == nil && u.User != nil {
+			password, _ := u.User.Password()
+			logging.AddSecret(password, u.User.String())
+			env["chocolateyProxyUser"] = u.User.Username()
+			env["chocolateyProxyPassword"] = password
+			u.User = nil
+			location = u.String()
+		}
+		env["chocolateyProxyLocation"] = location
+	}
+	if s.NoProxy != "" {
+		env["NO_PROXY"] = s.NoProxy
+		env["no_proxy"] = s.NoProxy
+	}
+
+	if s.CABundle != "" {
+		pem, err := os.ReadFile(s.CABundle)
+		if err != nil {
+			return fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("CA bundle %s contains no PEM certificates", s.CABundle)
+		}
+		rootCAs = pool
+
+		// uv, pip/requests, curl (used by brew and the install scripts) and
+		// OpenSSL use only the file these name, so they get the system roots
+		// plus the extra certificates
+		merged, err := writeMergedBundle(pem)
+		if err != nil {
+			return fmt.Errorf("failed to combine CA bundle with the system certificates: %w", err)
+		}
+		env["SSL_CERT_FILE"] = merged
+		env["REQUESTS_CA_BUNDLE"] = merged
+		env["PIP_CERT"] = merged
+		env["CURL_CA_BUNDLE"] = merged
+		env["UV_NATIVE_TLS"] = "1"
+	}
+
+	if s.IndexURL != "" {
+		env["UV_DEFAULT_INDEX"] = s.IndexURL
+		env["UV_INDEX_URL"] = s.IndexURL
+		env["PIP_INDEX_URL"] = s.IndexURL
+	}
+
+	for key, value := range env {
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+	}
+
+	current = s
+	return nil
+}
+
+// Current returns the active network settings
+func Current() Settings {
+	return current
+}
+
+// DownloadMirror returns the configured download mirror. Commands that don't
+// call Configure still honor the environment variable.
+func DownloadMirror() string {
+	if current.DownloadMirror != "" {
+		return current.DownloadMirror
+	}
+	return os.Getenv(DownloadMirrorEnv)
+}
+
+// Client returns an HTTP client that honors the configured proxy and CA bundle
+func Client(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if rootCAs != nil {
+		transport.TLSClientConfig = &tls.Config{RootCAs: rootCAs}
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
+
+// PreflightResult is the outcome of a connectivity check against one endpoint
+type PreflightResult struct {
+	URL             string
+	Err             error
+	TLSInterception bool   // Certificate was issued by an authority we don't trust
+	Issuer          string // Issuer of the untrusted certificate, if known
+}
+
+// PreflightURLs are the endpoints the installer and its subprocesses download from
+var PreflightURLs = []string{
+	"https://pypi.org/simple/pyhubbledemo/",
+	"https://github.com/",
+}
+
+// Preflight checks that each endpoint is reachable over TLS with the current settings
+func Preflight(urls []string) []PreflightResult {
+	client := Client(15 * time.Second)
+
+	var results []PreflightResult
+	for _, url := range urls {
+		result := PreflightResult{URL: url}
+
+		resp, err := client.Head(url)
+		if err == nil {
+			resp.Body.Close()
+		} else {
+			result.Err = err
+
+			var unknownAuthority x509.UnknownAuthorityError
+			var invalidCert x509.CertificateInvalidError
+			var tlsVerify *tls.CertificateVerificationError
+			switch {
+			case errors.As(err, &unknownAuthority):
+				result.TLSInterception = true
+				if unknownAuthority.Cert != nil {
+					result.Issuer = unknownAuthority.Cert.Issuer.String()
+				}
+			case errors.As(err, &tlsVerify):
+				result.TLSInterception = true
+				if len(tlsVerify.UnverifiedCertificates) > 0 {
+					result.Issuer = tlsVerify.UnverifiedCertificates[0].Issuer.String()
+				}
+			case errors.As(err, &invalidCert):
+				result.TLSInterception = true
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// firstEnv returns the first non-empty environment variable among keys
+func firstEnv(keys ...string) string {
+	for _, key := range keys {
+		if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+			return value
+		}
+	}
+	return ""
+}
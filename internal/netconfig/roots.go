@@ -0,0 +1,117 @@
+package netconfig
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// certFiles are the system CA bundles of common Linux and BSD distributions,
+// in the order Go's crypto/x509 looks for them
+var certFiles = []string{
+	"/etc/ssl/certs/ca-certificates.crt",                // Debian/Ubuntu/Gentoo etc.
+	"/etc/pki/tls/certs/ca-bundle.crt",                  // Fedora/RHEL 6
+	"/etc/ssl/ca-bundle.pem",                            // OpenSUSE
+	"/etc/pki/tls/cacert.pem",                           // OpenELEC
+	"/etc/pki/ca-trust/extracted/pem/tls-ca-bundle.pem", // CentOS/RHEL 7
+	"/etc/ssl/cert.pem",                                 // Alpine, macOS, FreeBSD
+	"/usr/local/etc/ssl/cert.pem",                       // FreeBSD
+}
+
+// exportTimeout bounds exporting the roots from the macOS keychain or the
+// Windows certificate store
+const exportTimeout = 30 * time.Second
+
+// windowsExportScript prints the machine and user root certificates as PEM
+const windowsExportScript = `Get-ChildItem Cert:\LocalMachine\Root, Cert:\CurrentUser\Root | ForEach-Object {
+	"-----BEGIN CERTIFICATE-----"
+	[Convert]::ToBase64String($_.RawData, 'InsertLineBreaks')
+	"-----END CERTIFICATE-----"
+}`
+
+// systemRootsPEM returns the certificates the system trusts by default. A
+// bundle already named by SSL_CERT_FILE is what tools use now, so it wins,
+// unless it is the merged bundle itself (inherited from a parent run).
+func systemRootsPEM(merged string) ([]byte, error) {
+	if file := os.Getenv("SSL_CERT_FILE"); file != "" && file != merged {
+		return os.ReadFile(file)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+	switch runtime.GOOS {
+	case "darwin":
+		out, err := exec.CommandContext(ctx, "/usr/bin/security", "find-certificate", "-a", "-p",
+			"/System/Library/Keychains/SystemRootCertificates.keychain",
+			"/Library/Keychains/System.keychain").Output()
+		if err == nil && len(out) > 0 {
+			return out, nil
+		}
+	case "windows":
+		out, err := exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", windowsExportScript).Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to export the Windows root certificates: %w", err)
+		}
+		return out, nil
+	}
+
+	for _, file := range certFiles {
+		if data, err := os.ReadFile(file); err == nil {
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("no system CA bundle found")
+}
+
+// writeMergedBundle writes the system roots followed by the extra
+// certificates to a bundle in the cache directory and returns its path.
+// Subprocesses read it through SSL_CERT_FILE and friends, which replace the
+// default trust store rather than adding to it. The file is rewritten on
+// every run and removed by "hubble-install uninstall".
+func writeMergedBundle(extra []byte) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+	dir := filepath.Join(cacheDir, "hubble-install")
+	path := filepath.Join(dir, "ca-bundle.pem")
+
+	roots, err := systemRootsPEM(path)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	var merged bytes.Buffer
+	merged.Write(bytes.TrimSpace(roots))
+	merged.WriteString("\n")
+	merged.Write(bytes.TrimSpace(extra))
+	merged.WriteString("\n")
+
+	// Replace the file atomically so a concurrent run never reads half of it
+	tmp, err := os.CreateTemp(dir, ".ca-bundle-*.pem")
+	if err != nil {
+		return "", err
+	}
+	if _, err := tmp.Write(merged.Bytes()); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,140 @@
+// Package output chooses safe locations for the files the installer
+// generates and records how they were made
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Policy says what to do when a generated file already exists
+type Policy string
+
+const (
+	Ask    Policy = "ask"    // Ask before overwriting; otherwise add a suffix
+	Suffix Policy = "suffix" // Add "-2", "-3", ... to the name
+	Fail   Policy = "fail"   // Stop with an error
+)
+
+// ParsePolicy checks an --overwrite value
+func ParsePolicy(value string) (Policy, error) {
+	switch p := Policy(strings.ToLower(strings.TrimSpace(value))); p {
+	case Ask, Suffix, Fail:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid overwrite policy %q (use ask, suffix or fail)", value)
+}
+
+// maxBaseLength keeps generated names well within filesystem limits
+const maxBaseLength = 100
+
+// windowsReserved are names Windows won't create, with any extension
+var windowsReserved = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// SafeFilename turns a device name into a file name without directories:
+// separators, characters Windows forbids and control characters become
+// '-', and leading dots are dropped so "../x" can't escape or hide the file
+func SafeFilename(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r < 0x20 || r == 0x7F || strings.ContainsRune(`/\:*?"<>|`, r):
+			b.WriteRune('-')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	safe := strings.Trim(b.String(), ". -")
+	if len(safe) > maxBaseLength {
+		// Cut on a rune boundary so multi-byte names stay valid UTF-8
+		n := maxBaseLength
+		for n > 0 && !utf8.RuneStart(safe[n]) {
+			n--
+		}
+		safe = strings.TrimRight(safe[:n], ". -")
+	}
+	if safe == "" {
+		return "device"
+	}
+	if stem, _, _ := strings.Cut(safe, "."); windowsReserved[strings.ToUpper(stem)] {
+		safe = "_" + safe
+	}
+	return safe
+}
+
+// Choose returns the path for base+ext in dir under the policy. confirm
+// asks whether an existing file may be overwritten (Ask only). A name is
+// taken if the file or its sidecar exists.
+func Choose(dir, base, ext string, policy Policy, confirm func(path string) bool) (string, error) {
+	path := filepath.Join(dir, base+ext)
+	if !taken(path) {
+		return path, nil
+	}
+
+	switch policy {
+	case Fail:
+		return "", fmt.Errorf("%s already exists; choose another --output-dir or device name, or pass --overwrite suffix", path)
+	case Ask:
+		if confirm(path) {
+			return path, nil
+		}
+	}
+
+	for n := 2; ; n++ {
+		path = filepath.Join(dir, base+"-"+strconv.Itoa(n)+ext)
+		if !taken(path) {
+			return path, nil
+		}
+	}
+}
+
+// taken reports whether path or its sidecar exists
+func taken(path string) bool {
+	for _, p := range []string{path, SidecarPath(path)} {
+		if _, err := os.Lstat(p); !errors.Is(err, os.ErrNotExist) {
+			return true
+		}
+	}
+	return false
+}
+
+// Metadata describes a generated file; it is written next to it as JSON
+type Metadata struct {
+	File             string    `json:"file"`
+	Board            string    `json:"board"`
+	DeviceName       string    `json:"device_name,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	ToolVersion      string    `json:"tool_version"`
+	InstallerVersion string    `json:"installer_version"`
+	SHA256           string    `json:"sha256"`
+}
+
+// SidecarPath returns where the metadata for path is written: the same name with .json
+func SidecarPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".json"
+}
+
+// WriteSidecar writes the metadata next to the file it describes
+func WriteSidecar(path string, m Metadata) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	sidecar := SidecarPath(path)
+	if err := os.WriteFile(sidecar, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", sidecar, err)
+	}
+	return nil
+}
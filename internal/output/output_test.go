@@ -0,0 +1,145 @@
+package output
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestSafeFilename(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "bench-01", want: "bench-01"},
+		{name: "Lab bench 2", want: "Lab bench 2"},
+		{name: "../../etc/passwd", want: "etc-passwd"},
+		{name: `..\..\Windows\evil`, want: "Windows-evil"},
+		{name: ".hidden", want: "hidden"},
+		{name: "a:b*c?d\"e<f>g|h", want: "a-b-c-d-e-f-g-h"},
+		{name: "tab\there\nnewline\x7f", want: "tab-here-newline"},
+		{name: "capteur-été", want: "capteur-été"},
+		{name: "", want: "device"},
+		{name: "../..", want: "device"},
+		{name: "CON", want: "_CON"},
+		{name: "nul.txt", want: "_nul.txt"},
+		{name: "com1", want: "_com1"},
+		{name: "CONSOLE", want: "CONSOLE"},
+		{name: strings.Repeat("a", 150), want: strings.Repeat("a", maxBaseLength)},
+		{name: strings.Repeat("a", 97) + "---" + "b", want: strings.Repeat("a", 97)},
+		{name: strings.Repeat("a", 99) + "é", want: strings.Repeat("a", 99)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SafeFilename(tt.name)
+			if got != tt.want {
+				t.Errorf("SafeFilename(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+			if !utf8.ValidString(got) || strings.ContainsAny(got, `/\`) || len(got) > maxBaseLength {
+				t.Errorf("SafeFilename(%q) = %q is not a safe file name", tt.name, got)
+			}
+		})
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	for value, want := range map[string]Policy{"ask": Ask, " Suffix ": Suffix, "FAIL": Fail} {
+		if got, err := ParsePolicy(value); err != nil || got != want {
+			t.Errorf("ParsePolicy(%q) = %q, %v, want %q", value, got, err, want)
+		}
+	}
+	if _, err := ParsePolicy("overwrite"); err == nil {
+		t.Error(`ParsePolicy("overwrite") succeeded`)
+	}
+}
+
+func TestChoose(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string // Existing files in the directory
+		policy  Policy
+		confirm bool // Answer to the overwrite question
+		want    string
+		asked   bool
+		wantErr string
+	}{
+		{name: "free name", policy: Fail, want: "bench.hex"},
+		{name: "suffix", files: []string{"bench.hex"}, policy: Suffix, want: "bench-2.hex"},
+		{name: "suffix skips taken names", files: []string{"bench.hex", "bench-2.hex", "bench-3.hex"}, policy: Suffix, want: "bench-4.hex"},
+		{name: "sidecar alone takes the name", files: []string{"bench.json"}, policy: Suffix, want: "bench-2.hex"},
+		{name: "suffix sidecar alone takes the name", files: []string{"bench.hex", "bench-2.json"}, policy: Suffix, want: "bench-3.hex"},
+		{name: "fail", files: []string{"bench.hex"}, policy: Fail, wantErr: "already exists"},
+		{name: "ask and overwrite", files: []string{"bench.hex"}, policy: Ask, confirm: true, want: "bench.hex", asked: true},
+		{name: "ask and keep", files: []string{"bench.hex"}, policy: Ask, want: "bench-2.hex", asked: true},
+		{name: "ask without a conflict", policy: Ask, want: "bench.hex"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			asked := false
+			confirm := func(path string) bool {
+				asked = true
+				if path != filepath.Join(dir, "bench.hex") {
+					t.Errorf("asked about %s", path)
+				}
+				return tt.confirm
+			}
+			path, err := Choose(dir, "bench", ".hex", tt.policy, confirm)
+
+			if asked != tt.asked {
+				t.Errorf("asked = %v, want %v", asked, tt.asked)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Choose() = %q, %v, want an error containing %q", path, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Choose() = %v", err)
+			}
+			if want := filepath.Join(dir, tt.want); path != want {
+				t.Errorf("Choose() = %q, want %q", path, want)
+			}
+		})
+	}
+}
+
+func TestWriteSidecar(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bench.hex")
+	m := Metadata{
+		File:        "bench.hex",
+		Board:       "lp_em_cc2340r5",
+		DeviceName:  "bench",
+		CreatedAt:   time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		ToolVersion: "1.2.3",
+		SHA256:      strings.Repeat("ab", 32),
+	}
+	if err := WriteSidecar(path, m); err != nil {
+		t.Fatalf("WriteSidecar() = %v", err)
+	}
+
+	data, err := os.ReadFile(SidecarPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Metadata
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != m {
+		t.Errorf("sidecar = %+v, want %+v", got, m)
+	}
+}
@@ -0,0 +1,125 @@
+// Command pin downloads every artifact in the download manifest and records
+// its SHA-256. Scripts served from a GitHub branch such as HEAD are first
+// re-pointed at the commit that branch is on, so the digest stays valid. Run
+// it when bumping a pinned version or to move a script to its latest commit,
+// and review what changed before committing:
+//
+//	go run ./internal/pinned/pin
+//	git diff internal/pinned/manifest.json
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/HubbleNetwork/hubble-install/internal/pinned"
+)
+
+func main() {
+	path := flag.String("manifest", "internal/pinned/manifest.json", "Download manifest to update")
+	flag.Parse()
+
+	data, err := os.ReadFile(*path)
+	if err != nil {
+		fail(err)
+	}
+	m, err := pinned.Parse(data)
+	if err != nil {
+		fail(err)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Minute}
+	commits := map[string]string{}
+	for i, a := range m.Artifacts {
+		if repo, ref, ok := pinned.GitHubRef(a.URL); ok && len(ref) != 40 {
+			key := repo + "@" + ref
+			if commits[key] == "" {
+				if commits[key], err = resolve(client, repo, ref); err != nil {
+					fail(fmt.Errorf("%s: %w", a.Name, err))
+				}
+			}
+			a.URL = strings.Replace(a.URL, "/"+repo+"/"+ref+"/", "/"+repo+"/"+commits[key]+"/", 1)
+			m.Artifacts[i].URL = a.URL
+		}
+
+		digest, err := sum(client, a.URL)
+		if err != nil {
+			fail(fmt.Errorf("%s: %w", a.Name, err))
+		}
+		status := "unchanged"
+		if a.SHA256 != digest {
+			status = "updated"
+			if a.SHA256 == "" {
+				status = "pinned"
+			}
+		}
+		m.Artifacts[i].SHA256 = digest
+		fmt.Printf("%-20s %s  %s\n", a.Name, digest, status)
+	}
+
+	out, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		fail(err)
+	}
+	if err := os.WriteFile(*path, append(out, '\n'), 0644); err != nil {
+		fail(err)
+	}
+}
+
+// resolve returns the commit a GitHub branch or tag currently points at
+func resolve(client *http.Client, repo, ref string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, "https://api.github.com/repos/"+repo+"/commits/"+ref, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github.sha")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("resolving %s@%s: %s", repo, ref, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 100))
+	if err != nil {
+		return "", err
+	}
+	commit := strings.TrimSpace(string(body))
+	if _, err := hex.DecodeString(commit); err != nil || len(commit) != 40 {
+		return "", fmt.Errorf("resolving %s@%s: unexpected response %q", repo, ref, commit)
+	}
+	return commit, nil
+}
+
+// sum downloads url and returns its SHA-256
+func sum(client *http.Client, url string) (string, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, resp.Body); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
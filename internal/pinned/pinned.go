@@ -0,0 +1,319 @@
+// Package pinned verifies third-party downloads (installers, install scripts,
+// standalone tools) against SHA-256 digests embedded in the installer, before
+// anything downloaded is executed.
+package pinned
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"embed"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HubbleNetwork/hubble-install/internal/netconfig"
+	"github.com/HubbleNetwork/hubble-install/internal/retry"
+	"github.com/HubbleNetwork/hubble-install/internal/runner"
+	"github.com/HubbleNetwork/hubble-install/internal/selfupdate"
+	"github.com/HubbleNetwork/hubble-install/internal/ui"
+)
+
+// Artifacts listed in the manifest
+const (
+	JLinkWindows      = "jlink-windows"
+	NRFUtilWindows    = "nrfutil-windows"
+	HomebrewInstall   = "homebrew-install"
+	HomebrewUninstall = "homebrew-uninstall"
+	UVInstall         = "uv-install"
+	ChocolateyInstall = "chocolatey-install"
+)
+
+// ManifestFile and SignatureFile are embedded from this directory. The
+// signature is written by the release workflow and absent in source builds.
+const (
+	ManifestFile  = "manifest.json"
+	SignatureFile = "manifest.json.sig"
+)
+
+//go:embed manifest.json*
+var files embed.FS
+
+// Artifact is one pinned third-party download
+type Artifact struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	File   string `json:"file"`   // Name of the file on a mirror
+	SHA256 string `json:"sha256"` // Expected digest (hex)
+}
+
+// Manifest lists every third-party file the installer downloads
+type Manifest struct {
+	FormatVersion int        `json:"format_version"`
+	Artifacts     []Artifact `json:"artifacts"`
+}
+
+// MismatchError reports a download whose digest differs from the pinned one
+type MismatchError struct {
+	Artifact Artifact
+	URL      string
+	Got      string
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("checksum mismatch for %s downloaded from %s: got %s, expected %s. "+
+		"The file was deleted without being run; it was either tampered with or changed upstream since this installer was released",
+		e.Artifact.File, e.URL, e.Got, e.Artifact.SHA256)
+}
+
+var (
+	loadOnce sync.Once
+	loaded   *Manifest
+	loadErr  error
+)
+
+// Load returns the embedded manifest. Release builds carry a signing key and
+// refuse a manifest whose signature is missing or does not match.
+func Load() (*Manifest, error) {
+	loadOnce.Do(func() {
+		data, err := files.ReadFile(ManifestFile)
+		if err != nil {
+			loadErr = err
+			return
+		}
+		if selfupdate.PublicKey != "" {
+			signature, err := files.ReadFile(SignatureFile)
+			if errors.Is(err, fs.ErrNotExist) {
+				loadErr = errors.New("download manifest is not signed")
+				return
+			}
+			if err := Verify(data, signature, selfupdate.PublicKey); err != nil {
+				loadErr = err
+				return
+			}
+		}
+		loaded, loadErr = Parse(data)
+	})
+	return loaded, loadErr
+}
+
+// Parse decodes a manifest
+func Parse(data []byte) (*Manifest, error) {
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid download manifest: %w", err)
+	}
+	if m.FormatVersion != 1 {
+		return nil, fmt.Errorf("download manifest has unsupported format version %d", m.FormatVersion)
+	}
+	return &m, nil
+}
+
+// Check reports artifacts that have no valid digest or that are served from
+// a moving GitHub ref, so a manifest can't be signed before every entry is
+// pinned to content that won't change under it
+func (m *Manifest) Check() error {
+	var unpinned, moving []string
+	for _, a := range m.Artifacts {
+		if !a.Pinned() {
+			unpinned = append(unpinned, a.Name)
+		}
+		if _, ref, ok := GitHubRef(a.URL); ok && !isCommit(ref) {
+			moving = append(moving, a.Name)
+		}
+	}
+	var problems []string
+	if len(unpinned) > 0 {
+		problems = append(problems, "no valid SHA-256 for "+strings.Join(unpinned, ", "))
+	}
+	if len(moving) > 0 {
+		problems = append(problems, "not pinned to a commit: "+strings.Join(moving, ", "))
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Pinned reports whether the artifact has a usable digest: 64 hex digits and
+// not the all-zero placeholder
+func (a Artifact) Pinned() bool {
+	digest, err := hex.DecodeString(a.SHA256)
+	if err != nil || len(digest) != sha256.Size {
+		return false
+	}
+	for _, b := range digest {
+		if b != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// GitHubRef splits a raw.githubusercontent.com URL into "owner/repo" and the
+// ref the file is served from
+func GitHubRef(url string) (repo, ref string, ok bool) {
+	path, found := strings.CutPrefix(url, "https://raw.githubusercontent.com/")
+	if !found {
+		return "", "", false
+	}
+	parts := strings.SplitN(path, "/", 4)
+	if len(parts) < 4 {
+		return "", "", false
+	}
+	return parts[0] + "/" + parts[1], parts[2], true
+}
+
+// isCommit reports whether ref is a full commit SHA rather than a branch or tag
+func isCommit(ref string) bool {
+	_, err := hex.DecodeString(ref)
+	return err == nil && len(ref) == 40
+}
+
+// Sign returns the base64 ed25519 signature of a manifest file
+func Sign(data []byte, key ed25519.PrivateKey) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, data)) + "\n")
+}
+
+// Verify checks a manifest signature against a base64 ed25519 public key
+func Verify(data, signature []byte, publicKey string) error {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.New("invalid release signing key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil || !ed25519.Verify(ed25519.PublicKey(key), data, sig) {
+		return errors.New("download manifest signature does not match")
+	}
+	return nil
+}
+
+// Lookup returns the pinned artifact with the given name
+func Lookup(name string) (Artifact, error) {
+	m, err := Load()
+	if err != nil {
+		return Artifact{}, err
+	}
+	for _, a := range m.Artifacts {
+		if a.Name == name {
+			return a, nil
+		}
+	}
+	return Artifact{}, fmt.Errorf("%s is not in the download manifest", name)
+}
+
+// Source returns where the artifact is downloaded from: the configured mirror
+// if there is one, otherwise the upstream URL
+func (a Artifact) Source() string {
+	if mirror := netconfig.DownloadMirror(); mirror != "" {
+		return strings.TrimSuffix(mirror, "/") + "/" + a.File
+	}
+	return a.URL
+}
+
+// Download fetches the named artifact to destPath and verifies it, retrying
+// transient network failures. destPath is removed unless the digest matches.
+func Download(ctx context.Context, r runner.Runner, name, destPath string) error {
+	a, err := Lookup(name)
+	if err != nil {
+		return err
+	}
+	// Release builds can't be signed with an unpinned artifact (see Check),
+	// so this only lets source builds download before the manifest is pinned
+	verify := a.Pinned()
+	if !verify {
+		if selfupdate.PublicKey != "" {
+			return fmt.Errorf("no pinned checksum for %s in this build, so it can't be verified and was not downloaded", a.File)
+		}
+		ui.PrintWarning(fmt.Sprintf("No pinned checksum for %s in this development build; it will not be verified", a.File))
+	}
+
+	url := a.Source()
+	ui.PrintInfo(fmt.Sprintf("Downloading from %s...", url))
+
+	var got string
+	err = retry.Download.Do(ctx, r, "Download of "+a.File, func(ctx context.Context) error {
+		var fetchErr error
+		got, fetchErr = fetch(ctx, r, url, destPath)
+		return fetchErr
+	})
+	if err != nil {
+		return err
+	}
+
+	if !verify {
+		ui.PrintSuccess(fmt.Sprintf("Download complete (not verified, SHA-256: %s)", got))
+		return nil
+	}
+	if got != strings.ToLower(a.SHA256) {
+		r.RemoveAll(destPath)
+		return &MismatchError{Artifact: a, URL: url, Got: got}
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Download complete (SHA-256 verified: %s)", a.File))
+	return nil
+}
+
+// DownloadTemp downloads and verifies the named artifact into a new temporary
+// directory. cleanup removes the directory once the file has been used.
+func DownloadTemp(ctx context.Context, r runner.Runner, name string) (path string, cleanup func(), err error) {
+	a, err := Lookup(name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	dir := filepath.Join(r.TempDir(), "hubble-"+a.Name)
+	if err := r.MkdirAll(dir, 0700); err != nil {
+		return "", nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	cleanup = func() { r.RemoveAll(dir) }
+
+	path = filepath.Join(dir, a.File)
+	if err := Download(ctx, r, name, path); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return path, cleanup, nil
+}
+
+// fetch makes a single attempt at downloading url to destPath and returns the
+// digest of what was written. A partial file is removed on failure.
+func fetch(ctx context.Context, r runner.Runner, url, destPath string) (digest string, err error) {
+	out, err := r.Create(destPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %w", err)
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to save file: %w", closeErr)
+		}
+		if err != nil {
+			r.RemoveAll(destPath)
+		}
+	}()
+
+	resp, err := r.Get(ctx, url, 10*time.Minute)
+	if err != nil {
+		return "", fmt.Errorf("failed to download: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &retry.StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, h), resp.Body); err != nil {
+		return "", fmt.Errorf("failed to save file: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
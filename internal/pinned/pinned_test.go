@@ -0,0 +1,93 @@
+package pinned
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/HubbleNetwork/hubble-install/internal/runner"
+	"github.com/HubbleNetwork/hubble-install/internal/selfupdate"
+)
+
+const digest = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" // SHA-256 of "hello"
+
+func TestCheck(t *testing.T) {
+	commit := strings.Repeat("a", 40)
+	tests := []struct {
+		name     string
+		artifact Artifact
+		want     string // Substring of the error; empty for no error
+	}{
+		{name: "pinned", artifact: Artifact{Name: "a", URL: "https://example.com/a.sh", SHA256: digest}},
+		{name: "commit URL", artifact: Artifact{Name: "a", URL: "https://raw.githubusercontent.com/o/r/" + commit + "/a.sh", SHA256: digest}},
+		{name: "empty digest", artifact: Artifact{Name: "a", URL: "https://example.com/a.sh"}, want: "no valid SHA-256 for a"},
+		{name: "zero digest", artifact: Artifact{Name: "a", URL: "https://example.com/a.sh", SHA256: strings.Repeat("0", 64)}, want: "no valid SHA-256 for a"},
+		{name: "short digest", artifact: Artifact{Name: "a", URL: "https://example.com/a.sh", SHA256: digest[:60]}, want: "no valid SHA-256 for a"},
+		{name: "branch URL", artifact: Artifact{Name: "a", URL: "https://raw.githubusercontent.com/o/r/HEAD/a.sh", SHA256: digest}, want: "not pinned to a commit: a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Manifest{FormatVersion: 1, Artifacts: []Artifact{tt.artifact}}).Check()
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Check() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Check() = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownloadUnpinned(t *testing.T) {
+	a := unpinnedArtifact(t)
+
+	t.Run("development build downloads without verifying", func(t *testing.T) {
+		f := runner.NewFake()
+		f.AddURL(a.URL, runner.Response{Body: []byte("hello")})
+		if err := Download(context.Background(), f, a.Name, "/tmp/file"); err != nil {
+			t.Fatal(err)
+		}
+		if data, ok := f.File("/tmp/file"); !ok || string(data) != "hello" {
+			t.Errorf("downloaded file = %q, %v", data, ok)
+		}
+	})
+
+	t.Run("release build refuses", func(t *testing.T) {
+		defer func(key string) { selfupdate.PublicKey = key }(selfupdate.PublicKey)
+		selfupdate.PublicKey = "release-key"
+
+		f := runner.NewFake()
+		f.AddURL(a.URL, runner.Response{Body: []byte("hello")})
+		err := Download(context.Background(), f, a.Name, "/tmp/file")
+		if err == nil || !strings.Contains(err.Error(), "no pinned checksum") {
+			t.Fatalf("Download() = %v, want a missing checksum error", err)
+		}
+		if calls := f.Calls(); len(calls) != 0 {
+			t.Errorf("calls = %v, want none", calls)
+		}
+		if _, ok := f.File("/tmp/file"); ok {
+			t.Error("file was downloaded")
+		}
+	})
+}
+
+// unpinnedArtifact returns an artifact of the embedded manifest without a
+// digest, skipping the test once every artifact is pinned
+func unpinnedArtifact(t *testing.T) Artifact {
+	t.Helper()
+	m, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range m.Artifacts {
+		if !a.Pinned() {
+			return a
+		}
+	}
+	t.Skip("every artifact is pinned")
+	return Artifact{}
+}
@@ -0,0 +1,18 @@
+//go:build release
+
+package pinned
+
+import "testing"
+
+// TestEmbeddedManifestPinned fails until every artifact has a digest and no
+// script is served from a branch, as signing requires. CI runs it with
+// "go test -tags release ./internal/pinned".
+func TestEmbeddedManifestPinned(t *testing.T) {
+	m, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Check(); err != nil {
+		t.Fatalf("download manifest is not fully pinned (run go run ./internal/pinned/pin): %v", err)
+	}
+}
@@ -1,22 +1,30 @@
 package platform
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
-	"path/filepath"
 	"strings"
 	"sync"
 
+	"github.com/HubbleNetwork/hubble-install/internal/installed"
+	"github.com/HubbleNetwork/hubble-install/internal/pinned"
+	"github.com/HubbleNetwork/hubble-install/internal/runner"
 	"github.com/HubbleNetwork/hubble-install/internal/ui"
 )
 
-// DarwinInstaller implements the Installer interface for macOS
-type DarwinInstaller struct{}
+// DarwinInstaller implements the Installer interface for macOS. Flashing and
+// hex generation use the shared implementations.
+type DarwinInstaller struct {
+	darwinRebootChecker
+	hubbledemoTool
+	jlinkProbes
+	run runner.Runner
+}
 
 // NewDarwinInstaller creates a new macOS installer
-func NewDarwinInstaller() *DarwinInstaller {
-	return &DarwinInstaller{}
+func NewDarwinInstaller(r runner.Runner) *DarwinInstaller {
+	return &DarwinInstaller{darwinRebootChecker: darwinRebootChecker{r}, hubbledemoTool: newHubbledemoTool(r), jlinkProbes: jlinkProbes{r, "JLinkExe"}, run: r}
 }
 
 // Name returns the platform name
@@ -24,29 +32,20 @@ func (d *DarwinInstaller) Name() string {
 	return "macOS"
 }
 
-// CheckPendingReboot checks if a system reboot is pending (not typically needed on macOS)
-func (d *DarwinInstaller) CheckPendingReboot() error {
-	// macOS doesn't typically require reboot checks for package installations
-	return nil
-}
-
 // ensureSudoAccess validates sudo access upfront to avoid multiple password prompts
-func (d *DarwinInstaller) ensureSudoAccess() error {
+func (d *DarwinInstaller) ensureSudoAccess(ctx context.Context) error {
 	// Check if we already have valid sudo credentials
-	checkCmd := exec.Command("sudo", "-n", "true")
-	if err := checkCmd.Run(); err == nil {
+	checkCmd := &runner.Command{Name: "sudo", Args: []string{"-n", "true"}}
+	if err := d.run.Run(ctx, checkCmd); err == nil {
 		// Already have valid sudo, no need to prompt
 		return nil
 	}
 
 	// Need to prompt for password
 	ui.PrintWarning("Administrator access required for installation")
-	cmd := exec.Command("sudo", "-v")
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd := &runner.Command{Name: "sudo", Args: []string{"-v"}, Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr}
 
-	if err := cmd.Run(); err != nil {
+	if err := d.run.Run(ctx, cmd); err != nil {
 		return fmt.Errorf("failed to obtain sudo access: %w", err)
 	}
 
@@ -54,7 +53,7 @@ func (d *DarwinInstaller) ensureSudoAccess() error {
 }
 
 // CheckPrerequisites checks for missing dependencies based on required deps
-func (d *DarwinInstaller) CheckPrerequisites(requiredDeps []string) ([]MissingDependency, error) {
+func (d *DarwinInstaller) CheckPrerequisites(ctx context.Context, requiredDeps []string) ([]MissingDependency, error) {
 	var missing []MissingDependency
 
 	// Check for Homebrew (always required for installing other deps)
@@ -96,7 +95,7 @@ func (d *DarwinInstaller) CheckPrerequisites(requiredDeps []string) ([]MissingDe
 }
 
 // InstallPackageManager installs Homebrew if not present
-func (d *DarwinInstaller) InstallPackageManager() error {
+func (d *DarwinInstaller) InstallPackageManager(ctx context.Context) error {
 	if d.commandExists("brew") {
 		ui.PrintSuccess("Homebrew already installed")
 		return nil
@@ -104,24 +103,36 @@ func (d *DarwinInstaller) InstallPackageManager() error {
 
 	// Ensure we have sudo access upfront (single password prompt)
 	// The Homebrew script will use sudo internally when needed (e.g., for Xcode Command Line Tools)
-	if err := d.ensureSudoAccess(); err != nil {
+	if err := d.ensureSudoAccess(ctx); err != nil {
 		return err
 	}
 
 	ui.PrintInfo("Installing Homebrew...")
 	ui.PrintInfo("This may take a few minutes...")
 
-	// Run the official Homebrew installation script as regular user (not sudo)
+	// Run the official Homebrew installation script as regular user (not sudo),
+	// once its checksum is verified
 	// The script will internally use sudo when needed, using our cached credentials
 	// NONINTERACTIVE=1 suppresses the "running in noninteractive mode" warning
-	cmd := exec.Command("/bin/bash", "-c", `NONINTERACTIVE=1 /bin/bash -c "$(curl -fsSL https://raw.githubusercontent.com/Homebrew/install/HEAD/install.sh)"`)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	script, cleanup, err := pinned.DownloadTemp(ctx, d.run, pinned.HomebrewInstall)
+	if err != nil {
+		return fmt.Errorf("failed to download the Homebrew install script: %w", err)
+	}
+	defer cleanup()
+
+	cmd := &runner.Command{
+		Name:   "/bin/bash",
+		Args:   []string{script},
+		Env:    []string{"NONINTERACTIVE=1"},
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
 
-	if err := cmd.Run(); err != nil {
+	if err := d.run.Run(ctx, cmd); err != nil {
 		return fmt.Errorf("failed to install Homebrew: %w", err)
 	}
+	installed.Note("Homebrew", installed.MethodHomebrewScript, "")
 
 	// Add Homebrew to PATH for this process
 	if err := d.setupBrewPath(); err != nil {
@@ -134,8 +145,8 @@ func (d *DarwinInstaller) InstallPackageManager() error {
 	}
 
 	// Test brew with a simple command to ensure it's functional
-	testCmd := exec.Command("brew", "--version")
-	if err := testCmd.Run(); err != nil {
+	testCmd := &runner.Command{Name: "brew", Args: []string{"--version"}}
+	if err := d.run.Run(ctx, testCmd); err != nil {
 		return fmt.Errorf("homebrew installed but not functioning correctly: %w", err)
 	}
 
@@ -144,10 +155,10 @@ func (d *DarwinInstaller) InstallPackageManager() error {
 }
 
 // InstallDependencies installs the specified dependencies
-func (d *DarwinInstaller) InstallDependencies(deps []string) error {
+func (d *DarwinInstaller) InstallDependencies(ctx context.Context, deps []string) error {
 	// First ensure Homebrew is installed
 	if !d.commandExists("brew") {
-		if err := d.InstallPackageManager(); err != nil {
+		if err := d.InstallPackageManager(ctx); err != nil {
 			return err
 		}
 	}
@@ -168,10 +179,11 @@ func (d *DarwinInstaller) InstallDependencies(deps []string) error {
 					return
 				}
 				ui.PrintInfo("Installing uv...")
-				if err := d.runBrewInstall("uv", false); err != nil {
+				if err := d.runBrewInstall(ctx, "uv", false); err != nil {
 					errChan <- fmt.Errorf("failed to install uv: %w", err)
 					return
 				}
+				installed.Note("uv", installed.MethodBrew, "")
 				ui.PrintSuccess("uv installed successfully")
 
 			case "nrfutil":
@@ -179,19 +191,18 @@ func (d *DarwinInstaller) InstallDependencies(deps []string) error {
 					ui.PrintSuccess("nrfutil already installed")
 					return
 				}
-				uvPath, err := exec.LookPath("uv")
+				uvPath, err := d.run.LookPath("uv")
 				if err != nil {
 					errChan <- fmt.Errorf("uv not found in PATH (required to install nrfutil): %w", err)
 					return
 				}
 				ui.PrintInfo("Installing nrfutil (via uv tool install)...")
-				cmd := exec.Command(uvPath, "tool", "install", "nrfutil")
-				cmd.Stdout = os.Stdout
-				cmd.Stderr = os.Stderr
-				if err := cmd.Run(); err != nil {
+				cmd := &runner.Command{Name: uvPath, Args: []string{"tool", "install", "nrfutil"}, Stdout: os.Stdout, Stderr: os.Stderr}
+				if err := d.run.Run(ctx, cmd); err != nil {
 					errChan <- fmt.Errorf("failed to install nrfutil: %w", err)
 					return
 				}
+				installed.Note("nrfutil", installed.MethodUVTool, "")
 				ui.PrintSuccess("nrfutil installed successfully")
 
 			case "segger-jlink":
@@ -200,10 +211,11 @@ func (d *DarwinInstaller) InstallDependencies(deps []string) error {
 					return
 				}
 				ui.PrintInfo("Installing segger-jlink (this may take a few minutes)...")
-				if err := d.runBrewInstall("segger-jlink", true); err != nil {
+				if err := d.runBrewInstall(ctx, "segger-jlink", true); err != nil {
 					errChan <- fmt.Errorf("failed to install segger-jlink: %w", err)
 					return
 				}
+				installed.Note("segger-jlink", installed.MethodBrew, "")
 				ui.PrintSuccess("segger-jlink installed successfully")
 			}
 		}()
@@ -223,86 +235,11 @@ func (d *DarwinInstaller) InstallDependencies(deps []string) error {
 	return nil
 }
 
-// FlashBoard flashes the specified board using uvx (for J-Link boards)
-func (d *DarwinInstaller) FlashBoard(orgID, apiToken, board, deviceName string) (*FlashResult, error) {
-	ui.PrintInfo(fmt.Sprintf("Flashing board: %s", board))
-	ui.PrintInfo("This may take 10-15 seconds...")
-
-	uvPath, err := exec.LookPath("uv")
-	if err != nil {
-		return nil, fmt.Errorf("uv not found in PATH: %w", err)
-	}
-
-	// Build the command with --refresh to prevent stale versions
-	args := []string{"tool", "run", "--refresh", "--from", "pyhubbledemo", "hubbledemo", "flash", board, "-o", orgID, "-t", apiToken}
-	if deviceName != "" {
-		args = append(args, "-n", deviceName)
-	}
-	cmd := exec.Command(uvPath, args...)
-
-	cmd.Env = append(os.Environ(), "PYTHONWARNINGS=ignore")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("flash command failed: %w", err)
-	}
-
-	resultDeviceName := deviceName
-	if resultDeviceName == "" {
-		resultDeviceName = "your-device"
-	}
-
-	ui.PrintSuccess(fmt.Sprintf("Board %s flashed successfully!", board))
-	return &FlashResult{DeviceName: resultDeviceName}, nil
-}
-
-// GenerateHexFile generates a hex file for Uniflash boards (TI)
-func (d *DarwinInstaller) GenerateHexFile(orgID, apiToken, board, deviceName string) (*FlashResult, error) {
-	ui.PrintInfo(fmt.Sprintf("Generating hex file for board: %s", board))
-	ui.PrintInfo("This may take a few seconds...")
-
-	uvPath, err := exec.LookPath("uv")
-	if err != nil {
-		return nil, fmt.Errorf("uv not found in PATH: %w", err)
-	}
-
-	// Determine hex file path in current working directory
-	currentDir, err := os.Getwd()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get current directory: %w", err)
-	}
-
-	// Use device name for filename if provided, otherwise use board name
-	filename := board + ".hex"
-	if deviceName != "" {
-		filename = deviceName + ".hex"
-	}
-	hexFilePath := filepath.Join(currentDir, filename)
-
-	// Build the command with --refresh to prevent stale versions and -f for output file
-	args := []string{"tool", "run", "--refresh", "--from", "pyhubbledemo", "hubbledemo", "flash", board, "-o", orgID, "-t", apiToken, "-f", hexFilePath}
-	if deviceName != "" {
-		args = append(args, "-n", deviceName)
-	}
-	cmd := exec.Command(uvPath, args...)
-
-	cmd.Env = append(os.Environ(), "PYTHONWARNINGS=ignore")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("command failed: %w", err)
-	}
-
-	return &FlashResult{HexFilePath: hexFilePath}, nil
-}
-
 // Helper functions
 
 // commandExists checks if a command is available in PATH
 func (d *DarwinInstaller) commandExists(cmd string) bool {
-	_, err := exec.LookPath(cmd)
+	_, err := d.run.LookPath(cmd)
 	return err == nil
 }
 
@@ -312,27 +249,27 @@ func (d *DarwinInstaller) setupBrewPath() error {
 	// Apple Silicon: /opt/homebrew
 	// Intel: /usr/local
 	var brewPath string
-	if _, err := os.Stat("/opt/homebrew/bin/brew"); err == nil {
+	if _, err := d.run.Stat("/opt/homebrew/bin/brew"); err == nil {
 		brewPath = "/opt/homebrew/bin"
-	} else if _, err := os.Stat("/usr/local/bin/brew"); err == nil {
+	} else if _, err := d.run.Stat("/usr/local/bin/brew"); err == nil {
 		brewPath = "/usr/local/bin"
 	} else {
 		return fmt.Errorf("brew not found in expected locations")
 	}
 
 	// Update PATH for this process
-	currentPath := os.Getenv("PATH")
+	currentPath := d.run.Getenv("PATH")
 	if !strings.Contains(currentPath, brewPath) {
 		newPath := brewPath + ":" + currentPath
-		os.Setenv("PATH", newPath)
+		d.run.Setenv("PATH", newPath)
 	}
 
 	return nil
 }
 
 // runBrewInstall runs a brew install command
-func (d *DarwinInstaller) runBrewInstall(pkg string, showOutput bool) error {
-	cmd := exec.Command("brew", "install", pkg)
+func (d *DarwinInstaller) runBrewInstall(ctx context.Context, pkg string, showOutput bool) error {
+	cmd := &runner.Command{Name: "brew", Args: []string{"install", pkg}}
 
 	// Show output if requested
 	if showOutput {
@@ -340,5 +277,5 @@ func (d *DarwinInstaller) runBrewInstall(pkg string, showOutput bool) error {
 		cmd.Stderr = os.Stderr
 	}
 
-	return cmd.Run()
+	return d.run.Run(ctx, cmd)
 }
@@ -0,0 +1,144 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/HubbleNetwork/hubble-install/internal/boards"
+	"github.com/HubbleNetwork/hubble-install/internal/hex"
+	"github.com/HubbleNetwork/hubble-install/internal/hubbledemo"
+	"github.com/HubbleNetwork/hubble-install/internal/runner"
+	"github.com/HubbleNetwork/hubble-install/internal/ui"
+)
+
+// hubbledemoTool implements Flasher and ArtifactGenerator by running
+// hubbledemo through uv, which works the same way on every platform.
+// Installers embed it and only customize how uv is found and how failures
+// are explained.
+type hubbledemoTool struct {
+	run runner.Runner
+
+	// findUV locates the uv executable
+	findUV func(ctx context.Context) (string, error)
+
+	// explainFailure, if set, prints platform-specific help after hubbledemo
+	// fails; action is "flashing" or "hex file generation"
+	explainFailure func(action string, err error)
+}
+
+// newHubbledemoTool returns a hubbledemoTool that finds uv on PATH
+func newHubbledemoTool(r runner.Runner) hubbledemoTool {
+	return hubbledemoTool{
+		run: r,
+		findUV: func(ctx context.Context) (string, error) {
+			uvPath, err := r.LookPath("uv")
+			if err != nil {
+				return "", fmt.Errorf("uv not found in PATH: %w", err)
+			}
+			return uvPath, nil
+		},
+	}
+}
+
+// FlashBoard flashes the specified board using uvx (for J-Link boards)
+func (t hubbledemoTool) FlashBoard(ctx context.Context, orgID, apiToken, board, deviceName string) (*FlashResult, error) {
+	ui.PrintInfo(fmt.Sprintf("Flashing board: %s", board))
+	ui.PrintInfo("This may take 10-15 seconds...")
+
+	toolVersion, err := t.runFlash(ctx, "flashing", board, orgID, apiToken, deviceName, "")
+	if err != nil {
+		return nil, err
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Board %s flashed successfully!", board))
+	return &FlashResult{DeviceName: deviceName, ToolVersion: toolVersion}, nil
+}
+
+// GenerateHexFile generates a hex file for Uniflash boards (TI) at hexFilePath
+func (t hubbledemoTool) GenerateHexFile(ctx context.Context, orgID, apiToken, board, deviceName, hexFilePath string) (*FlashResult, error) {
+	ui.PrintInfo(fmt.Sprintf("Generating hex file for board: %s", board))
+	ui.PrintInfo("This may take a few seconds...")
+
+	toolVersion, err := t.runFlash(ctx, "hex file generation", board, orgID, apiToken, deviceName, hexFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	image, err := t.checkHexFile(hexFilePath, board)
+	if err != nil {
+		return nil, err
+	}
+	ui.PrintSuccess(fmt.Sprintf("Hex file checked: %d bytes of data, SHA-256 %s", image.DataBytes, image.SHA256))
+
+	return &FlashResult{HexFilePath: hexFilePath, ToolVersion: toolVersion, Image: image}, nil
+}
+
+// checkHexFile makes sure the firmware tool wrote a complete, valid image
+// that fits the board's flash, so a half-written file is never reported as
+// a success
+func (t hubbledemoTool) checkHexFile(path, boardID string) (*hex.Image, error) {
+	data, err := t.run.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("the firmware tool finished but did not write %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read generated hex file: %w", err)
+	}
+
+	image, err := hex.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("generated hex file %s is invalid: %w", path, err)
+	}
+
+	board, err := boards.GetBoard(boardID)
+	if err != nil {
+		return nil, err
+	}
+	var regions []hex.Range
+	for _, r := range board.FlashRegions() {
+		regions = append(regions, hex.Range{Start: r.Start, End: r.Start + r.Size})
+	}
+	if len(regions) > 0 {
+		if err := image.CheckFits(regions); err != nil {
+			return nil, fmt.Errorf("generated hex file %s does not fit the %s: %w", path, board.Name, err)
+		}
+	}
+	return image, nil
+}
+
+// runFlash runs "hubbledemo flash" and returns the tool version that ran.
+// With hexFile set the image is written to that file instead of the board.
+func (t hubbledemoTool) runFlash(ctx context.Context, action, board, orgID, apiToken, deviceName, hexFile string) (string, error) {
+	uvPath, err := t.findUV(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// Resolve the pinned tool version so the result records what produced the image
+	toolVersion, err := hubbledemo.Prepare(ctx, t.run, uvPath)
+	if err != nil {
+		return "", err
+	}
+
+	cmd := &runner.Command{
+		Name:   uvPath,
+		Args:   hubbledemo.FlashArgs(board, orgID, deviceName, hexFile),
+		Env:    []string{"PYTHONWARNINGS=ignore"},
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+
+	if err := hubbledemo.Run(ctx, t.run, cmd, apiToken); err != nil {
+		if t.explainFailure != nil {
+			t.explainFailure(action, err)
+		}
+		if hexFile == "" {
+			return "", fmt.Errorf("flash command failed: %w", err)
+		}
+		return "", fmt.Errorf("command failed: %w", err)
+	}
+
+	return toolVersion, nil
+}
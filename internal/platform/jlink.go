@@ -0,0 +1,90 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/HubbleNetwork/hubble-install/internal/runner"
+)
+
+// jlinkSerialPattern matches a probe in J-Link Commander's ShowEmuList output:
+//
+//	J-Link[0]: Connection: USB, Serial number: 683456789, ProductName: J-Link OB-nRF5340-NordicSemi
+var jlinkSerialPattern = regexp.MustCompile(`Serial number:\s*(\d+)`)
+
+// jlinkProbes implements ProbeFinder with J-Link Commander
+type jlinkProbes struct {
+	run runner.Runner
+	exe string // JLinkExe, or JLink.exe on Windows
+}
+
+// ProbeSerials lists the serial numbers of the connected J-Link probes
+func (j jlinkProbes) ProbeSerials(ctx context.Context) ([]string, error) {
+	path, err := j.run.LookPath(j.exe)
+	if err != nil {
+		return nil, fmt.Errorf("%s not found in PATH: %w", j.exe, err)
+	}
+
+	out, err := j.run.Output(ctx, &runner.Command{
+		Name:  path,
+		Args:  []string{"-NoGui", "1"},
+		Stdin: strings.NewReader("ShowEmuList\nExit\n"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing J-Link probes failed: %w", err)
+	}
+
+	var serials []string
+	for _, m := range jlinkSerialPattern.FindAllStringSubmatch(string(out), -1) {
+		serials = append(serials, m[1])
+	}
+	return serials, nil
+}
+
+// jlinkMemPattern matches a line of J-Link Commander's mem32 output:
+//
+//	00000000 = 20040000 00000B4D
+var jlinkMemPattern = regexp.MustCompile(`(?m)^([0-9A-Fa-f]{8}) = ((?:[0-9A-Fa-f]{8} ?)+)`)
+
+// jlinkConnectFailures are printed when J-Link Commander can't reach the target
+var jlinkConnectFailures = []string{"Cannot connect to target", "Could not connect to target", "Failed to attach", "No J-Link found"}
+
+// ReadMemory reads words 32-bit words starting at addr through the J-Link probe
+func (j jlinkProbes) ReadMemory(ctx context.Context, device string, addr uint32, words int) ([]uint32, error) {
+	path, err := j.run.LookPath(j.exe)
+	if err != nil {
+		return nil, fmt.Errorf("%s not found in PATH: %w", j.exe, err)
+	}
+
+	out, err := j.run.Output(ctx, &runner.Command{
+		Name:  path,
+		Args:  []string{"-NoGui", "1", "-Device", device, "-If", "SWD", "-Speed", "4000", "-AutoConnect", "1", "-ExitOnError", "1"},
+		Stdin: strings.NewReader(fmt.Sprintf("mem32 0x%08X, %d\nExit\n", addr, words)),
+	})
+	for _, failure := range jlinkConnectFailures {
+		if strings.Contains(string(out), failure) {
+			return nil, fmt.Errorf("J-Link could not connect to the %s: %s", device, failure)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading memory through J-Link failed: %w", err)
+	}
+
+	var values []uint32
+	for _, m := range jlinkMemPattern.FindAllStringSubmatch(string(out), -1) {
+		for _, field := range strings.Fields(m[2]) {
+			v, err := strconv.ParseUint(field, 16, 32)
+			if err != nil {
+				return nil, fmt.Errorf("unexpected J-Link output %q", m[0])
+			}
+			values = append(values, uint32(v))
+		}
+	}
+	if len(values) < words {
+		return nil, fmt.Errorf("J-Link returned %d of %d words at 0x%08X", len(values), words, addr)
+	}
+	return values[:words], nil
+}
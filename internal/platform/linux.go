@@ -1,12 +1,15 @@
 package platform
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strings"
+	"slices"
 
+	"github.com/HubbleNetwork/hubble-install/internal/installed"
+	"github.com/HubbleNetwork/hubble-install/internal/pinned"
+	"github.com/HubbleNetwork/hubble-install/internal/runner"
 	"github.com/HubbleNetwork/hubble-install/internal/ui"
 )
 
@@ -20,15 +23,24 @@ const (
 	PackageManagerDNF                    // Fedora, RHEL 8+
 )
 
-// LinuxInstaller implements the Installer interface for Linux
+// LinuxInstaller implements the Installer interface for Linux. Flashing and
+// hex generation use the shared hubbledemo implementation.
 type LinuxInstaller struct {
+	linuxRebootChecker
+	hubbledemoTool
+	jlinkProbes
+	run        runner.Runner
 	pkgManager PackageManager
 }
 
 // NewLinuxInstaller creates a new Linux installer
-func NewLinuxInstaller() *LinuxInstaller {
+func NewLinuxInstaller(r runner.Runner) *LinuxInstaller {
 	return &LinuxInstaller{
-		pkgManager: detectPackageManager(),
+		linuxRebootChecker: newLinuxRebootChecker(r, systemRoot),
+		hubbledemoTool:     newHubbledemoTool(r),
+		jlinkProbes:        jlinkProbes{r, "JLinkExe"},
+		run:                r,
+		pkgManager:         detectPackageManager(r),
 	}
 }
 
@@ -37,30 +49,20 @@ func (l *LinuxInstaller) Name() string {
 	return "Linux"
 }
 
-// CheckPendingReboot checks if a system reboot is pending (not typically needed on Linux)
-func (l *LinuxInstaller) CheckPendingReboot() error {
-	// Linux doesn't typically require reboot checks for package installations
-	// (though some kernel updates do, they're not relevant for our dependencies)
-	return nil
-}
-
 // ensureSudoAccess validates sudo access upfront to avoid multiple password prompts
-func (l *LinuxInstaller) ensureSudoAccess() error {
+func (l *LinuxInstaller) ensureSudoAccess(ctx context.Context) error {
 	// Check if we already have valid sudo credentials
-	checkCmd := exec.Command("sudo", "-n", "true")
-	if err := checkCmd.Run(); err == nil {
+	checkCmd := &runner.Command{Name: "sudo", Args: []string{"-n", "true"}}
+	if err := l.run.Run(ctx, checkCmd); err == nil {
 		// Already have valid sudo, no need to prompt
 		return nil
 	}
 
 	// Need to prompt for password
 	ui.PrintWarning("Administrator access required for installation")
-	cmd := exec.Command("sudo", "-v")
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd := &runner.Command{Name: "sudo", Args: []string{"-v"}, Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr}
 
-	if err := cmd.Run(); err != nil {
+	if err := l.run.Run(ctx, cmd); err != nil {
 		return fmt.Errorf("failed to obtain sudo access: %w", err)
 	}
 
@@ -68,7 +70,7 @@ func (l *LinuxInstaller) ensureSudoAccess() error {
 }
 
 // CheckPrerequisites checks for missing dependencies based on required deps
-func (l *LinuxInstaller) CheckPrerequisites(requiredDeps []string) ([]MissingDependency, error) {
+func (l *LinuxInstaller) CheckPrerequisites(ctx context.Context, requiredDeps []string) ([]MissingDependency, error) {
 	var missing []MissingDependency
 
 	// Check if package manager is supported
@@ -125,23 +127,30 @@ func (l *LinuxInstaller) CheckPrerequisites(requiredDeps []string) ([]MissingDep
 }
 
 // InstallPackageManager is not needed for Linux (uv and jlink use direct installers)
-func (l *LinuxInstaller) InstallPackageManager() error {
+func (l *LinuxInstaller) InstallPackageManager(ctx context.Context) error {
 	// Both uv (astral.sh) and jlink (SEGGER) use their own installers
 	// No package manager operations needed
 	return nil
 }
 
 // InstallDependencies installs the specified dependencies
-func (l *LinuxInstaller) InstallDependencies(deps []string) error {
+func (l *LinuxInstaller) InstallDependencies(ctx context.Context, deps []string) error {
 	for _, dep := range deps {
 		switch dep {
 		case "uv":
 			// Install uv (must be installed via astral.sh installer)
 			if !l.commandExists("uv") {
 				ui.PrintInfo("Installing uv from astral.sh...")
-				if err := l.installUV(); err != nil {
+				if err := l.installUV(ctx); err != nil {
 					return fmt.Errorf("failed to install uv: %w", err)
 				}
+				// Uninstall deletes the recorded binary, so only record a path
+				// that was actually found
+				if uvPath, err := l.run.LookPath("uv"); err == nil && filepath.IsAbs(uvPath) {
+					installed.Note("uv", installed.MethodUVScript, uvPath)
+				} else {
+					ui.PrintWarning("uv was installed but is not on PATH yet; uninstall will not remove it")
+				}
 				ui.PrintSuccess("uv installed successfully")
 			} else {
 				ui.PrintSuccess("uv already installed")
@@ -151,17 +160,16 @@ func (l *LinuxInstaller) InstallDependencies(deps []string) error {
 				ui.PrintSuccess("nrfutil already installed")
 				break
 			}
-			uvPath, err := exec.LookPath("uv")
+			uvPath, err := l.run.LookPath("uv")
 			if err != nil {
 				return fmt.Errorf("uv not found in PATH (required to install nrfutil): %w", err)
 			}
 			ui.PrintInfo("Installing nrfutil (via uv tool install)...")
-			cmd := exec.Command(uvPath, "tool", "install", "nrfutil")
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
-			if err := cmd.Run(); err != nil {
+			cmd := &runner.Command{Name: uvPath, Args: []string{"tool", "install", "nrfutil"}, Stdout: os.Stdout, Stderr: os.Stderr}
+			if err := l.run.Run(ctx, cmd); err != nil {
 				return fmt.Errorf("failed to install nrfutil: %w", err)
 			}
+			installed.Note("nrfutil", installed.MethodUVTool, "")
 			ui.PrintSuccess("nrfutil installed successfully")
 		case "segger-jlink":
 			// J-Link must be installed manually on Linux - verified in CheckPrerequisites
@@ -175,116 +183,50 @@ func (l *LinuxInstaller) InstallDependencies(deps []string) error {
 }
 
 // installUV installs uv using the official astral.sh installer
-func (l *LinuxInstaller) installUV() error {
-	// Download and run the uv installer script
-	cmd := exec.Command("sh", "-c", "curl -LsSf https://astral.sh/uv/install.sh | sh")
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("uv installation failed: %w", err)
-	}
-
-	// Add uv to PATH for current process
-	// The installer puts it in ~/.cargo/bin
-	homeDir := os.Getenv("HOME")
-	cargoPath := filepath.Join(homeDir, ".cargo", "bin")
-
-	currentPath := os.Getenv("PATH")
-	if !strings.Contains(currentPath, cargoPath) {
-		os.Setenv("PATH", cargoPath+":"+currentPath)
-	}
-
-	return nil
-}
-
-// FlashBoard flashes the specified board using uvx (for J-Link boards)
-func (l *LinuxInstaller) FlashBoard(orgID, apiToken, board, deviceName string) (*FlashResult, error) {
-	ui.PrintInfo(fmt.Sprintf("Flashing board: %s", board))
-	ui.PrintInfo("This may take 10-15 seconds...")
-
-	uvPath, err := exec.LookPath("uv")
+func (l *LinuxInstaller) installUV(ctx context.Context) error {
+	// Download the uv installer script and run it once its checksum is verified
+	script, cleanup, err := pinned.DownloadTemp(ctx, l.run, pinned.UVInstall)
 	if err != nil {
-		return nil, fmt.Errorf("uv not found in PATH: %w", err)
+		return fmt.Errorf("failed to download the uv install script: %w", err)
 	}
-
-	// Build the command
-	args := []string{"tool", "run", "--from", "pyhubbledemo", "hubbledemo", "flash", board, "-o", orgID, "-t", apiToken}
-	if deviceName != "" {
-		args = append(args, "-n", deviceName)
-	}
-	cmd := exec.Command(uvPath, args...)
-
-	cmd.Env = append(os.Environ(), "PYTHONWARNINGS=ignore")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("flash command failed: %w", err)
+	defer cleanup()
+
+	cmd := &runner.Command{
+		Name:   "sh",
+		Args:   []string{script},
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
 	}
 
-	resultDeviceName := deviceName
-	if resultDeviceName == "" {
-		resultDeviceName = "your-device"
-	}
-
-	ui.PrintSuccess(fmt.Sprintf("Board %s flashed successfully!", board))
-	return &FlashResult{DeviceName: resultDeviceName}, nil
-}
-
-// GenerateHexFile generates a hex file for Uniflash boards (TI)
-func (l *LinuxInstaller) GenerateHexFile(orgID, apiToken, board, deviceName string) (*FlashResult, error) {
-	ui.PrintInfo(fmt.Sprintf("Generating hex file for board: %s", board))
-	ui.PrintInfo("This may take a few seconds...")
-
-	uvPath, err := exec.LookPath("uv")
-	if err != nil {
-		return nil, fmt.Errorf("uv not found in PATH: %w", err)
-	}
-
-	// Determine hex file path in current working directory
-	currentDir, err := os.Getwd()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get current directory: %w", err)
-	}
-
-	// Use device name for filename if provided, otherwise use board name
-	filename := board + ".hex"
-	if deviceName != "" {
-		filename = deviceName + ".hex"
-	}
-	hexFilePath := filepath.Join(currentDir, filename)
-
-	// Build the command with -f for output file
-	args := []string{"tool", "run", "--from", "pyhubbledemo", "hubbledemo", "flash", board, "-o", orgID, "-t", apiToken, "-f", hexFilePath}
-	if deviceName != "" {
-		args = append(args, "-n", deviceName)
+	if err := l.run.Run(ctx, cmd); err != nil {
+		return fmt.Errorf("uv installation failed: %w", err)
 	}
-	cmd := exec.Command(uvPath, args...)
-
-	cmd.Env = append(os.Environ(), "PYTHONWARNINGS=ignore")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
 
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("command failed: %w", err)
+	// Add uv to PATH for current process. Current installers put it in
+	// ~/.local/bin, older ones in ~/.cargo/bin.
+	homeDir := l.run.Getenv("HOME")
+	for _, dir := range []string{filepath.Join(homeDir, ".cargo", "bin"), filepath.Join(homeDir, ".local", "bin")} {
+		currentPath := l.run.Getenv("PATH")
+		if !slices.Contains(filepath.SplitList(currentPath), dir) {
+			l.run.Setenv("PATH", dir+string(filepath.ListSeparator)+currentPath)
+		}
 	}
 
-	return &FlashResult{HexFilePath: hexFilePath}, nil
+	return nil
 }
 
 // Helper functions
 
 // detectPackageManager detects which package manager is available
-func detectPackageManager() PackageManager {
-	if commandExistsGlobal("apt-get") {
+func detectPackageManager(r runner.Runner) PackageManager {
+	if commandExistsGlobal(r, "apt-get") {
 		return PackageManagerAPT
 	}
-	if commandExistsGlobal("dnf") {
+	if commandExistsGlobal(r, "dnf") {
 		return PackageManagerDNF
 	}
-	if commandExistsGlobal("yum") {
+	if commandExistsGlobal(r, "yum") {
 		return PackageManagerYUM
 	}
 	return PackageManagerUnknown
@@ -292,27 +234,27 @@ func detectPackageManager() PackageManager {
 
 // commandExists checks if a command is available in PATH
 func (l *LinuxInstaller) commandExists(cmd string) bool {
-	_, err := exec.LookPath(cmd)
+	_, err := l.run.LookPath(cmd)
 	return err == nil
 }
 
 // commandExistsGlobal checks if a command is available (global function for init)
-func commandExistsGlobal(cmd string) bool {
-	_, err := exec.LookPath(cmd)
+func commandExistsGlobal(r runner.Runner, cmd string) bool {
+	_, err := r.LookPath(cmd)
 	return err == nil
 }
 
 // installPackage installs a package using the detected package manager
-func (l *LinuxInstaller) installPackage(pkg string, showOutput bool) error {
-	var cmd *exec.Cmd
+func (l *LinuxInstaller) installPackage(ctx context.Context, pkg string, showOutput bool) error {
+	var cmd *runner.Command
 
 	switch l.pkgManager {
 	case PackageManagerAPT:
-		cmd = exec.Command("sudo", "apt-get", "install", "-y", pkg)
+		cmd = &runner.Command{Name: "sudo", Args: []string{"apt-get", "install", "-y", pkg}}
 	case PackageManagerDNF:
-		cmd = exec.Command("sudo", "dnf", "install", "-y", pkg)
+		cmd = &runner.Command{Name: "sudo", Args: []string{"dnf", "install", "-y", pkg}}
 	case PackageManagerYUM:
-		cmd = exec.Command("sudo", "yum", "install", "-y", pkg)
+		cmd = &runner.Command{Name: "sudo", Args: []string{"yum", "install", "-y", pkg}}
 	default:
 		return fmt.Errorf("unsupported package manager")
 	}
@@ -323,5 +265,5 @@ func (l *LinuxInstaller) installPackage(pkg string, showOutput bool) error {
 		cmd.Stderr = os.Stderr
 	}
 
-	return cmd.Run()
+	return l.run.Run(ctx, cmd)
 }
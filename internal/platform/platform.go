@@ -1,8 +1,12 @@
 package platform
 
 import (
+	"context"
 	"fmt"
 	"runtime"
+
+	"github.com/HubbleNetwork/hubble-install/internal/hex"
+	"github.com/HubbleNetwork/hubble-install/internal/runner"
 )
 
 // MissingDependency represents a missing system dependency
@@ -13,44 +17,86 @@ type MissingDependency struct {
 
 // FlashResult contains the result of a flash operation
 type FlashResult struct {
-	DeviceName  string // Device name (for J-Link flash)
-	HexFilePath string // Path to generated hex file (for Uniflash)
+	DeviceName  string     // Device name (for J-Link flash); empty if the firmware tool chose it
+	HexFilePath string     // Path to generated hex file (for Uniflash)
+	ToolVersion string     // pyhubbledemo version that produced the image
+	Image       *hex.Image // Checked contents of the hex file (for Uniflash)
 }
 
-// Installer defines the interface for platform-specific installation
-type Installer interface {
-	// Name returns the platform name
-	Name() string
-
+// RebootChecker detects a pending system reboot that would break installation
+type RebootChecker interface {
 	// CheckPendingReboot checks if a system reboot is pending (platform-specific)
-	CheckPendingReboot() error
+	CheckPendingReboot(ctx context.Context) error
+}
 
+// DependencyManager finds and installs the tools a board needs
+type DependencyManager interface {
 	// CheckPrerequisites checks for missing dependencies based on required deps
-	CheckPrerequisites(requiredDeps []string) ([]MissingDependency, error)
+	CheckPrerequisites(ctx context.Context, requiredDeps []string) ([]MissingDependency, error)
 
 	// InstallPackageManager installs the package manager (e.g., Homebrew)
-	InstallPackageManager() error
+	InstallPackageManager(ctx context.Context) error
 
 	// InstallDependencies installs the specified dependencies
-	InstallDependencies(deps []string) error
+	InstallDependencies(ctx context.Context, deps []string) error
+}
 
+// Flasher writes firmware with credentials directly to a connected board
+type Flasher interface {
 	// FlashBoard flashes the specified board with credentials and returns the result
-	FlashBoard(orgID, apiToken, board, deviceName string) (*FlashResult, error)
+	FlashBoard(ctx context.Context, orgID, apiToken, board, deviceName string) (*FlashResult, error)
+}
+
+// ArtifactGenerator produces firmware images for boards flashed with external tools
+type ArtifactGenerator interface {
+	// GenerateHexFile writes a hex file for Uniflash boards to hexFilePath
+	GenerateHexFile(ctx context.Context, orgID, apiToken, board, deviceName, hexFilePath string) (*FlashResult, error)
+}
 
-	// GenerateHexFile generates a hex file for Uniflash boards and returns the path
-	GenerateHexFile(orgID, apiToken, board, deviceName string) (*FlashResult, error)
+// ProbeFinder identifies the debug probes connected to the computer
+type ProbeFinder interface {
+	// ProbeSerials lists the serial numbers of the connected J-Link probes
+	ProbeSerials(ctx context.Context) ([]string, error)
+}
+
+// MemoryReader reads target memory through the debug probe
+type MemoryReader interface {
+	// ReadMemory reads 32-bit words from the J-Link target named device
+	ReadMemory(ctx context.Context, device string, addr uint32, words int) ([]uint32, error)
+}
+
+// Installer combines every capability a supported platform provides. Every
+// method stops and kills the commands it started when ctx is cancelled.
+// Platforms embed the shared implementations (hubbledemoTool, jlinkProbes)
+// and implement only what differs.
+type Installer interface {
+	// Name returns the platform name
+	Name() string
+
+	RebootChecker
+	DependencyManager
+	Flasher
+	ArtifactGenerator
+	ProbeFinder
+	MemoryReader
 }
 
 // GetInstaller returns the appropriate installer for the current platform
 func GetInstaller() (Installer, error) {
-	switch runtime.GOOS {
+	return NewInstaller(runtime.GOOS, runner.New())
+}
+
+// NewInstaller returns the installer for the given GOOS using r for all
+// system access. Tests pass a runner.Fake to exercise any platform on any host.
+func NewInstaller(goos string, r runner.Runner) (Installer, error) {
+	switch goos {
 	case "darwin":
-		return NewDarwinInstaller(), nil
+		return NewDarwinInstaller(r), nil
 	case "linux":
-		return NewLinuxInstaller(), nil
+		return NewLinuxInstaller(r), nil
 	case "windows":
-		return NewWindowsInstaller(), nil
+		return NewWindowsInstaller(r), nil
 	default:
-		return nil, fmt.Errorf("unsupported platform: %s", runtime.GOOS)
+		return nil, fmt.Errorf("unsupported platform: %s", goos)
 	}
 }
@@ -0,0 +1,483 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/HubbleNetwork/hubble-install/internal/hubbledemo"
+	"github.com/HubbleNetwork/hubble-install/internal/installed"
+	"github.com/HubbleNetwork/hubble-install/internal/pinned"
+	"github.com/HubbleNetwork/hubble-install/internal/runner"
+)
+
+// newFake returns a Fake with the given commands on PATH. The uninstall
+// record written by installed.Note goes to a temporary directory.
+func newFake(t *testing.T, commands ...string) *runner.Fake {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("AppData", home)
+
+	f := runner.NewFake()
+	for _, name := range commands {
+		f.AddCommand(name, "/usr/bin/"+name)
+	}
+	f.Setenv("LOCALAPPDATA", "/appdata")
+	return f
+}
+
+// callStrings formats the calls for comparison, sorted when the installer
+// runs them in parallel
+func callStrings(f *runner.Fake, sorted bool) []string {
+	var calls []string
+	for _, call := range f.Calls() {
+		calls = append(calls, call.String())
+	}
+	if sorted {
+		sort.Strings(calls)
+	}
+	return calls
+}
+
+// matchCalls compares calls with the expected ones; an expectation ending in
+// " *" matches any remaining arguments
+func matchCalls(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range want {
+		if prefix, ok := strings.CutSuffix(want[i], " *"); ok {
+			if !strings.HasPrefix(got[i], prefix+" ") {
+				return false
+			}
+		} else if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCheckPrerequisites(t *testing.T) {
+	allDeps := []string{"uv", "nrfutil", "segger-jlink"}
+	tests := []struct {
+		name     string
+		goos     string
+		commands []string
+		files    []string
+		deps     []string
+		want     []string
+		wantErr  string
+	}{
+		{
+			name:     "linux with everything installed",
+			goos:     "linux",
+			commands: []string{"apt-get", "uv", "nrfutil", "JLinkExe"},
+			deps:     allDeps,
+		},
+		{
+			name:     "linux missing uv and nrfutil",
+			goos:     "linux",
+			commands: []string{"dnf", "JLinkExe"},
+			deps:     allDeps,
+			want:     []string{"uv", "nrfutil"},
+		},
+		{
+			name:     "linux without J-Link",
+			goos:     "linux",
+			commands: []string{"apt-get", "uv"},
+			deps:     allDeps,
+			wantErr:  "J-Link must be installed",
+		},
+		{
+			name:     "linux without a supported package manager",
+			goos:     "linux",
+			commands: []string{"uv"},
+			deps:     []string{"uv"},
+			wantErr:  "unsupported Linux distribution",
+		},
+		{
+			name: "darwin on a fresh machine",
+			goos: "darwin",
+			deps: allDeps,
+			want: []string{"Homebrew", "uv", "nrfutil", "segger-jlink"},
+		},
+		{
+			name:     "darwin with everything installed",
+			goos:     "darwin",
+			commands: []string{"brew", "uv", "nrfutil", "JLinkExe"},
+			deps:     allDeps,
+		},
+		{
+			name: "windows on a fresh machine",
+			goos: "windows",
+			deps: allDeps,
+			want: []string{"Chocolatey", "uv", "nrfutil"},
+		},
+		{
+			name:     "windows with nrfutil at the default location",
+			goos:     "windows",
+			commands: []string{"choco", "uv"},
+			files:    []string{"/appdata/hubble/nrfutil/nrfutil.exe"},
+			deps:     []string{"uv", "nrfutil"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFake(t, tt.commands...)
+			for _, path := range tt.files {
+				f.AddFile(path, nil)
+			}
+			inst, err := NewInstaller(tt.goos, f)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			missing, err := inst.CheckPrerequisites(context.Background(), tt.deps)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, m := range missing {
+				got = append(got, m.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("missing = %v, want %v", got, tt.want)
+			}
+			if calls := f.Calls(); len(calls) > 0 {
+				t.Errorf("checking prerequisites ran %v", calls)
+			}
+		})
+	}
+}
+
+func TestInstallDependencies(t *testing.T) {
+	tests := []struct {
+		name     string
+		goos     string
+		commands []string
+		deps     []string
+		handlers map[string]runner.Handler
+		parallel bool // Calls are made concurrently and compared sorted
+		want     []string
+		wantErr  string
+		reboot   bool
+	}{
+		{
+			name:     "linux installs nrfutil with uv",
+			goos:     "linux",
+			commands: []string{"apt-get", "uv", "JLinkExe"},
+			deps:     []string{"uv", "nrfutil", "segger-jlink"},
+			want:     []string{"uv tool install nrfutil"},
+		},
+		{
+			name:     "linux with everything installed",
+			goos:     "linux",
+			commands: []string{"apt-get", "uv", "nrfutil", "JLinkExe"},
+			deps:     []string{"uv", "nrfutil", "segger-jlink"},
+		},
+		{
+			name:     "linux nrfutil without uv",
+			goos:     "linux",
+			commands: []string{"apt-get"},
+			deps:     []string{"nrfutil"},
+			wantErr:  "uv not found in PATH",
+		},
+		{
+			name:     "darwin installs with brew",
+			goos:     "darwin",
+			commands: []string{"brew"},
+			deps:     []string{"uv", "segger-jlink"},
+			parallel: true,
+			want:     []string{"brew install segger-jlink", "brew install uv"},
+		},
+		{
+			name:     "darwin installs nrfutil with uv",
+			goos:     "darwin",
+			commands: []string{"brew", "uv", "JLinkExe"},
+			deps:     []string{"uv", "nrfutil", "segger-jlink"},
+			parallel: true,
+			want:     []string{"uv tool install nrfutil"},
+		},
+		{
+			name:     "darwin brew failure",
+			goos:     "darwin",
+			commands: []string{"brew"},
+			deps:     []string{"uv"},
+			handlers: map[string]runner.Handler{
+				"brew": func(f *runner.Fake, cmd *runner.Command) error { return &runner.ExitError{Code: 1} },
+			},
+			parallel: true,
+			want:     []string{"brew install uv"},
+			wantErr:  "failed to install uv",
+		},
+		{
+			name:     "windows installs uv with Chocolatey",
+			goos:     "windows",
+			commands: []string{"choco"},
+			deps:     []string{"uv"},
+			want:     []string{"net session", "choco install uv -y", "powershell -NoProfile -Command *"},
+		},
+		{
+			name:     "windows without administrator rights",
+			goos:     "windows",
+			commands: []string{"choco"},
+			deps:     []string{"uv"},
+			handlers: map[string]runner.Handler{
+				"net": func(f *runner.Fake, cmd *runner.Command) error { return &runner.ExitError{Code: 2} },
+			},
+			want:    []string{"net session"},
+			wantErr: "administrator privileges required",
+		},
+		{
+			name:     "windows Chocolatey asks for a reboot",
+			goos:     "windows",
+			commands: []string{"choco"},
+			deps:     []string{"uv"},
+			handlers: map[string]runner.Handler{
+				"choco": func(f *runner.Fake, cmd *runner.Command) error { return &runner.ExitError{Code: 3010} },
+			},
+			want:    []string{"net session", "choco install uv -y"},
+			wantErr: "requires a system reboot",
+			reboot:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFake(t, tt.commands...)
+			for name, h := range tt.handlers {
+				f.Handle(name, h)
+			}
+			inst, err := NewInstaller(tt.goos, f)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = inst.InstallDependencies(context.Background(), tt.deps)
+			if tt.wantErr == "" && err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+			var rebootErr *RebootRequiredError
+			if errors.As(err, &rebootErr) != tt.reboot {
+				t.Errorf("error = %v, reboot required = %v", err, tt.reboot)
+			}
+
+			if got := callStrings(f, tt.parallel); !matchCalls(got, tt.want) {
+				t.Errorf("calls = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// uvHandler scripts uv: dependency resolution pins pyhubbledemo to version,
+// and "hubbledemo flash" stores its stdin in stdin and runs flash for each
+// attempt
+func uvHandler(version string, stdin *string, flash func(attempt int, cmd *runner.Command) error) runner.Handler {
+	attempts := 0
+	return func(f *runner.Fake, cmd *runner.Command) error {
+		switch {
+		case slices.Contains(cmd.Args, "compile"):
+			fmt.Fprintf(cmd.Stdout, "pyhubbledemo==%s \\\n    --hash=sha256:00\n", version)
+		case slices.Contains(cmd.Args, "flash"):
+			if cmd.Stdin != nil {
+				data, _ := io.ReadAll(cmd.Stdin)
+				*stdin = string(data)
+			}
+			attempts++
+			if flash != nil {
+				return flash(attempts, cmd)
+			}
+		}
+		return nil
+	}
+}
+
+func TestFlashBoard(t *testing.T) {
+	tests := []struct {
+		name     string
+		goos     string
+		commands []string
+		version  string // Resolved pyhubbledemo version (default 1.2.3)
+		flash    func(attempt int, cmd *runner.Command) error
+		attempts int
+		wantErr  string
+	}{
+		{name: "linux", goos: "linux", commands: []string{"apt-get", "uv"}, attempts: 1},
+		{name: "darwin", goos: "darwin", commands: []string{"uv"}, attempts: 1},
+		{name: "windows", goos: "windows", commands: []string{"uv"}, attempts: 1},
+		{name: "older release", goos: "linux", commands: []string{"apt-get", "uv"}, version: "0.9.0", attempts: 1},
+		{name: "linux without uv", goos: "linux", commands: []string{"apt-get"}, wantErr: "uv not found in PATH"},
+		{name: "windows without uv", goos: "windows", wantErr: "uv executable not found"},
+		{
+			name:     "lookup failure before hubbledemo starts is retried",
+			goos:     "linux",
+			commands: []string{"apt-get", "uv"},
+			flash: func(attempt int, cmd *runner.Command) error {
+				if attempt == 1 {
+					fmt.Fprintln(cmd.Stderr, "Failed to establish a new connection: Name or service not known")
+					return &runner.ExitError{Code: 1}
+				}
+				return nil
+			},
+			attempts: 2,
+		},
+		{
+			name:     "lookup failure after hubbledemo started is not retried",
+			goos:     "linux",
+			commands: []string{"apt-get", "uv"},
+			flash: func(attempt int, cmd *runner.Command) error {
+				fmt.Fprintln(cmd.Stderr, hubbledemo.StartMarker)
+				fmt.Fprintln(cmd.Stderr, "Failed to establish a new connection: Name or service not known")
+				return &runner.ExitError{Code: 1}
+			},
+			attempts: 1,
+			wantErr:  "may have reached the server",
+		},
+		{
+			name:     "tool error is not retried",
+			goos:     "darwin",
+			commands: []string{"uv"},
+			flash: func(attempt int, cmd *runner.Command) error {
+				fmt.Fprintln(cmd.Stderr, hubbledemo.StartMarker)
+				fmt.Fprintln(cmd.Stderr, "Error: no J-Link probe found")
+				return &runner.ExitError{Code: 1}
+			},
+			attempts: 1,
+			wantErr:  "flash command failed",
+		},
+	}
+
+	const token = "secret-token"
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hubbledemo.Configure("", "")
+			f := newFake(t, tt.commands...)
+			version := tt.version
+			if version == "" {
+				version = "1.2.3"
+			}
+			var stdin string
+			f.Handle("uv", uvHandler(version, &stdin, tt.flash))
+			inst, err := NewInstaller(tt.goos, f)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := inst.FlashBoard(context.Background(), "org-1", token, "nrf52dk", "desk-1")
+
+			var flashes []runner.Call
+			for _, call := range f.CallsTo("uv") {
+				if slices.Contains(call.Args, "flash") {
+					flashes = append(flashes, call)
+				}
+			}
+			if len(flashes) != tt.attempts {
+				t.Errorf("flash ran %d times, want %d", len(flashes), tt.attempts)
+			}
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.DeviceName != "desk-1" || result.ToolVersion != version {
+				t.Errorf("result = %+v", result)
+			}
+
+			call := flashes[len(flashes)-1]
+			want := []string{"flash", "nrf52dk", "-o", "org-1", "-n", "desk-1"}
+			if got := call.Args[len(call.Args)-len(want):]; !slices.Equal(got, want) {
+				t.Errorf("flash args = %q, want them to end with %q", call.Args, want)
+			}
+			if !slices.Contains(call.Args, "pyhubbledemo=="+version) {
+				t.Errorf("flash args = %q, want the resolved version pinned", call.Args)
+			}
+			if strings.Contains(call.String(), token) || strings.Contains(strings.Join(call.Env, " "), token) {
+				t.Errorf("flash call %s (env %q) exposes the API token", call, call.Env)
+			}
+			if stdin != token+"\n" {
+				t.Errorf("flash stdin = %q, want the API token", stdin)
+			}
+		})
+	}
+}
+
+func TestLinuxUVScriptRecord(t *testing.T) {
+	tests := []struct {
+		name     string
+		uvPath   string // Where the install script puts uv; empty if it isn't found afterwards
+		wantPath string // Recorded path; empty for no record
+	}{
+		{name: "found after install", uvPath: "/home/maker/.local/bin/uv", wantPath: "/home/maker/.local/bin/uv"},
+		{name: "not on PATH after install"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFake(t, "apt-get", "sh")
+			f.Setenv("HOME", "/home/maker")
+			script, err := pinned.Lookup(pinned.UVInstall)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if script.Pinned() {
+				t.Skip("the fake install script doesn't match the pinned digest")
+			}
+			f.AddURL(script.Source(), runner.Response{Body: []byte("#!/bin/sh\n")})
+			f.Handle("sh", func(f *runner.Fake, cmd *runner.Command) error {
+				if tt.uvPath != "" {
+					f.AddCommand("uv", tt.uvPath)
+				}
+				return nil
+			})
+			inst, err := NewInstaller("linux", f)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := inst.InstallDependencies(context.Background(), []string{"uv"}); err != nil {
+				t.Fatal(err)
+			}
+
+			record, err := installed.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got string
+			for _, item := range record.Items {
+				if item.Name == "uv" {
+					got = item.Path
+				}
+			}
+			if got != tt.wantPath || (tt.wantPath == "" && len(record.Items) > 0) {
+				t.Errorf("record = %+v, want uv at %q", record.Items, tt.wantPath)
+			}
+			if path := filepath.SplitList(f.Getenv("PATH")); !slices.Contains(path, "/home/maker/.local/bin") {
+				t.Errorf("PATH = %q, want ~/.local/bin added", path)
+			}
+		})
+	}
+}
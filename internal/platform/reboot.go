@@ -0,0 +1,203 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/HubbleNetwork/hubble-install/internal/runner"
+)
+
+// RebootRequiredError is returned when a system reboot is required
+type RebootRequiredError struct {
+	Message string
+
+	// SessionOnly is set when logging out and back in is enough, e.g. after
+	// the user was added to a group
+	SessionOnly bool
+}
+
+func (e *RebootRequiredError) Error() string {
+	return e.Message
+}
+
+// systemRoot prefixes the system files read by the Linux reboot checks. It
+// is empty in real builds; the end-to-end harness sets it at link time
+// (-ldflags -X) to a scratch directory.
+var systemRoot = ""
+
+// deviceGroups grant access to USB debug probes and serial ports on Linux
+var deviceGroups = []string{"dialout", "plugdev"}
+
+// darwinRebootChecker detects system extensions that are only replaced or
+// removed at the next boot (e.g. a USB driver update). macOS software
+// updates are not checked: "softwareupdate" can't tell one that is staged
+// from one that is merely available. Stale group memberships don't apply,
+// as probe access on macOS doesn't depend on groups.
+type darwinRebootChecker struct {
+	run runner.Runner
+}
+
+// CheckPendingReboot reports system extensions waiting for a reboot
+func (c darwinRebootChecker) CheckPendingReboot(ctx context.Context) error {
+	path, err := c.run.LookPath("systemextensionsctl")
+	if err != nil {
+		return nil
+	}
+	out, err := c.run.Output(ctx, &runner.Command{Name: path, Args: []string{"list"}})
+	if err != nil {
+		return nil
+	}
+
+	// enabled active teamID bundleID (version) name [state]
+	var waiting []string
+	for _, line := range strings.Split(string(out), "\n") {
+		lower := strings.ToLower(line)
+		if !strings.Contains(lower, "waiting") || !strings.Contains(lower, "reboot") {
+			continue
+		}
+		name := "a system extension"
+		if fields := strings.Split(line, "\t"); len(fields) >= 4 {
+			if id, _, _ := strings.Cut(strings.TrimSpace(fields[3]), " "); id != "" {
+				name = id
+			}
+		}
+		if !contains(waiting, name) {
+			waiting = append(waiting, name)
+		}
+	}
+	if len(waiting) == 0 {
+		return nil
+	}
+	return &RebootRequiredError{
+		Message: fmt.Sprintf("pending reboot detected (system extension waiting for a reboot: %s)", strings.Join(waiting, ", ")),
+	}
+}
+
+// linuxRebootChecker detects package updates that need a reboot and group
+// memberships that only take effect in a new login session
+type linuxRebootChecker struct {
+	run  runner.Runner
+	root string // Prefix for system files; empty on a real system
+}
+
+// newLinuxRebootChecker reads system files below root ("" for the real system)
+func newLinuxRebootChecker(r runner.Runner, root string) linuxRebootChecker {
+	return linuxRebootChecker{run: r, root: root}
+}
+
+// CheckPendingReboot checks for a pending reboot, then for a stale login session
+func (c linuxRebootChecker) CheckPendingReboot(ctx context.Context) error {
+	var reasons []string
+
+	// Debian and Ubuntu: written by package scripts (kernel, libc, ...)
+	if _, err := c.run.Stat(c.path("/var/run/reboot-required")); err == nil {
+		reason := "system updates"
+		if data, err := c.run.ReadFile(c.path("/var/run/reboot-required.pkgs")); err == nil {
+			if pkgs := uniqueFields(string(data)); len(pkgs) > 0 {
+				reason = "updated packages: " + strings.Join(pkgs, ", ")
+			}
+		}
+		reasons = append(reasons, reason)
+	}
+
+	// Fedora and RHEL: needs-restarting -r exits 1 when a reboot is needed
+	if path, err := c.run.LookPath("needs-restarting"); err == nil {
+		_, err := c.run.Output(ctx, &runner.Command{Name: path, Args: []string{"-r"}})
+		if code, ok := runner.ExitCode(err); ok && code == 1 {
+			reasons = append(reasons, "core libraries or kernel updated")
+		}
+	}
+
+	if len(reasons) > 0 {
+		return &RebootRequiredError{
+			Message: fmt.Sprintf("pending reboot detected (%s)", strings.Join(reasons, "; ")),
+		}
+	}
+
+	if stale := c.staleGroups(ctx); len(stale) > 0 {
+		return &RebootRequiredError{
+			Message: fmt.Sprintf("you were added to the %s group, but this login session started before that, so the debug probe is not accessible yet",
+				strings.Join(stale, " and ")),
+			SessionOnly: true,
+		}
+	}
+
+	return nil
+}
+
+// staleGroups returns the device groups /etc/group lists the user in that
+// the current session does not have yet
+func (c linuxRebootChecker) staleGroups(ctx context.Context) []string {
+	idPath, err := c.run.LookPath("id")
+	if err != nil {
+		return nil
+	}
+	userOut, err := c.run.Output(ctx, &runner.Command{Name: idPath, Args: []string{"-un"}})
+	if err != nil {
+		return nil
+	}
+	activeOut, err := c.run.Output(ctx, &runner.Command{Name: idPath, Args: []string{"-Gn"}})
+	if err != nil {
+		return nil
+	}
+	data, err := c.run.ReadFile(c.path("/etc/group"))
+	if err != nil {
+		return nil
+	}
+
+	user := strings.TrimSpace(string(userOut))
+	active := strings.Fields(string(activeOut))
+	members := groupMembers(string(data))
+
+	var stale []string
+	for _, group := range deviceGroups {
+		if contains(members[group], user) && !contains(active, group) {
+			stale = append(stale, group)
+		}
+	}
+	return stale
+}
+
+// path returns the location of a system file below the configured root
+func (c linuxRebootChecker) path(name string) string {
+	if c.root == "" {
+		return name
+	}
+	return filepath.Join(c.root, filepath.FromSlash(name))
+}
+
+// groupMembers parses /etc/group into the supplementary members of each group
+func groupMembers(data string) map[string][]string {
+	members := map[string][]string{}
+	for _, line := range strings.Split(data, "\n") {
+		// name:password:gid:user1,user2
+		fields := strings.Split(strings.TrimSpace(line), ":")
+		if len(fields) != 4 || fields[3] == "" {
+			continue
+		}
+		members[fields[0]] = strings.Split(fields[3], ",")
+	}
+	return members
+}
+
+// uniqueFields returns the distinct whitespace-separated words in s, in order
+func uniqueFields(s string) []string {
+	var unique []string
+	for _, field := range strings.Fields(s) {
+		if !contains(unique, field) {
+			unique = append(unique, field)
+		}
+	}
+	return unique
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/HubbleNetwork/hubble-install/internal/runner"
+)
+
+// idHandler scripts "id -un" and "id -Gn"
+func idHandler(user, groups string) runner.Handler {
+	return func(f *runner.Fake, cmd *runner.Command) error {
+		switch strings.Join(cmd.Args, " ") {
+		case "-un":
+			fmt.Fprintln(cmd.Stdout, user)
+		case "-Gn":
+			fmt.Fprintln(cmd.Stdout, groups)
+		}
+		return nil
+	}
+}
+
+func TestLinuxRebootChecker(t *testing.T) {
+	const group = "root:x:0:\ndialout:x:20:maker\nplugdev:x:46:maker\n"
+
+	tests := []struct {
+		name        string
+		root        string
+		setup       func(f *runner.Fake)
+		want        string // Substring of the error; empty for no error
+		sessionOnly bool
+	}{
+		{
+			name:  "nothing pending",
+			setup: func(f *runner.Fake) {},
+		},
+		{
+			name: "reboot-required lists unique packages",
+			setup: func(f *runner.Fake) {
+				f.AddFile("/var/run/reboot-required", []byte("*** System restart required ***\n"))
+				f.AddFile("/var/run/reboot-required.pkgs", []byte("linux-image-6.8.0\nlibc6\nlibc6\n"))
+			},
+			want: "pending reboot detected (updated packages: linux-image-6.8.0, libc6)",
+		},
+		{
+			name: "reboot-required without packages",
+			setup: func(f *runner.Fake) {
+				f.AddFile("/var/run/reboot-required", nil)
+			},
+			want: "pending reboot detected (system updates)",
+		},
+		{
+			name: "needs-restarting exit 1",
+			setup: func(f *runner.Fake) {
+				f.AddCommand("needs-restarting", "/usr/bin/needs-restarting")
+				f.Handle("needs-restarting", func(*runner.Fake, *runner.Command) error {
+					return &runner.ExitError{Code: 1}
+				})
+			},
+			want: "core libraries or kernel updated",
+		},
+		{
+			name: "needs-restarting exit 0",
+			setup: func(f *runner.Fake) {
+				f.AddCommand("needs-restarting", "/usr/bin/needs-restarting")
+			},
+		},
+		{
+			name: "group not active in this session",
+			setup: func(f *runner.Fake) {
+				f.AddCommand("id", "/usr/bin/id")
+				f.Handle("id", idHandler("maker", "maker plugdev"))
+				f.AddFile("/etc/group", []byte(group))
+			},
+			want:        "added to the dialout group",
+			sessionOnly: true,
+		},
+		{
+			name: "groups already active",
+			setup: func(f *runner.Fake) {
+				f.AddCommand("id", "/usr/bin/id")
+				f.Handle("id", idHandler("maker", "maker dialout plugdev"))
+				f.AddFile("/etc/group", []byte(group))
+			},
+		},
+		{
+			name: "files are read below the root",
+			root: "/scratch",
+			setup: func(f *runner.Fake) {
+				f.AddFile("/var/run/reboot-required", nil)
+				f.AddFile("/scratch/var/run/reboot-required", nil)
+				f.AddFile("/scratch/var/run/reboot-required.pkgs", []byte("linux-image-6.8.0\n"))
+			},
+			want: "updated packages: linux-image-6.8.0",
+		},
+		{
+			name: "real system files are ignored below a root",
+			root: "/scratch",
+			setup: func(f *runner.Fake) {
+				f.AddFile("/var/run/reboot-required", nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := runner.NewFake()
+			tt.setup(f)
+
+			err := newLinuxRebootChecker(f, tt.root).CheckPendingReboot(context.Background())
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("CheckPendingReboot() = %v, want nil", err)
+				}
+				return
+			}
+
+			var rebootErr *RebootRequiredError
+			if !errors.As(err, &rebootErr) {
+				t.Fatalf("CheckPendingReboot() = %v, want a RebootRequiredError", err)
+			}
+			if !strings.Contains(rebootErr.Message, tt.want) {
+				t.Errorf("message = %q, want it to contain %q", rebootErr.Message, tt.want)
+			}
+			if rebootErr.SessionOnly != tt.sessionOnly {
+				t.Errorf("SessionOnly = %v, want %v", rebootErr.SessionOnly, tt.sessionOnly)
+			}
+		})
+	}
+}
+
+func TestDarwinRebootChecker(t *testing.T) {
+	const header = "2 extension(s)\n--- com.apple.system_extension.driver_extension\nenabled\tactive\tteamID\tbundleID (version)\tname\t[state]\n"
+	tests := []struct {
+		name string
+		list string // Output of "systemextensionsctl list"; empty when the tool is missing
+		want string
+	}{
+		{name: "tool missing"},
+		{
+			name: "extensions active",
+			list: header + "*\t*\tABCDE12345\tcom.segger.jlink.driver (7.94/1)\tJ-Link\t[activated enabled]\n",
+		},
+		{
+			name: "extension waiting for a reboot",
+			list: header +
+				"*\t*\tABCDE12345\tcom.segger.jlink.driver (7.94/1)\tJ-Link\t[activated enabled]\n" +
+				"\t\tABCDE12345\tcom.segger.jlink.driver (7.92/1)\tJ-Link\t[terminated waiting to uninstall on reboot]\n",
+			want: "system extension waiting for a reboot: com.segger.jlink.driver",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := runner.NewFake()
+			if tt.list != "" {
+				f.AddCommand("systemextensionsctl", "/usr/bin/systemextensionsctl")
+				f.Handle("systemextensionsctl", func(f *runner.Fake, cmd *runner.Command) error {
+					_, err := io.WriteString(cmd.Stdout, tt.list)
+					return err
+				})
+			}
+
+			err := darwinRebootChecker{f}.CheckPendingReboot(context.Background())
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("CheckPendingReboot() = %v, want nil", err)
+				}
+				return
+			}
+			var rebootErr *RebootRequiredError
+			if !errors.As(err, &rebootErr) || !strings.Contains(rebootErr.Message, tt.want) || rebootErr.SessionOnly {
+				t.Fatalf("CheckPendingReboot() = %v, want a reboot for %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -1,33 +1,34 @@
 package platform
 
 import (
+	"context"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/HubbleNetwork/hubble-install/internal/installed"
+	"github.com/HubbleNetwork/hubble-install/internal/pinned"
+	"github.com/HubbleNetwork/hubble-install/internal/retry"
+	"github.com/HubbleNetwork/hubble-install/internal/runner"
 	"github.com/HubbleNetwork/hubble-install/internal/ui"
 )
 
-// WindowsInstaller implements the Installer interface for Windows
-type WindowsInstaller struct{}
-
-// RebootRequiredError is returned when a system reboot is required
-type RebootRequiredError struct {
-	Message string
-}
-
-func (e *RebootRequiredError) Error() string {
-	return e.Message
+// WindowsInstaller implements the Installer interface for Windows. Flashing
+// and hex generation use the shared hubbledemo implementation with
+// Windows-specific uv discovery and network troubleshooting.
+type WindowsInstaller struct {
+	hubbledemoTool
+	jlinkProbes
+	run runner.Runner
 }
 
 // NewWindowsInstaller creates a new Windows installer
-func NewWindowsInstaller() *WindowsInstaller {
-	return &WindowsInstaller{}
+func NewWindowsInstaller(r runner.Runner) *WindowsInstaller {
+	w := &WindowsInstaller{jlinkProbes: jlinkProbes{r, "JLink.exe"}, run: r}
+	w.hubbledemoTool = hubbledemoTool{run: r, findUV: w.locateUV, explainFailure: explainNetworkFailure}
+	return w
 }
 
 // Name returns the platform name
@@ -36,7 +37,7 @@ func (w *WindowsInstaller) Name() string {
 }
 
 // CheckPendingReboot checks if Windows has a pending reboot
-func (w *WindowsInstaller) CheckPendingReboot() error {
+func (w *WindowsInstaller) CheckPendingReboot(ctx context.Context) error {
 	// Use PowerShell to check for pending reboot indicators
 	// This is more reliable than checking registry directly and works cross-platform
 	psScript := `
@@ -77,8 +78,8 @@ func (w *WindowsInstaller) CheckPendingReboot() error {
 		}
 	`
 
-	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", psScript)
-	output, err := cmd.Output()
+	cmd := &runner.Command{Name: "powershell", Args: []string{"-NoProfile", "-NonInteractive", "-Command", psScript}}
+	output, err := w.run.Output(ctx, cmd)
 	if err != nil {
 		// If PowerShell fails, assume no reboot is pending
 		// This prevents blocking installation if PowerShell has issues
@@ -97,10 +98,10 @@ func (w *WindowsInstaller) CheckPendingReboot() error {
 }
 
 // ensureAdminAccess checks if running with administrator privileges
-func (w *WindowsInstaller) ensureAdminAccess() error {
+func (w *WindowsInstaller) ensureAdminAccess(ctx context.Context) error {
 	// Check if we have admin rights by trying to access a protected registry key
-	cmd := exec.Command("net", "session")
-	if err := cmd.Run(); err != nil {
+	cmd := &runner.Command{Name: "net", Args: []string{"session"}}
+	if err := w.run.Run(ctx, cmd); err != nil {
 		ui.PrintError("Administrator access required")
 		ui.PrintInfo("Please run this installer as Administrator:")
 		ui.PrintInfo("  Right-click the executable and select 'Run as administrator'")
@@ -110,7 +111,7 @@ func (w *WindowsInstaller) ensureAdminAccess() error {
 }
 
 // CheckPrerequisites checks for missing dependencies based on required deps
-func (w *WindowsInstaller) CheckPrerequisites(requiredDeps []string) ([]MissingDependency, error) {
+func (w *WindowsInstaller) CheckPrerequisites(ctx context.Context, requiredDeps []string) ([]MissingDependency, error) {
 	var missing []MissingDependency
 
 	// Check for Chocolatey (always required for installing other deps)
@@ -145,65 +146,22 @@ func (w *WindowsInstaller) CheckPrerequisites(requiredDeps []string) ([]MissingD
 	return missing, nil
 }
 
-// downloadFile downloads a file from a URL to a destination path with progress indication
-func (w *WindowsInstaller) downloadFile(url, destPath string) error {
-	ui.PrintInfo(fmt.Sprintf("Downloading from %s...", url))
-
-	// Create the file
-	out, err := os.Create(destPath)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
-	}
-	defer out.Close()
-
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 10 * time.Minute,
-	}
-
-	// Get the data
-	resp, err := client.Get(url)
-	if err != nil {
-		return fmt.Errorf("failed to download: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check server response
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("bad status: %s", resp.Status)
-	}
-
-	// Write the body to file
-	_, err = io.Copy(out, resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to save file: %w", err)
-	}
-
-	ui.PrintSuccess("Download complete")
-	return nil
-}
-
 // installJLinkFromSEGGER downloads and installs J-Link from SEGGER's official installer
-func (w *WindowsInstaller) installJLinkFromSEGGER() error {
+func (w *WindowsInstaller) installJLinkFromSEGGER(ctx context.Context) error {
 	ui.PrintInfo("Installing SEGGER J-Link from official installer...")
 	ui.PrintInfo("This may take a few minutes...")
 
-	// Use a recent stable version
-	// Format: https://www.segger.com/downloads/jlink/JLink_Windows_V794l.exe
-	jlinkVersion := "V794l" // Update this periodically
-	jlinkURL := fmt.Sprintf("https://www.segger.com/downloads/jlink/JLink_Windows_%s.exe", jlinkVersion)
-
 	// Create temp directory for download
-	tempDir := filepath.Join(os.TempDir(), "hubble-jlink-install")
-	if err := os.MkdirAll(tempDir, 0755); err != nil {
+	tempDir := filepath.Join(w.run.TempDir(), "hubble-jlink-install")
+	if err := w.run.MkdirAll(tempDir, 0755); err != nil {
 		return fmt.Errorf("failed to create temp directory: %w", err)
 	}
-	defer os.RemoveAll(tempDir) // Clean up after installation
+	defer w.run.RemoveAll(tempDir) // Clean up after installation
 
 	installerPath := filepath.Join(tempDir, "JLink_Installer.exe")
 
-	// Download the installer
-	if err := w.downloadFile(jlinkURL, installerPath); err != nil {
+	// Download the installer pinned in the download manifest
+	if err := pinned.Download(ctx, w.run, pinned.JLinkWindows, installerPath); err != nil {
 		ui.PrintWarning("Failed to download J-Link installer automatically")
 		ui.PrintInfo("You can download it manually from: https://www.segger.com/downloads/jlink/")
 		return fmt.Errorf("download failed: %w", err)
@@ -216,15 +174,13 @@ func (w *WindowsInstaller) installJLinkFromSEGGER() error {
 	ui.PrintInfo("Accepting SEGGER license agreement automatically...")
 
 	// Method 1: NSIS-style with license acceptance
-	cmd := exec.Command(installerPath, "/S", "/ACCEPTLICENSE=yes")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd := &runner.Command{Name: installerPath, Args: []string{"/S", "/ACCEPTLICENSE=yes"}, Stdout: os.Stdout, Stderr: os.Stderr}
 
-	if err := cmd.Run(); err != nil {
+	if err := w.run.Run(ctx, cmd); err != nil {
 		// Method 1 failed, try Method 2: Alternative flags
 		ui.PrintWarning("First installation method failed, trying alternative...")
-		cmd = exec.Command(installerPath, "/q", "/norestart", "ACCEPTLICENSE=yes")
-		if err2 := cmd.Run(); err2 != nil {
+		cmd = &runner.Command{Name: installerPath, Args: []string{"/q", "/norestart", "ACCEPTLICENSE=yes"}}
+		if err2 := w.run.Run(ctx, cmd); err2 != nil {
 			// Both methods failed
 			ui.PrintError("Silent installation failed")
 			ui.PrintInfo("The installer may require manual intervention")
@@ -246,65 +202,76 @@ func (w *WindowsInstaller) installJLinkFromSEGGER() error {
 	maxWaitTime := 60 * time.Second
 	checkInterval := 2 * time.Second
 	elapsed := time.Duration(0)
-	installed := false
+	found := false
 
 	for elapsed < maxWaitTime {
 		for _, path := range jlinkPaths {
-			if _, err := os.Stat(path); err == nil {
-				installed = true
+			if _, err := w.run.Stat(path); err == nil {
+				found = true
 				// Add to PATH for current process
 				jlinkDir := filepath.Dir(path)
-				currentPath := os.Getenv("PATH")
+				currentPath := w.run.Getenv("PATH")
 				if !strings.Contains(currentPath, jlinkDir) {
-					os.Setenv("PATH", jlinkDir+";"+currentPath)
+					w.run.Setenv("PATH", jlinkDir+";"+currentPath)
 				}
 				break
 			}
 		}
 
-		if installed {
+		if found {
 			break
 		}
 
-		time.Sleep(checkInterval)
+		if err := w.run.Sleep(ctx, checkInterval); err != nil {
+			return fmt.Errorf("J-Link installation interrupted: %w", err)
+		}
 		elapsed += checkInterval
 	}
 
-	if !installed {
+	if !found {
 		return fmt.Errorf("J-Link installation completed but JLink.exe not found in expected locations after %v", maxWaitTime)
 	}
 
+	installed.Note("segger-jlink", installed.MethodManual, "")
 	ui.PrintSuccess("SEGGER J-Link installed successfully")
 	return nil
 }
 
 // InstallPackageManager installs Chocolatey if not present
-func (w *WindowsInstaller) InstallPackageManager() error {
+func (w *WindowsInstaller) InstallPackageManager(ctx context.Context) error {
 	if w.commandExists("choco") {
 		ui.PrintSuccess("Chocolatey already installed")
 		return nil
 	}
 
 	// Ensure we have admin access
-	if err := w.ensureAdminAccess(); err != nil {
+	if err := w.ensureAdminAccess(ctx); err != nil {
 		return err
 	}
 
 	ui.PrintInfo("Installing Chocolatey...")
 	ui.PrintInfo("This may take a few minutes...")
 
-	// Run the official Chocolatey installation script
+	// Run the official Chocolatey installation script once its checksum is verified
 	// Using PowerShell with execution policy bypass for the installation
-	installScript := `Set-ExecutionPolicy Bypass -Scope Process -Force; [System.Net.ServicePointManager]::SecurityProtocol = [System.Net.ServicePointManager]::SecurityProtocol -bor 3072; iex ((New-Object System.Net.WebClient).DownloadString('https://community.chocolatey.org/install.ps1'))`
+	script, cleanup, err := pinned.DownloadTemp(ctx, w.run, pinned.ChocolateyInstall)
+	if err != nil {
+		return fmt.Errorf("failed to download the Chocolatey install script: %w", err)
+	}
+	defer cleanup()
 
-	cmd := exec.Command("powershell", "-NoProfile", "-ExecutionPolicy", "Bypass", "-Command", installScript)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd := &runner.Command{
+		Name:   "powershell",
+		Args:   []string{"-NoProfile", "-ExecutionPolicy", "Bypass", "-File", script},
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
 
-	if err := cmd.Run(); err != nil {
+	if err := w.run.Run(ctx, cmd); err != nil {
 		return fmt.Errorf("failed to install Chocolatey: %w", err)
 	}
+	installed.Note("Chocolatey", installed.MethodChocolateyScript, "")
 
 	// Add Chocolatey to PATH for this process
 	if err := w.setupChocoPath(); err != nil {
@@ -317,8 +284,8 @@ func (w *WindowsInstaller) InstallPackageManager() error {
 	}
 
 	// Test choco with a simple command to ensure it's functional
-	testCmd := exec.Command("choco", "--version")
-	if err := testCmd.Run(); err != nil {
+	testCmd := &runner.Command{Name: "choco", Args: []string{"--version"}}
+	if err := w.run.Run(ctx, testCmd); err != nil {
 		return fmt.Errorf("chocolatey installed but not functioning correctly: %w", err)
 	}
 
@@ -327,16 +294,16 @@ func (w *WindowsInstaller) InstallPackageManager() error {
 }
 
 // InstallDependencies installs the specified dependencies
-func (w *WindowsInstaller) InstallDependencies(deps []string) error {
+func (w *WindowsInstaller) InstallDependencies(ctx context.Context, deps []string) error {
 	// First ensure Chocolatey is installed
 	if !w.commandExists("choco") {
-		if err := w.InstallPackageManager(); err != nil {
+		if err := w.InstallPackageManager(ctx); err != nil {
 			return err
 		}
 	}
 
 	// Ensure we have admin access for package installation
-	if err := w.ensureAdminAccess(); err != nil {
+	if err := w.ensureAdminAccess(ctx); err != nil {
 		return err
 	}
 
@@ -349,11 +316,12 @@ func (w *WindowsInstaller) InstallDependencies(deps []string) error {
 				ui.PrintSuccess("uv already installed")
 			} else {
 				ui.PrintInfo("Installing uv...")
-				if err := w.runChocoInstall("uv", true); err != nil {
+				if err := w.runChocoInstall(ctx, "uv", true); err != nil {
 					return fmt.Errorf("failed to install uv: %w", err)
 				}
+				installed.Note("uv", installed.MethodChoco, "")
 				// Update PATH to include uv location
-				if err := w.setupUVPath(); err != nil {
+				if err := w.setupUVPath(ctx); err != nil {
 					ui.PrintWarning(fmt.Sprintf("Could not update PATH for uv: %v", err))
 				}
 				ui.PrintSuccess("uv installed successfully")
@@ -366,7 +334,7 @@ func (w *WindowsInstaller) InstallDependencies(deps []string) error {
 			}
 
 			ui.PrintInfo("Installing Nordic nrfutil (standalone binary)...")
-			if err := w.installNRFUtil(); err != nil {
+			if err := w.installNRFUtil(ctx); err != nil {
 				return fmt.Errorf("failed to install nrfutil: %w", err)
 			}
 			ui.PrintSuccess("nrfutil installed successfully")
@@ -376,13 +344,10 @@ func (w *WindowsInstaller) InstallDependencies(deps []string) error {
 	return nil
 }
 
-// FlashBoard flashes the specified board using uvx (for J-Link boards)
-func (w *WindowsInstaller) FlashBoard(orgID, apiToken, board, deviceName string) (*FlashResult, error) {
-	ui.PrintInfo(fmt.Sprintf("Flashing board: %s", board))
-	ui.PrintInfo("This may take 10-15 seconds...")
-
-	// Try to find uv executable
-	uvPath, err := w.findUVPath()
+// locateUV finds uv for the shared hubbledemo implementation, explaining
+// the stale PATH problem common right after a Chocolatey install
+func (w *WindowsInstaller) locateUV(ctx context.Context) (string, error) {
+	uvPath, err := w.findUVPath(ctx)
 	if err != nil {
 		fmt.Println()
 		ui.PrintError("Could not locate the 'uv' executable")
@@ -398,155 +363,52 @@ func (w *WindowsInstaller) FlashBoard(orgID, apiToken, board, deviceName string)
 		fmt.Println()
 		ui.PrintInfo("If that doesn't work, try rebooting your computer and running again.")
 		fmt.Println()
-		return nil, fmt.Errorf("uv executable not found: %w", err)
-	}
-
-	// Build the command with --refresh to prevent stale versions
-	args := []string{"tool", "run", "--refresh", "--from", "pyhubbledemo", "hubbledemo", "flash", board, "-o", orgID, "-t", apiToken}
-	if deviceName != "" {
-		args = append(args, "-n", deviceName)
-	}
-	cmd := exec.Command(uvPath, args...)
-
-	cmd.Env = append(os.Environ(), "PYTHONWARNINGS=ignore")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		// Check if this is a network-related error
-		errStr := err.Error()
-		if strings.Contains(errStr, "dns error") ||
-			strings.Contains(errStr, "No such host") ||
-			strings.Contains(errStr, "client error") ||
-			strings.Contains(errStr, "Failed to download") {
-			fmt.Println()
-			ui.PrintError("Network connectivity error during flashing")
-			fmt.Println()
-			ui.PrintInfo("The flashing tool failed to download required files from the internet.")
-			fmt.Println()
-			ui.PrintInfo("Possible causes:")
-			ui.PrintInfo("  • Network connectivity issues")
-			ui.PrintInfo("  • Corporate firewall or proxy blocking GitHub")
-			ui.PrintInfo("  • DNS resolution problems")
-			ui.PrintInfo("  • Antivirus or security software blocking downloads")
-			fmt.Println()
-			ui.PrintInfo("Troubleshooting steps:")
-			ui.PrintInfo("  1. Check your internet connection")
-			ui.PrintInfo("  2. Try accessing https://github.com in a browser")
-			ui.PrintInfo("  3. If behind a corporate firewall, configure proxy settings:")
-			ui.PrintInfo("     $env:HTTP_PROXY = 'http://proxy.company.com:8080'")
-			ui.PrintInfo("     $env:HTTPS_PROXY = 'http://proxy.company.com:8080'")
-			ui.PrintInfo("  4. Temporarily disable antivirus/firewall and try again")
-			ui.PrintInfo("  5. Try again in a few minutes (GitHub may be temporarily unavailable)")
-			fmt.Println()
-		}
-		return nil, fmt.Errorf("flash command failed: %w", err)
-	}
-
-	resultDeviceName := deviceName
-	if resultDeviceName == "" {
-		resultDeviceName = "your-device"
+		return "", fmt.Errorf("uv executable not found: %w", err)
 	}
-
-	ui.PrintSuccess(fmt.Sprintf("Board %s flashed successfully!", board))
-	return &FlashResult{DeviceName: resultDeviceName}, nil
+	return uvPath, nil
 }
 
-// GenerateHexFile generates a hex file for Uniflash boards (TI)
-func (w *WindowsInstaller) GenerateHexFile(orgID, apiToken, board, deviceName string) (*FlashResult, error) {
-	ui.PrintInfo(fmt.Sprintf("Generating hex file for board: %s", board))
-	ui.PrintInfo("This may take a few seconds...")
-
-	// Try to find uv executable
-	uvPath, err := w.findUVPath()
-	if err != nil {
-		fmt.Println()
-		ui.PrintError("Could not locate the 'uv' executable")
-		fmt.Println()
-		ui.PrintInfo("This usually happens because:")
-		ui.PrintInfo("  1. The PATH environment variable hasn't been updated in this session")
-		ui.PrintInfo("  2. A system reboot may be required")
-		fmt.Println()
-		ui.PrintInfo("To fix this:")
-		ui.PrintInfo("  1. Close this terminal/PowerShell window")
-		ui.PrintInfo("  2. Open a NEW terminal/PowerShell window")
-		ui.PrintInfo("  3. Run this installer again")
-		fmt.Println()
-		ui.PrintInfo("If that doesn't work, try rebooting your computer and running again.")
-		fmt.Println()
-		return nil, fmt.Errorf("uv executable not found: %w", err)
-	}
-
-	// Determine hex file path in current working directory
-	currentDir, err := os.Getwd()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get current directory: %w", err)
-	}
-
-	// Use device name for filename if provided, otherwise use board name
-	filename := board + ".hex"
-	if deviceName != "" {
-		filename = deviceName + ".hex"
-	}
-	hexFilePath := filepath.Join(currentDir, filename)
-
-	// Build the command with --refresh to prevent stale versions and -f for output file
-	args := []string{"tool", "run", "--refresh", "--from", "pyhubbledemo", "hubbledemo", "flash", board, "-o", orgID, "-t", apiToken, "-f", hexFilePath}
-	if deviceName != "" {
-		args = append(args, "-n", deviceName)
-	}
-	cmd := exec.Command(uvPath, args...)
-
-	cmd.Env = append(os.Environ(), "PYTHONWARNINGS=ignore")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		// Check if this is a network-related error
-		errStr := err.Error()
-		if strings.Contains(errStr, "dns error") ||
-			strings.Contains(errStr, "No such host") ||
-			strings.Contains(errStr, "client error") ||
-			strings.Contains(errStr, "Failed to download") {
-			fmt.Println()
-			ui.PrintError("Network connectivity error during hex file generation")
-			fmt.Println()
-			ui.PrintInfo("The tool failed to download required files from the internet.")
-			fmt.Println()
-			ui.PrintInfo("Possible causes:")
-			ui.PrintInfo("  • Network connectivity issues")
-			ui.PrintInfo("  • Corporate firewall or proxy blocking GitHub")
-			ui.PrintInfo("  • DNS resolution problems")
-			ui.PrintInfo("  • Antivirus or security software blocking downloads")
-			fmt.Println()
-			ui.PrintInfo("Troubleshooting steps:")
-			ui.PrintInfo("  1. Check your internet connection")
-			ui.PrintInfo("  2. Try accessing https://github.com in a browser")
-			ui.PrintInfo("  3. If behind a corporate firewall, configure proxy settings:")
-			ui.PrintInfo("     $env:HTTP_PROXY = 'http://proxy.company.com:8080'")
-			ui.PrintInfo("     $env:HTTPS_PROXY = 'http://proxy.company.com:8080'")
-			ui.PrintInfo("  4. Temporarily disable antivirus/firewall and try again")
-			ui.PrintInfo("  5. Try again in a few minutes (GitHub may be temporarily unavailable)")
-			fmt.Println()
-		}
-		return nil, fmt.Errorf("command failed: %w", err)
-	}
-
-	return &FlashResult{HexFilePath: hexFilePath}, nil
+// explainNetworkFailure prints troubleshooting steps when hubbledemo failed
+// because of the network, which firewalls and antivirus often cause on Windows
+func explainNetworkFailure(action string, err error) {
+	if !retry.IsNetwork(err) {
+		return
+	}
+
+	fmt.Println()
+	ui.PrintError(fmt.Sprintf("Network connectivity error during %s", action))
+	fmt.Println()
+	ui.PrintInfo("The tool failed to download required files from the internet.")
+	fmt.Println()
+	ui.PrintInfo("Possible causes:")
+	ui.PrintInfo("  • Network connectivity issues")
+	ui.PrintInfo("  • Corporate firewall or proxy blocking GitHub")
+	ui.PrintInfo("  • DNS resolution problems")
+	ui.PrintInfo("  • Antivirus or security software blocking downloads")
+	fmt.Println()
+	ui.PrintInfo("Troubleshooting steps:")
+	ui.PrintInfo("  1. Check your internet connection")
+	ui.PrintInfo("  2. Try accessing https://github.com in a browser")
+	ui.PrintInfo("  3. If behind a corporate firewall, pass your proxy settings:")
+	ui.PrintInfo("     hubble-install --proxy http://proxy.company.com:8080")
+	ui.PrintInfo("     (add --ca-bundle corp-ca.pem if your network inspects TLS traffic)")
+	ui.PrintInfo("  4. Temporarily disable antivirus/firewall and try again")
+	ui.PrintInfo("  5. Try again in a few minutes (GitHub may be temporarily unavailable)")
+	fmt.Println()
 }
 
 // Helper functions
 
 // commandExists checks if a command is available in PATH
 func (w *WindowsInstaller) commandExists(cmd string) bool {
-	_, err := exec.LookPath(cmd)
+	_, err := w.run.LookPath(cmd)
 	return err == nil
 }
 
 // setupChocoPath adds Chocolatey to PATH for the current process
 func (w *WindowsInstaller) setupChocoPath() error {
 	// Get Chocolatey install path from environment variable
-	chocoInstall := os.Getenv("ChocolateyInstall")
+	chocoInstall := w.run.Getenv("ChocolateyInstall")
 	if chocoInstall == "" {
 		// Fall back to default location
 		chocoInstall = `C:\ProgramData\chocolatey`
@@ -554,24 +416,24 @@ func (w *WindowsInstaller) setupChocoPath() error {
 
 	chocoPath := filepath.Join(chocoInstall, "bin")
 
-	if _, err := os.Stat(chocoPath); os.IsNotExist(err) {
+	if _, err := w.run.Stat(chocoPath); os.IsNotExist(err) {
 		return fmt.Errorf("choco not found in expected location: %s", chocoPath)
 	}
 
 	// Update PATH for this process
-	currentPath := os.Getenv("PATH")
+	currentPath := w.run.Getenv("PATH")
 	if !strings.Contains(currentPath, chocoPath) {
 		newPath := chocoPath + ";" + currentPath
-		os.Setenv("PATH", newPath)
+		w.run.Setenv("PATH", newPath)
 	}
 
 	return nil
 }
 
 // runChocoInstall runs a choco install command using the full path to choco.exe
-func (w *WindowsInstaller) runChocoInstall(pkg string, showOutput bool) error {
+func (w *WindowsInstaller) runChocoInstall(ctx context.Context, pkg string, showOutput bool) error {
 	// Get Chocolatey install path from environment variable
-	chocoInstall := os.Getenv("ChocolateyInstall")
+	chocoInstall := w.run.Getenv("ChocolateyInstall")
 	if chocoInstall == "" {
 		chocoInstall = `C:\ProgramData\chocolatey`
 	}
@@ -579,7 +441,9 @@ func (w *WindowsInstaller) runChocoInstall(pkg string, showOutput bool) error {
 	// Use full path to avoid PATH lookup issues after fresh Chocolatey install
 	chocoExe := filepath.Join(chocoInstall, "bin", "choco.exe")
 
-	cmd := exec.Command(chocoExe, "install", pkg, "-y")
+	// choco reads the proxy from the chocolateyProxy* variables set by
+	// netconfig.Configure, which keeps the proxy password off the command line
+	cmd := &runner.Command{Name: chocoExe, Args: []string{"install", pkg, "-y"}}
 
 	// Show output if requested
 	if showOutput {
@@ -587,12 +451,12 @@ func (w *WindowsInstaller) runChocoInstall(pkg string, showOutput bool) error {
 		cmd.Stderr = os.Stderr
 	}
 
-	err := cmd.Run()
+	err := w.run.Run(ctx, cmd)
 	if err != nil {
 		// Exit code 3010 means "success, but reboot required"
 		// This is a special case that requires user action
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			if exitErr.ExitCode() == 3010 {
+		if code, ok := runner.ExitCode(err); ok {
+			if code == 3010 {
 				return &RebootRequiredError{
 					Message: fmt.Sprintf("installation of %s requires a system reboot", pkg),
 				}
@@ -605,31 +469,31 @@ func (w *WindowsInstaller) runChocoInstall(pkg string, showOutput bool) error {
 }
 
 // findUVPath attempts to locate the uv executable using multiple methods
-func (w *WindowsInstaller) findUVPath() (string, error) {
+func (w *WindowsInstaller) findUVPath(ctx context.Context) (string, error) {
 	// Method 1: Try standard PATH lookup
-	if uvPath, err := exec.LookPath("uv"); err == nil {
+	if uvPath, err := w.run.LookPath("uv"); err == nil {
 		return uvPath, nil
 	}
 
 	// Method 2: Check Chocolatey bin directory (where shims are)
-	chocoInstall := os.Getenv("ChocolateyInstall")
+	chocoInstall := w.run.Getenv("ChocolateyInstall")
 	if chocoInstall == "" {
 		chocoInstall = `C:\ProgramData\chocolatey`
 	}
 
 	chocoBin := filepath.Join(chocoInstall, "bin", "uv.exe")
-	if _, err := os.Stat(chocoBin); err == nil {
+	if _, err := w.run.Stat(chocoBin); err == nil {
 		return chocoBin, nil
 	}
 
 	// Method 3: Search Chocolatey lib directory for uv installation
-	cmd := exec.Command("powershell", "-NoProfile", "-Command",
-		fmt.Sprintf(`$uvLib = Get-ChildItem -Path "%s\lib" -Filter "uv*" -Directory | Select-Object -First 1; if ($uvLib) { $uvExe = Get-ChildItem -Path $uvLib.FullName -Filter "uv.exe" -Recurse | Select-Object -First 1; if ($uvExe) { Write-Output $uvExe.FullName } }`, chocoInstall))
-	output, err := cmd.Output()
+	cmd := &runner.Command{Name: "powershell", Args: []string{"-NoProfile", "-Command",
+		fmt.Sprintf(`$uvLib = Get-ChildItem -Path "%s\lib" -Filter "uv*" -Directory | Select-Object -First 1; if ($uvLib) { $uvExe = Get-ChildItem -Path $uvLib.FullName -Filter "uv.exe" -Recurse | Select-Object -First 1; if ($uvExe) { Write-Output $uvExe.FullName } }`, chocoInstall)}}
+	output, err := w.run.Output(ctx, cmd)
 	if err == nil && len(output) > 0 {
 		uvPath := strings.TrimSpace(string(output))
 		if uvPath != "" {
-			if _, err := os.Stat(uvPath); err == nil {
+			if _, err := w.run.Stat(uvPath); err == nil {
 				return uvPath, nil
 			}
 		}
@@ -637,12 +501,12 @@ func (w *WindowsInstaller) findUVPath() (string, error) {
 
 	// Method 4: Check common installation locations
 	commonPaths := []string{
-		filepath.Join(os.Getenv("LOCALAPPDATA"), "Programs", "uv", "uv.exe"),
-		filepath.Join(os.Getenv("USERPROFILE"), ".local", "bin", "uv.exe"),
+		filepath.Join(w.run.Getenv("LOCALAPPDATA"), "Programs", "uv", "uv.exe"),
+		filepath.Join(w.run.Getenv("USERPROFILE"), ".local", "bin", "uv.exe"),
 	}
 
 	for _, path := range commonPaths {
-		if _, err := os.Stat(path); err == nil {
+		if _, err := w.run.Stat(path); err == nil {
 			return path, nil
 		}
 	}
@@ -651,9 +515,9 @@ func (w *WindowsInstaller) findUVPath() (string, error) {
 }
 
 // setupUVPath adds uv to PATH for the current process after Chocolatey installation
-func (w *WindowsInstaller) setupUVPath() error {
+func (w *WindowsInstaller) setupUVPath(ctx context.Context) error {
 	// Get Chocolatey install path from environment variable
-	chocoInstall := os.Getenv("ChocolateyInstall")
+	chocoInstall := w.run.Getenv("ChocolateyInstall")
 	if chocoInstall == "" {
 		// Fall back to default location
 		chocoInstall = `C:\ProgramData\chocolatey`
@@ -661,17 +525,17 @@ func (w *WindowsInstaller) setupUVPath() error {
 
 	// Find uv tools directory using PowerShell
 	// Get-ChildItem -Path "$env:ChocolateyInstall\lib" | Where-Object Name -Like "uv*"
-	cmd := exec.Command("powershell", "-NoProfile", "-Command",
-		fmt.Sprintf(`(Get-ChildItem -Path "%s\lib" | Where-Object Name -Like "uv*" | Select-Object -First 1).FullName`, chocoInstall))
-	output, err := cmd.Output()
+	cmd := &runner.Command{Name: "powershell", Args: []string{"-NoProfile", "-Command",
+		fmt.Sprintf(`(Get-ChildItem -Path "%s\lib" | Where-Object Name -Like "uv*" | Select-Object -First 1).FullName`, chocoInstall)}}
+	output, err := w.run.Output(ctx, cmd)
 	if err == nil && len(output) > 0 {
 		uvLibPath := strings.TrimSpace(string(output))
 		if uvLibPath != "" {
 			uvToolsPath := filepath.Join(uvLibPath, "tools")
-			if _, err := os.Stat(uvToolsPath); err == nil {
-				currentPath := os.Getenv("PATH")
+			if _, err := w.run.Stat(uvToolsPath); err == nil {
+				currentPath := w.run.Getenv("PATH")
 				if !strings.Contains(currentPath, uvToolsPath) {
-					os.Setenv("PATH", uvToolsPath+";"+currentPath)
+					w.run.Setenv("PATH", uvToolsPath+";"+currentPath)
 				}
 			}
 		}
@@ -679,9 +543,9 @@ func (w *WindowsInstaller) setupUVPath() error {
 
 	// Also ensure Chocolatey bin is in PATH (where shims live)
 	chocoBin := filepath.Join(chocoInstall, "bin")
-	currentPath := os.Getenv("PATH")
+	currentPath := w.run.Getenv("PATH")
 	if !strings.Contains(currentPath, chocoBin) {
-		os.Setenv("PATH", chocoBin+";"+currentPath)
+		w.run.Setenv("PATH", chocoBin+";"+currentPath)
 	}
 
 	return nil
@@ -693,8 +557,8 @@ func (w *WindowsInstaller) nrfutilInstalled() bool {
 		return true
 	}
 
-	defaultPath := filepath.Join(os.Getenv("LOCALAPPDATA"), "hubble", "nrfutil", "nrfutil.exe")
-	if _, err := os.Stat(defaultPath); err == nil {
+	defaultPath := filepath.Join(w.run.Getenv("LOCALAPPDATA"), "hubble", "nrfutil", "nrfutil.exe")
+	if _, err := w.run.Stat(defaultPath); err == nil {
 		_ = w.ensureNRFUtilPath()
 		return true
 	}
@@ -704,27 +568,27 @@ func (w *WindowsInstaller) nrfutilInstalled() bool {
 
 // ensureNRFUtilPath adds the default nrfutil install location to PATH for this process
 func (w *WindowsInstaller) ensureNRFUtilPath() error {
-	defaultDir := filepath.Join(os.Getenv("LOCALAPPDATA"), "hubble", "nrfutil")
-	currentPath := os.Getenv("PATH")
+	defaultDir := filepath.Join(w.run.Getenv("LOCALAPPDATA"), "hubble", "nrfutil")
+	currentPath := w.run.Getenv("PATH")
 	if !strings.Contains(strings.ToLower(currentPath), strings.ToLower(defaultDir)) {
-		os.Setenv("PATH", defaultDir+";"+currentPath)
+		w.run.Setenv("PATH", defaultDir+";"+currentPath)
 	}
 	return nil
 }
 
 // installNRFUtil downloads the official nrfutil binary and ensures it's available
-func (w *WindowsInstaller) installNRFUtil() error {
-	url := "https://developer.nordicsemi.com/.pc-tools/nrfutil/x64-win/nrfutil.exe"
-	destDir := filepath.Join(os.Getenv("LOCALAPPDATA"), "hubble", "nrfutil")
-	if err := os.MkdirAll(destDir, 0755); err != nil {
+func (w *WindowsInstaller) installNRFUtil(ctx context.Context) error {
+	destDir := filepath.Join(w.run.Getenv("LOCALAPPDATA"), "hubble", "nrfutil")
+	if err := w.run.MkdirAll(destDir, 0755); err != nil {
 		return fmt.Errorf("failed to create nrfutil directory: %w", err)
 	}
 
 	destPath := filepath.Join(destDir, "nrfutil.exe")
 
-	if err := w.downloadFile(url, destPath); err != nil {
+	if err := pinned.Download(ctx, w.run, pinned.NRFUtilWindows, destPath); err != nil {
 		return fmt.Errorf("failed to download nrfutil: %w", err)
 	}
+	installed.Note("nrfutil", installed.MethodDownload, destDir)
 
 	// Add to PATH for current process
 	if err := w.ensureNRFUtilPath(); err != nil {
@@ -732,8 +596,8 @@ func (w *WindowsInstaller) installNRFUtil() error {
 	}
 
 	// Verify it runs
-	cmd := exec.Command(destPath, "--version")
-	if err := cmd.Run(); err != nil {
+	cmd := &runner.Command{Name: destPath, Args: []string{"--version"}}
+	if err := w.run.Run(ctx, cmd); err != nil {
 		return fmt.Errorf("nrfutil download completed but binary did not run: %w", err)
 	}
 
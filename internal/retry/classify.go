@@ -0,0 +1,223 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// failure describes how far a network operation got before it failed
+type failure int
+
+const (
+	notNetwork failure = iota
+	notSent            // The request never reached the server, or was rejected unprocessed
+	inFlight           // The connection broke or timed out after the request may have been sent
+)
+
+// Output patterns printed by uv, Python requests and urllib3 for network
+// failures. Matching is case-insensitive.
+var (
+	notSentPatterns = []string{
+		"dns error", "failed to lookup address", "no such host", "name or service not known",
+		"temporary failure in name resolution", "nodename nor servname", "getaddrinfo failed",
+		"connection refused", "network is unreachable", "no route to host",
+		"failed to establish a new connection", "newconnectionerror",
+		"429 too many requests",
+	}
+	inFlightPatterns = []string{
+		"connection reset", "connection aborted", "broken pipe", "remotedisconnected",
+		"timed out", "operation timed out", "readtimeout", "unexpected eof", "incompleteread",
+		"error sending request", "failed to download", "failed to fetch",
+		"502 bad gateway", "503 service unavailable", "504 gateway timeout",
+	}
+)
+
+// StatusError is an unexpected HTTP response status
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("bad status: %s", e.Status)
+}
+
+// OutputError carries the error output of a failed command so the failure
+// can be classified from what the command printed
+type OutputError struct {
+	Err    error
+	Output string
+	// Started is set when the command reported starting the operation a
+	// Capture watches for (see Capture.StartAt) before it failed
+	Started bool
+}
+
+func (e *OutputError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *OutputError) Unwrap() error {
+	return e.Err
+}
+
+// Classify treats every network failure as transient. Use it for operations
+// that are safe to repeat.
+func Classify(err error) Class {
+	if classifyFailure(err) == notNetwork {
+		return Permanent
+	}
+	return Transient
+}
+
+// ClassifyRegistration only retries failures that happened before the
+// request reached the server; anything later is ambiguous. A command that
+// failed after reporting the start of registration is always ambiguous,
+// whatever its output says.
+func ClassifyRegistration(err error) Class {
+	var outErr *OutputError
+	if errors.As(err, &outErr) && outErr.Started {
+		return Ambiguous
+	}
+	switch classifyFailure(err) {
+	case notSent:
+		return Transient
+	case inFlight:
+		return Ambiguous
+	}
+	return Permanent
+}
+
+func classifyFailure(err error) failure {
+	if err == nil {
+		return notNetwork
+	}
+
+	var status *StatusError
+	if errors.As(err, &status) {
+		switch status.StatusCode {
+		case http.StatusTooManyRequests:
+			return notSent
+		case http.StatusRequestTimeout, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return inFlight
+		}
+		return notNetwork
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return notSent
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ENETUNREACH) || errors.Is(err, syscall.EHOSTUNREACH) {
+		return notSent
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return notSent
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return inFlight
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return inFlight
+	}
+
+	var outErr *OutputError
+	if errors.As(err, &outErr) {
+		return classifyOutput(outErr.Output)
+	}
+	return notNetwork
+}
+
+// classifyOutput looks for network failures in a command's error output
+func classifyOutput(output string) failure {
+	lower := strings.ToLower(output)
+	// A connection that broke mid-request outweighs earlier failed lookups
+	for _, pattern := range inFlightPatterns {
+		if strings.Contains(lower, pattern) {
+			return inFlight
+		}
+	}
+	for _, pattern := range notSentPatterns {
+		if strings.Contains(lower, pattern) {
+			return notSent
+		}
+	}
+	return notNetwork
+}
+
+// captureLimit is how much of a command's most recent error output is kept
+const captureLimit = 16 * 1024
+
+// Capture passes a command's error output through to w while keeping the
+// most recent part of it for classification
+type Capture struct {
+	w       io.Writer
+	mu      sync.Mutex
+	buf     []byte
+	markers []string
+	started bool
+}
+
+// NewCapture returns a Capture writing through to w, which may be nil
+func NewCapture(w io.Writer) *Capture {
+	return &Capture{w: w}
+}
+
+// StartAt makes the capture watch for any of markers (matched
+// case-insensitively), which the command prints when it starts an operation
+// that must not be repeated. Output before the marker is discarded and the
+// resulting OutputError is marked Started.
+func (c *Capture) StartAt(markers ...string) *Capture {
+	for _, marker := range markers {
+		c.markers = append(c.markers, strings.ToLower(marker))
+	}
+	return c
+}
+
+func (c *Capture) Write(p []byte) (int, error) {
+	c.record(p)
+	if c.w == nil {
+		return len(p), nil
+	}
+	return c.w.Write(p)
+}
+
+// record appends p to the captured output
+func (c *Capture) record(p []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.buf = append(c.buf, p...)
+	if !c.started {
+		lower := strings.ToLower(string(c.buf))
+		for _, marker := range c.markers {
+			if i := strings.LastIndex(lower, marker); i >= 0 {
+				c.started = true
+				c.buf = c.buf[i+len(marker):]
+				break
+			}
+		}
+	}
+	if len(c.buf) > captureLimit {
+		c.buf = c.buf[len(c.buf)-captureLimit:]
+	}
+}
+
+// Err attaches the captured output to a command error and resets the
+// capture for the next attempt. It returns nil when err is nil.
+func (c *Capture) Err(err error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	output, started := string(c.buf), c.started
+	c.buf, c.started = nil, false
+	if err == nil {
+		return nil
+	}
+	return &OutputError{Err: err, Output: output, Started: started}
+}
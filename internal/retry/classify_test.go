@@ -0,0 +1,74 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestClassifyRegistration(t *testing.T) {
+	exit := errors.New("exit status 1")
+	tests := []struct {
+		name   string
+		stderr string // Writes are separated by "|"
+		want   Class
+	}{
+		{name: "lookup failure", stderr: "Failed to establish a new connection: Name or service not known", want: Transient},
+		{name: "connection reset", stderr: "ConnectionResetError(104, 'Connection reset by peer')", want: Ambiguous},
+		{name: "tool error", stderr: "Error: no J-Link probe found", want: Permanent},
+		{name: "lookup failure after the marker", stderr: "Starting hubbledemo\nName or service not known", want: Ambiguous},
+		{name: "tool error after the marker", stderr: "starting hubbledemo\nError: no J-Link probe found", want: Ambiguous},
+		{name: "marker split across writes", stderr: "Start|ing hubbledemo\n|Name or service not known", want: Ambiguous},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			capture := NewCapture(nil).StartAt("Starting hubbledemo")
+			for _, part := range strings.Split(tt.stderr, "|") {
+				io.WriteString(capture, part)
+			}
+
+			if got := ClassifyRegistration(capture.Err(exit)); got != tt.want {
+				t.Errorf("ClassifyRegistration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCaptureResetsBetweenAttempts(t *testing.T) {
+	capture := NewCapture(nil).StartAt("Starting hubbledemo")
+	fmt.Fprintln(capture, "Starting hubbledemo")
+	if err := capture.Err(nil); err != nil {
+		t.Fatalf("Err(nil) = %v", err)
+	}
+
+	fmt.Fprintln(capture, "Name or service not known")
+	err := capture.Err(errors.New("exit status 1"))
+	var outErr *OutputError
+	if !errors.As(err, &outErr) {
+		t.Fatalf("Err() = %v, want an OutputError", err)
+	}
+	if outErr.Started {
+		t.Error("Started carried over from the previous attempt")
+	}
+	if got := ClassifyRegistration(err); got != Transient {
+		t.Errorf("ClassifyRegistration() = %v, want %v", got, Transient)
+	}
+}
+
+func TestCaptureDropsOutputBeforeMarker(t *testing.T) {
+	capture := NewCapture(nil).StartAt("Starting hubbledemo")
+	fmt.Fprintln(capture, "connection reset while downloading")
+	fmt.Fprintln(capture, "Starting hubbledemo")
+	fmt.Fprintln(capture, "Error: device name taken")
+
+	var outErr *OutputError
+	if !errors.As(capture.Err(errors.New("exit status 1")), &outErr) {
+		t.Fatal("want an OutputError")
+	}
+	if !outErr.Started || strings.Contains(outErr.Output, "connection reset") {
+		t.Errorf("OutputError = %+v, want only the output after the marker", outErr)
+	}
+}
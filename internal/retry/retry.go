@@ -0,0 +1,149 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/HubbleNetwork/hubble-install/internal/ui"
+)
+
+// Class is the final classification of a failed operation
+type Class int
+
+const (
+	// Permanent failures would fail again: bad credentials, unknown board, tool errors
+	Permanent Class = iota
+	// Transient failures are network problems that are safe to retry
+	Transient
+	// Ambiguous failures may have taken effect on the server (e.g. a request
+	// that timed out after being sent), so repeating them is not safe
+	Ambiguous
+)
+
+// String describes the class for error messages
+func (c Class) String() string {
+	switch c {
+	case Transient:
+		return "network error"
+	case Ambiguous:
+		return "outcome unknown"
+	default:
+		return "not retryable"
+	}
+}
+
+// Sleeper waits between attempts; runner.Runner implements it so tests don't wait
+type Sleeper interface {
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// Policy controls how often and how quickly an operation is retried
+type Policy struct {
+	Attempts int           // Total attempts, including the first
+	Initial  time.Duration // Wait before the second attempt
+	Max      time.Duration // Upper bound on a single wait
+	Classify func(error) Class
+}
+
+// Download is used for file downloads and package resolution, which are
+// safe to repeat whatever happened to the previous attempt
+var Download = Policy{
+	Attempts: 4,
+	Initial:  2 * time.Second,
+	Max:      20 * time.Second,
+	Classify: Classify,
+}
+
+// Registration is used for steps that create a device in the Hubble
+// organization. Only failures that prove the request never reached the
+// server are retried.
+var Registration = Policy{
+	Attempts: 3,
+	Initial:  3 * time.Second,
+	Max:      20 * time.Second,
+	Classify: ClassifyRegistration,
+}
+
+// Error is returned when an operation fails for good
+type Error struct {
+	Op       string
+	Attempts int
+	Class    Class
+	Err      error
+}
+
+func (e *Error) Error() string {
+	switch e.Class {
+	case Transient:
+		return fmt.Sprintf("%v (gave up after %d attempts)", e.Err, e.Attempts)
+	case Ambiguous:
+		return fmt.Sprintf("%v (the request may have reached the server, so it was not retried)", e.Err)
+	}
+	if e.Attempts > 1 {
+		return fmt.Sprintf("%v (attempt %d)", e.Err, e.Attempts)
+	}
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ClassOf returns the classification of an error returned by Do, or
+// classifies err directly if it did not come from Do
+func ClassOf(err error) Class {
+	var retryErr *Error
+	if errors.As(err, &retryErr) {
+		return retryErr.Class
+	}
+	return Classify(err)
+}
+
+// IsNetwork reports whether err was caused by the network
+func IsNetwork(err error) bool {
+	return ClassOf(err) != Permanent
+}
+
+// Do runs fn until it succeeds, fails with an error the policy doesn't
+// retry, runs out of attempts or ctx is cancelled. op names the operation
+// in progress messages (e.g. "nrfutil download").
+func (p Policy) Do(ctx context.Context, s Sleeper, op string, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+
+		class := Permanent
+		if ctx.Err() == nil {
+			class = p.Classify(err)
+		}
+		if class != Transient || attempt >= p.Attempts {
+			return &Error{Op: op, Attempts: attempt, Class: class, Err: err}
+		}
+
+		wait := p.delay(attempt)
+		ui.PrintWarning(fmt.Sprintf("%s failed: %v", op, err))
+		ui.PrintInfo(fmt.Sprintf("Retrying in %s (attempt %d of %d)...", wait.Round(100*time.Millisecond), attempt+1, p.Attempts))
+		if err := s.Sleep(ctx, wait); err != nil {
+			return &Error{Op: op, Attempts: attempt, Class: Permanent, Err: err}
+		}
+	}
+}
+
+// delay returns the exponential backoff before the given retry, spread by
+// ±12.5% so machines on the same network don't retry in lockstep
+func (p Policy) delay(attempt int) time.Duration {
+	d := p.Initial
+	for i := 1; i < attempt && d < p.Max; i++ {
+		d *= 2
+	}
+	if d > p.Max {
+		d = p.Max
+	}
+	jitter := time.Duration(rand.Int63n(int64(d)/4 + 1))
+	return d - d/8 + jitter
+}
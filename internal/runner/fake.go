@@ -0,0 +1,371 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Call records one command executed through a Fake
+type Call struct {
+	Name string // Base name of the executable, without extension
+	Path string // Name exactly as passed to the runner
+	Args []string
+	Env  []string
+}
+
+// String formats the call like a shell command line
+func (c Call) String() string {
+	return strings.TrimSpace(c.Name + " " + strings.Join(c.Args, " "))
+}
+
+// Handler scripts the behavior of a faked command. Anything written to
+// cmd.Stdout is returned by Output.
+type Handler func(f *Fake, cmd *Command) error
+
+// ExitError is returned by scripted commands that exit with a non-zero code
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// ExitCode returns the scripted exit code
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
+
+// Response is a canned HTTP response served by a Fake
+type Response struct {
+	Status int
+	Body   []byte
+	Err    error
+}
+
+// Fake is an in-memory Runner that records every call. Commands without a
+// handler succeed with no output; executables are found by LookPath only once
+// added with AddCommand.
+type Fake struct {
+	mu       sync.Mutex
+	paths    map[string]string
+	env      map[string]string
+	files    map[string][]byte
+	dirs     map[string]bool
+	urls     map[string]Response
+	handlers map[string]Handler
+	calls    []Call
+	sleeps   time.Duration
+	workDir  string
+}
+
+// NewFake creates an empty Fake
+func NewFake() *Fake {
+	return &Fake{
+		paths:    map[string]string{},
+		env:      map[string]string{},
+		files:    map[string][]byte{},
+		dirs:     map[string]bool{},
+		urls:     map[string]Response{},
+		handlers: map[string]Handler{},
+		workDir:  "/work",
+	}
+}
+
+// AddCommand makes name resolvable by LookPath
+func (f *Fake) AddCommand(name, path string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.paths[name] = path
+}
+
+// RemoveCommand makes name unresolvable by LookPath
+func (f *Fake) RemoveCommand(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.paths, name)
+}
+
+// AddFile creates a file visible to Stat and ReadFile
+func (f *Fake) AddFile(path string, data []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.files[path] = data
+}
+
+// File returns the contents of a file created through the Fake
+func (f *Fake) File(path string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, ok := f.files[path]
+	return data, ok
+}
+
+// AddURL serves a canned response for url
+func (f *Fake) AddURL(url string, resp Response) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.urls[url] = resp
+}
+
+// Handle scripts the behavior of the command with the given base name
+func (f *Fake) Handle(name string, h Handler) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handlers[name] = h
+}
+
+// SetWorkDir sets the directory returned by Getwd
+func (f *Fake) SetWorkDir(dir string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.workDir = dir
+}
+
+// Calls returns every command run so far, in order
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// CallsTo returns the calls made to the command with the given base name
+func (f *Fake) CallsTo(name string) []Call {
+	var matched []Call
+	for _, call := range f.Calls() {
+		if call.Name == name {
+			matched = append(matched, call)
+		}
+	}
+	return matched
+}
+
+// Slept returns the total time passed to Sleep
+func (f *Fake) Slept() time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.sleeps
+}
+
+// Env returns a sorted snapshot of the variables set through Setenv
+func (f *Fake) Env() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var env []string
+	for key, value := range f.env {
+		env = append(env, key+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// Run records the command and invokes its handler. Cancelled contexts fail
+// without recording, as a real command would never start.
+func (f *Fake) Run(ctx context.Context, cmd *Command) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	name := baseName(cmd.Name)
+
+	f.mu.Lock()
+	f.calls = append(f.calls, Call{
+		Name: name,
+		Path: cmd.Name,
+		Args: append([]string(nil), cmd.Args...),
+		Env:  append([]string(nil), cmd.Env...),
+	})
+	handler := f.handlers[name]
+	f.mu.Unlock()
+
+	if handler == nil {
+		return nil
+	}
+	return handler(f, cmd)
+}
+
+// Output records the command and returns what its handler wrote to stdout
+func (f *Fake) Output(ctx context.Context, cmd *Command) ([]byte, error) {
+	var stdout bytes.Buffer
+	captured := *cmd
+	captured.Stdout = &stdout
+	err := f.Run(ctx, &captured)
+	return stdout.Bytes(), err
+}
+
+// LookPath resolves commands registered with AddCommand
+func (f *Fake) LookPath(file string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if path, ok := f.paths[file]; ok {
+		return path, nil
+	}
+	return "", fmt.Errorf("exec: %q: executable file not found in $PATH", file)
+}
+
+// Getenv reads a variable set through Setenv
+func (f *Fake) Getenv(key string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.env[key]
+}
+
+// Setenv sets a variable in the fake environment
+func (f *Fake) Setenv(key, value string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.env[key] = value
+	return nil
+}
+
+// Stat reports files added with AddFile or Create and directories from MkdirAll
+func (f *Fake) Stat(path string) (os.FileInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if data, ok := f.files[path]; ok {
+		return fakeFileInfo{name: filepath.Base(path), size: int64(len(data))}, nil
+	}
+	if f.dirs[path] {
+		return fakeFileInfo{name: filepath.Base(path), dir: true}, nil
+	}
+	return nil, &fs.PathError{Op: "stat", Path: path, Err: fs.ErrNotExist}
+}
+
+// ReadFile returns the contents of a fake file
+func (f *Fake) ReadFile(path string) ([]byte, error) {
+	data, ok := f.File(path)
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+	return data, nil
+}
+
+// Create returns a writer whose contents are stored when closed
+func (f *Fake) Create(path string) (io.WriteCloser, error) {
+	f.AddFile(path, nil)
+	return &fakeFile{fake: f, path: path}, nil
+}
+
+// MkdirAll records the directory
+func (f *Fake) MkdirAll(path string, perm os.FileMode) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.dirs[path] = true
+	return nil
+}
+
+// RemoveAll deletes the path and everything below it
+func (f *Fake) RemoveAll(path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	prefix := path + string(filepath.Separator)
+	for p := range f.files {
+		if p == path || strings.HasPrefix(p, prefix) {
+			delete(f.files, p)
+		}
+	}
+	for p := range f.dirs {
+		if p == path || strings.HasPrefix(p, prefix) {
+			delete(f.dirs, p)
+		}
+	}
+	return nil
+}
+
+// Getwd returns the directory set with SetWorkDir
+func (f *Fake) Getwd() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.workDir, nil
+}
+
+// TempDir returns a fixed temporary directory
+func (f *Fake) TempDir() string {
+	return "/tmp"
+}
+
+// Get serves responses registered with AddURL; unknown URLs return 404
+func (f *Fake) Get(ctx context.Context, url string, timeout time.Duration) (*http.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	resp, ok := f.urls[url]
+	f.mu.Unlock()
+
+	if !ok {
+		resp = Response{Status: http.StatusNotFound}
+	}
+	if resp.Err != nil {
+		return nil, resp.Err
+	}
+	if resp.Status == 0 {
+		resp.Status = http.StatusOK
+	}
+	return &http.Response{
+		StatusCode: resp.Status,
+		Status:     fmt.Sprintf("%d %s", resp.Status, http.StatusText(resp.Status)),
+		Body:       io.NopCloser(bytes.NewReader(resp.Body)),
+	}, nil
+}
+
+// Sleep records the duration without blocking
+func (f *Fake) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sleeps += d
+	return nil
+}
+
+// baseName strips directories and the Windows executable extension
+func baseName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	return strings.TrimSuffix(strings.TrimSuffix(name, ".exe"), ".EXE")
+}
+
+// fakeFile buffers writes until Close stores them in the Fake
+type fakeFile struct {
+	fake *Fake
+	path string
+	buf  bytes.Buffer
+}
+
+func (w *fakeFile) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *fakeFile) Close() error {
+	w.fake.AddFile(w.path, w.buf.Bytes())
+	return nil
+}
+
+// fakeFileInfo is the os.FileInfo returned by Fake.Stat
+type fakeFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (i fakeFileInfo) Name() string { return i.name }
+func (i fakeFileInfo) Size() int64  { return i.size }
+func (i fakeFileInfo) Mode() os.FileMode {
+	if i.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+func (i fakeFileInfo) ModTime() time.Time { return time.Time{} }
+func (i fakeFileInfo) IsDir() bool        { return i.dir }
+func (i fakeFileInfo) Sys() any           { return nil }
@@ -0,0 +1,23 @@
+//go:build !windows
+
+package runner
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// configureKill makes cancellation stop the command and, when it runs in its
+// own process group, everything it started
+func configureKill(cmd *exec.Cmd, ownGroup bool) {
+	if !ownGroup {
+		// Ctrl-C reaches foreground children directly; only the child itself
+		// needs stopping on a timeout or SIGTERM
+		return
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		// A negative PID signals the whole process group
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
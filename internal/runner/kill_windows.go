@@ -0,0 +1,20 @@
+//go:build windows
+
+package runner
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+// configureKill makes cancellation stop the command and every process it
+// started, using taskkill to walk the process tree
+func configureKill(cmd *exec.Cmd, ownGroup bool) {
+	cmd.Cancel = func() error {
+		kill := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid))
+		if err := kill.Run(); err != nil {
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+}
@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Command describes a subprocess to run. Commands without Stdin run in their
+// own process group so cancellation stops everything they started; commands
+// reading the terminal stay in the foreground group so they can prompt.
+type Command struct {
+	Name   string
+	Args   []string
+	Env    []string // Extra KEY=VALUE pairs added to the inherited environment
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Runner provides the operating system services the platform installers
+// depend on: subprocesses, executable lookup, environment, filesystem and HTTP.
+// Installers never call os/exec or net/http directly so they can be driven by
+// a Fake in tests. Operations that can block take a context; when it is
+// cancelled running commands are killed together with their children.
+type Runner interface {
+	// Run runs the command and waits for it to finish
+	Run(ctx context.Context, cmd *Command) error
+
+	// Output runs the command and returns its standard output
+	Output(ctx context.Context, cmd *Command) ([]byte, error)
+
+	// LookPath searches PATH for an executable
+	LookPath(file string) (string, error)
+
+	// Getenv and Setenv read and modify the process environment
+	Getenv(key string) string
+	Setenv(key, value string) error
+
+	// Filesystem access
+	Stat(path string) (os.FileInfo, error)
+	ReadFile(path string) ([]byte, error)
+	Create(path string) (io.WriteCloser, error)
+	MkdirAll(path string, perm os.FileMode) error
+	RemoveAll(path string) error
+	Getwd() (string, error)
+	TempDir() string
+
+	// Get performs an HTTP GET with the configured network settings
+	Get(ctx context.Context, url string, timeout time.Duration) (*http.Response, error)
+
+	// Sleep pauses while polling for an external process. It returns early
+	// with the context's error when cancelled.
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// ExitCode returns the exit code carried by a command error, if any
+func ExitCode(err error) (int, bool) {
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,163 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/HubbleNetwork/hubble-install/internal/logging"
+	"github.com/HubbleNetwork/hubble-install/internal/netconfig"
+)
+
+// System is the Runner backed by the real operating system
+type System struct{}
+
+// New returns the Runner backed by the real operating system
+func New() Runner {
+	return System{}
+}
+
+// command converts a Command into an exec.Cmd. Output is also copied to the
+// log; the returned flush function records any trailing partial lines.
+func (System) command(ctx context.Context, c *Command) (*exec.Cmd, func()) {
+	cmd := exec.CommandContext(ctx, c.Name, c.Args...)
+	configureKill(cmd, c.Stdin == nil)
+	// Don't wait forever for grandchildren holding the output pipes open
+	cmd.WaitDelay = 5 * time.Second
+	if len(c.Env) > 0 {
+		cmd.Env = append(os.Environ(), c.Env...)
+	}
+
+	stdoutLog := logging.NewLineWriter(logging.KindStdout)
+	stderrLog := logging.NewLineWriter(logging.KindStderr)
+	cmd.Stdin = c.Stdin
+	cmd.Stdout = tee(c.Stdout, stdoutLog)
+	cmd.Stderr = tee(c.Stderr, stderrLog)
+
+	return cmd, func() {
+		stdoutLog.Flush()
+		stderrLog.Flush()
+	}
+}
+
+// tee writes to w (if set) and to the log
+func tee(w io.Writer, log io.Writer) io.Writer {
+	if w == nil {
+		return log
+	}
+	return io.MultiWriter(w, log)
+}
+
+// Run runs the command and waits for it to finish
+func (s System) Run(ctx context.Context, c *Command) error {
+	cmd, flush := s.command(ctx, c)
+	logging.Command(c.Name, c.Args)
+	start := time.Now()
+
+	err := cmd.Run()
+	flush()
+	if ctxErr := ctx.Err(); ctxErr != nil && err != nil {
+		// Report why the command was killed rather than "signal: killed"
+		err = fmt.Errorf("%s: %w", filepath.Base(c.Name), ctxErr)
+	}
+	logging.Exit(c.Name, time.Since(start), err)
+	return err
+}
+
+// Output runs the command and returns its standard output
+func (s System) Output(ctx context.Context, c *Command) ([]byte, error) {
+	var stdout bytes.Buffer
+	captured := *c
+	captured.Stdout = &stdout
+
+	// Keep stderr for the caller's error message, as exec.Cmd.Output does
+	var stderr bytes.Buffer
+	if captured.Stderr == nil {
+		captured.Stderr = &stderr
+	}
+
+	err := s.Run(ctx, &captured)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && stderr.Len() > 0 {
+		exitErr.Stderr = stderr.Bytes()
+	}
+	return stdout.Bytes(), err
+}
+
+// LookPath searches PATH for an executable
+func (System) LookPath(file string) (string, error) {
+	return exec.LookPath(file)
+}
+
+// Getenv reads an environment variable
+func (System) Getenv(key string) string {
+	return os.Getenv(key)
+}
+
+// Setenv sets an environment variable for this process and its children
+func (System) Setenv(key, value string) error {
+	return os.Setenv(key, value)
+}
+
+// Stat describes the named file
+func (System) Stat(path string) (os.FileInfo, error) {
+	return os.Stat(path)
+}
+
+// ReadFile reads the named file
+func (System) ReadFile(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+// Create creates or truncates the named file
+func (System) Create(path string) (io.WriteCloser, error) {
+	return os.Create(path)
+}
+
+// MkdirAll creates a directory and any missing parents
+func (System) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+// RemoveAll removes a path and any children
+func (System) RemoveAll(path string) error {
+	return os.RemoveAll(path)
+}
+
+// Getwd returns the current working directory
+func (System) Getwd() (string, error) {
+	return os.Getwd()
+}
+
+// TempDir returns the directory for temporary files
+func (System) TempDir() string {
+	return os.TempDir()
+}
+
+// Get performs an HTTP GET honoring proxy and CA settings
+func (System) Get(ctx context.Context, url string, timeout time.Duration) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return netconfig.Client(timeout).Do(req)
+}
+
+// Sleep pauses the current goroutine
+func (System) Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,301 @@
+package selfupdate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HubbleNetwork/hubble-install/internal/retry"
+	"github.com/HubbleNetwork/hubble-install/internal/runner"
+)
+
+// DefaultBaseURL serves the assets of the latest GitHub release
+const DefaultBaseURL = "https://github.com/HubbleNetwork/hubble-install/releases/latest/download"
+
+// BaseURLEnv overrides the release location, e.g. for a mirror or a local test server
+const BaseURLEnv = "HUBBLE_RELEASE_URL"
+
+// Files published next to the binaries of every release
+const (
+	IndexFile     = "release.json"
+	SignatureFile = "release.json.sig" // Base64 ed25519 signature of IndexFile
+)
+
+// PublicKey is the base64 ed25519 key that signs release indexes, set at build
+// time with -ldflags "-X .../internal/selfupdate.PublicKey=..."
+var PublicKey = ""
+
+// maxBinarySize bounds downloads so a bad mirror can't fill the disk
+const maxBinarySize = 200 << 20
+
+// Index describes one release. It is signed as a whole, so the version can't
+// be swapped for an older release's without invalidating the signature.
+type Index struct {
+	Version string            `json:"version"`
+	Date    string            `json:"date,omitempty"`
+	Assets  map[string]string `json:"assets"` // File name to SHA-256 (hex)
+}
+
+// AssetName returns the release file for a platform, as named by goreleaser
+func AssetName(goos, goarch string) string {
+	name := fmt.Sprintf("hubble-install-%s-%s", goos, goarch)
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// NewIndex builds the index for a release from goreleaser's checksums.txt
+func NewIndex(version string, date time.Time, checksums []byte) (*Index, error) {
+	index := &Index{
+		Version: strings.TrimPrefix(version, "v"),
+		Date:    date.UTC().Format(time.RFC3339),
+		Assets:  map[string]string{},
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		// <sha256>  <file name>
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 || len(fields[0]) != sha256.Size*2 {
+			return nil, fmt.Errorf("invalid checksums line %q", scanner.Text())
+		}
+		index.Assets[fields[1]] = strings.ToLower(fields[0])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(index.Assets) == 0 {
+		return nil, errors.New("checksums file lists no assets")
+	}
+	return index, nil
+}
+
+// Sign returns the index file contents and their signature
+func Sign(index *Index, key ed25519.PrivateKey) (data, signature []byte, err error) {
+	data, err = json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	data = append(data, '\n')
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(key, data))
+	return data, []byte(sig + "\n"), nil
+}
+
+// Verify checks the signature of an index file and parses it
+func Verify(data, signature []byte, publicKey string) (*Index, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid release signing key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return nil, errors.New("malformed release signature")
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), data, sig) {
+		return nil, errors.New("release signature does not match; the index was modified or signed with another key")
+	}
+
+	var index Index
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("invalid release index: %w", err)
+	}
+	if index.Version == "" {
+		return nil, errors.New("release index has no version")
+	}
+	return &index, nil
+}
+
+// FetchIndex downloads the release index from baseURL and verifies its signature
+func FetchIndex(ctx context.Context, r runner.Runner, baseURL string) (*Index, error) {
+	if PublicKey == "" {
+		return nil, errors.New("this build has no release signing key, so updates can't be verified")
+	}
+
+	data, err := fetch(ctx, r, baseURL+"/"+IndexFile, 1<<20)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := fetch(ctx, r, baseURL+"/"+SignatureFile, 4<<10)
+	if err != nil {
+		return nil, err
+	}
+	return Verify(data, signature, PublicKey)
+}
+
+// Download fetches the binary for the current platform into a temporary file
+// in dir and checks it against the index. The caller removes the file if it
+// is not installed.
+func Download(ctx context.Context, r runner.Runner, baseURL string, index *Index, dir string) (string, error) {
+	asset := AssetName(runtime.GOOS, runtime.GOARCH)
+	want, ok := index.Assets[asset]
+	if !ok {
+		return "", fmt.Errorf("release %s has no binary for %s/%s", index.Version, runtime.GOOS, runtime.GOARCH)
+	}
+
+	data, err := fetch(ctx, r, baseURL+"/"+asset, maxBinarySize)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return "", fmt.Errorf("checksum mismatch for %s: got %s, want %s", asset, got, want)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".hubble-install-update-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to write %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// Replace moves newPath over the executable at exe. Windows can't overwrite a
+// running executable, so the old one is renamed aside and removed on the
+// next start by RemoveStale.
+func Replace(exe, newPath string) error {
+	if runtime.GOOS == "windows" {
+		old := exe + ".old"
+		os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			return fmt.Errorf("failed to move %s aside: %w", exe, err)
+		}
+		if err := os.Rename(newPath, exe); err != nil {
+			os.Rename(old, exe)
+			return fmt.Errorf("failed to install %s: %w", exe, err)
+		}
+		return nil
+	}
+
+	if err := os.Rename(newPath, exe); err != nil {
+		return fmt.Errorf("failed to install %s: %w", exe, err)
+	}
+	return nil
+}
+
+// RemoveStale deletes the executable left behind by an update on Windows
+func RemoveStale() {
+	if runtime.GOOS != "windows" {
+		return
+	}
+	if exe, err := Executable(); err == nil {
+		os.Remove(exe + ".old")
+	}
+}
+
+// Executable returns the path of the running binary with symlinks resolved,
+// so an update replaces the file rather than the link to it
+func Executable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the running executable: %w", err)
+	}
+	return filepath.EvalSymlinks(exe)
+}
+
+// Compare orders two versions such as "0.2.0", "v0.2.0" or "0.2.1-rc1" and
+// returns -1, 0 or 1. Pre-releases sort before the release they precede.
+func Compare(a, b string) (int, error) {
+	pa, prea, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	pb, preb, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range pa {
+		if pa[i] != pb[i] {
+			if pa[i] < pb[i] {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+	switch {
+	case prea == preb:
+		return 0, nil
+	case prea == "":
+		return 1, nil
+	case preb == "":
+		return -1, nil
+	case prea < preb:
+		return -1, nil
+	}
+	return 1, nil
+}
+
+// parseVersion splits MAJOR.MINOR.PATCH[-PRERELEASE]
+func parseVersion(v string) ([3]int, string, error) {
+	var parts [3]int
+	core, pre, _ := strings.Cut(strings.TrimPrefix(v, "v"), "-")
+	fields := strings.Split(core, ".")
+	if len(fields) != 3 {
+		return parts, "", fmt.Errorf("invalid version %q", v)
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, "", fmt.Errorf("invalid version %q", v)
+		}
+		parts[i] = n
+	}
+	return parts, pre, nil
+}
+
+// fetch downloads url into memory, retrying transient network failures
+func fetch(ctx context.Context, r runner.Runner, url string, limit int64) ([]byte, error) {
+	var data []byte
+	err := retry.Download.Do(ctx, r, "Download of "+url, func(ctx context.Context) error {
+		resp, err := r.Get(ctx, url, 5*time.Minute)
+		if err != nil {
+			return fmt.Errorf("failed to download: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return &retry.StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+		}
+
+		data, err = io.ReadAll(io.LimitReader(resp.Body, limit+1))
+		if err != nil {
+			return fmt.Errorf("failed to download: %w", err)
+		}
+		if int64(len(data)) > limit {
+			return fmt.Errorf("%s is larger than expected", url)
+		}
+		return nil
+	})
+	return data, err
+}
@@ -0,0 +1,256 @@
+package selfupdate
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HubbleNetwork/hubble-install/internal/runner"
+)
+
+const testBaseURL = "https://releases.example.com/latest"
+
+// newKey returns a signing key and sets PublicKey to it for the test
+func newKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := PublicKey
+	PublicKey = base64.StdEncoding.EncodeToString(public)
+	t.Cleanup(func() { PublicKey = old })
+	return private
+}
+
+// serve publishes a signed index on a Fake
+func serve(t *testing.T, data, signature []byte) *runner.Fake {
+	t.Helper()
+	f := runner.NewFake()
+	f.AddURL(testBaseURL+"/"+IndexFile, runner.Response{Body: data})
+	f.AddURL(testBaseURL+"/"+SignatureFile, runner.Response{Body: signature})
+	return f
+}
+
+func testIndex(version string) *Index {
+	return &Index{Version: version, Assets: map[string]string{AssetName("linux", "amd64"): strings.Repeat("ab", 32)}}
+}
+
+func TestFetchIndex(t *testing.T) {
+	key := newKey(t)
+	data, signature, err := Sign(testIndex("1.2.0"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := FetchIndex(context.Background(), serve(t, data, signature), testBaseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index.Version != "1.2.0" || len(index.Assets) != 1 {
+		t.Errorf("index = %+v", index)
+	}
+}
+
+func TestFetchIndexRejected(t *testing.T) {
+	key := newKey(t)
+	_, otherKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, signature, err := Sign(testIndex("1.2.0"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, wrongSignature, err := Sign(testIndex("1.2.0"), otherKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	older, _, err := Sign(testIndex("1.1.0"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		data      []byte
+		signature []byte
+		want      string
+	}{
+		{"signed with another key", data, wrongSignature, "release signature does not match"},
+		{"version changed", bytes.Replace(data, []byte("1.2.0"), []byte("9.9.9"), 1), signature, "release signature does not match"},
+		{"older index with a newer signature", older, signature, "release signature does not match"},
+		{"asset digest changed", bytes.Replace(data, []byte("abab"), []byte("cdcd"), 1), signature, "release signature does not match"},
+		{"whitespace added", append([]byte(" "), data...), signature, "release signature does not match"},
+		{"signature cut off", data, signature[:20], "malformed release signature"},
+		{"signature not base64", data, []byte("not base64!"), "malformed release signature"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index, err := FetchIndex(context.Background(), serve(t, tt.data, tt.signature), testBaseURL)
+			if err == nil {
+				t.Fatalf("FetchIndex() = %+v, want an error containing %q", index, tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchIndexWithoutKey(t *testing.T) {
+	old := PublicKey
+	PublicKey = ""
+	defer func() { PublicKey = old }()
+
+	_, err := FetchIndex(context.Background(), runner.NewFake(), testBaseURL)
+	if err == nil || !strings.Contains(err.Error(), "no release signing key") {
+		t.Errorf("FetchIndex() = %v, want an error about the missing key", err)
+	}
+}
+
+func TestVerifyInvalidKey(t *testing.T) {
+	for _, key := range []string{"", "not base64!", base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := Verify([]byte("{}"), []byte("sig"), key); err == nil || err.Error() != "invalid release signing key" {
+			t.Errorf("Verify(key %q) = %v, want an invalid key error", key, err)
+		}
+	}
+}
+
+func TestVerifyMissingVersion(t *testing.T) {
+	key := newKey(t)
+	data, signature, err := Sign(&Index{Assets: map[string]string{}}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(data, signature, PublicKey); err == nil || !strings.Contains(err.Error(), "no version") {
+		t.Errorf("Verify() = %v, want an error about the missing version", err)
+	}
+}
+
+// A validly signed index for an older release (e.g. replayed by a mirror)
+// is not installed over a newer binary
+func TestOlderIndexRejected(t *testing.T) {
+	key := newKey(t)
+	data, signature, err := Sign(testIndex("1.1.0"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := FetchIndex(context.Background(), serve(t, data, signature), testBaseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmp, err := Compare(index.Version, "1.2.0")
+	if err != nil || cmp >= 0 {
+		t.Errorf("Compare(%s, 1.2.0) = %d, %v, want the index to be older", index.Version, cmp, err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2.4", "1.2.3", 1},
+		{"1.3.0", "1.2.9", 1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.10.0", "1.9.0", 1},
+		{"0.9.0", "1.0.0", -1},
+		{"1.0.0-rc1", "1.0.0", -1},
+		{"1.0.0", "1.0.0-rc1", 1},
+		{"1.0.0-rc1", "1.0.0-rc2", -1},
+		{"1.0.0-beta", "1.0.0-alpha", 1},
+		{"1.0.0-rc1", "1.0.0-rc1", 0},
+		{"1.0.1-rc1", "1.0.0", 1},
+		{"0.9.9", "1.0.0-rc1", -1},
+	}
+	for _, tt := range tests {
+		got, err := Compare(tt.a, tt.b)
+		if err != nil || got != tt.want {
+			t.Errorf("Compare(%q, %q) = %d, %v, want %d", tt.a, tt.b, got, err, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"dev", "1.2", "1.2.3.4", "1.x.3", "-1.0.0", ""} {
+		if _, err := Compare(bad, "1.0.0"); err == nil {
+			t.Errorf("Compare(%q, 1.0.0) accepted an invalid version", bad)
+		}
+	}
+}
+
+func TestNewIndex(t *testing.T) {
+	sum := strings.Repeat("AB", 32)
+	checksums := []byte(sum + "  hubble-install-linux-amd64\n\n" + strings.Repeat("cd", 32) + "  hubble-install-windows-amd64.exe\n")
+	index, err := NewIndex("v1.2.0", time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC), checksums)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index.Version != "1.2.0" || index.Date != "2025-06-01T12:00:00Z" || len(index.Assets) != 2 {
+		t.Errorf("index = %+v", index)
+	}
+	if got := index.Assets["hubble-install-linux-amd64"]; got != strings.ToLower(sum) {
+		t.Errorf("linux digest = %s, want it lowercased", got)
+	}
+
+	for _, bad := range []string{"", "abc  file\n", sum + "\n"} {
+		if _, err := NewIndex("1.2.0", time.Now(), []byte(bad)); err == nil {
+			t.Errorf("NewIndex(%q) accepted invalid checksums", bad)
+		}
+	}
+}
+
+func TestDownloadChecksum(t *testing.T) {
+	binary := []byte("new binary")
+	sum := sha256.Sum256(binary)
+	asset := AssetName(runtime.GOOS, runtime.GOARCH)
+
+	tests := []struct {
+		name   string
+		digest string
+		want   string
+	}{
+		{"matching digest", hex.EncodeToString(sum[:]), ""},
+		{"tampered binary", strings.Repeat("00", 32), "checksum mismatch for " + asset},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := runner.NewFake()
+			f.AddURL(testBaseURL+"/"+asset, runner.Response{Body: binary})
+			index := &Index{Version: "1.2.0", Assets: map[string]string{asset: tt.digest}}
+			dir := t.TempDir()
+
+			path, err := Download(context.Background(), f, testBaseURL, index, dir)
+			if tt.want != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.want) {
+					t.Fatalf("Download() = %v, want an error containing %q", err, tt.want)
+				}
+				if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+					t.Errorf("a rejected download left %d files behind", len(entries))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if data, _ := os.ReadFile(path); !bytes.Equal(data, binary) {
+				t.Errorf("downloaded %q, want %q", data, binary)
+			}
+		})
+	}
+
+	_, err := Download(context.Background(), runner.NewFake(), testBaseURL, &Index{Version: "1.2.0"}, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "has no binary for") {
+		t.Errorf("Download() without an asset = %v", err)
+	}
+}
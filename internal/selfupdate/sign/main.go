@@ -0,0 +1,122 @@
+// Command sign writes the signed release index that "hubble-install
+// self-update" verifies. The release workflow runs it after goreleaser:
+//
+//	go run ./internal/selfupdate/sign -version v0.2.0 -checksums dist/checksums.txt -out dist
+//
+// Before the build, it also signs the embedded manifest of pinned third-party
+// downloads, refusing one with an entry that has no digest:
+//
+//	go run ./internal/selfupdate/sign -manifest internal/pinned/manifest.json
+//
+// The private key is read from $HUBBLE_RELEASE_SIGNING_KEY. Use -keygen to
+// create a key pair; the public half is built into release binaries.
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/HubbleNetwork/hubble-install/internal/pinned"
+	"github.com/HubbleNetwork/hubble-install/internal/selfupdate"
+)
+
+// keyEnv holds the base64 ed25519 private key
+const keyEnv = "HUBBLE_RELEASE_SIGNING_KEY"
+
+func main() {
+	keygen := flag.Bool("keygen", false, "Print a new key pair and exit")
+	version := flag.String("version", "", "Release version")
+	checksums := flag.String("checksums", "dist/checksums.txt", "goreleaser checksums file")
+	out := flag.String("out", "dist", "Directory to write the index and signature to")
+	manifest := flag.String("manifest", "", "Sign this download manifest instead of writing a release index")
+	flag.Parse()
+
+	if *keygen {
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Printf("public key:  %s\n", base64.StdEncoding.EncodeToString(public))
+		fmt.Printf("private key: %s\n", base64.StdEncoding.EncodeToString(private))
+		return
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(os.Getenv(keyEnv)))
+	if err != nil || len(key) != ed25519.PrivateKeySize {
+		fail(fmt.Errorf("%s must hold a base64 ed25519 private key", keyEnv))
+	}
+	private := ed25519.PrivateKey(key)
+	public := base64.StdEncoding.EncodeToString(private.Public().(ed25519.PublicKey))
+
+	if *manifest != "" {
+		signManifest(*manifest, private, public)
+		return
+	}
+
+	if *version == "" {
+		fail(fmt.Errorf("-version is required"))
+	}
+
+	data, err := os.ReadFile(*checksums)
+	if err != nil {
+		fail(err)
+	}
+	index, err := selfupdate.NewIndex(*version, time.Now(), data)
+	if err != nil {
+		fail(err)
+	}
+	indexData, signature, err := selfupdate.Sign(index, private)
+	if err != nil {
+		fail(err)
+	}
+
+	// Catch a key that doesn't match the public key being shipped
+	if _, err := selfupdate.Verify(indexData, signature, public); err != nil {
+		fail(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(*out, selfupdate.IndexFile), indexData, 0644); err != nil {
+		fail(err)
+	}
+	if err := os.WriteFile(filepath.Join(*out, selfupdate.SignatureFile), signature, 0644); err != nil {
+		fail(err)
+	}
+	fmt.Printf("Signed %s %s (%d assets) with public key %s\n", selfupdate.IndexFile, index.Version, len(index.Assets), public)
+}
+
+// signManifest writes the signature next to a download manifest
+func signManifest(path string, private ed25519.PrivateKey, public string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fail(err)
+	}
+	m, err := pinned.Parse(data)
+	if err != nil {
+		fail(err)
+	}
+	if err := m.Check(); err != nil {
+		fail(fmt.Errorf("%s: %w; run go run ./internal/pinned/pin", path, err))
+	}
+
+	signature := pinned.Sign(data, private)
+	if err := pinned.Verify(data, signature, public); err != nil {
+		fail(err)
+	}
+	sigPath := filepath.Join(filepath.Dir(path), pinned.SignatureFile)
+	if err := os.WriteFile(sigPath, signature, 0644); err != nil {
+		fail(err)
+	}
+	fmt.Printf("Signed %s (%d artifacts) with public key %s\n", path, len(m.Artifacts), public)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
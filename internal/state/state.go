@@ -0,0 +1,130 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// formatVersion is bumped when the state file layout changes incompatibly
+const formatVersion = 1
+
+// Installation steps recorded as completed
+const (
+	StepCredentials  = "credentials"
+	StepBoard        = "board"
+	StepDependencies = "dependencies"
+)
+
+// State is the progress of an unfinished installation. It never contains
+// secrets: credentials are re-read from CredentialSource on resume.
+type State struct {
+	FormatVersion    int       `json:"format_version"`
+	SavedAt          time.Time `json:"saved_at"`
+	Completed        []string  `json:"completed"`
+	Board            string    `json:"board,omitempty"`
+	DeviceName       string    `json:"device_name,omitempty"`
+	CredentialSource string    `json:"credential_source,omitempty"`
+	RebootRequired   bool      `json:"reboot_required,omitempty"`
+}
+
+// New returns an empty state
+func New() *State {
+	return &State{FormatVersion: formatVersion}
+}
+
+// Path returns the location of the state file
+func Path() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "hubble-install", "state.json"), nil
+}
+
+// Load reads the saved state. It returns nil without an error when there is
+// no unfinished installation.
+func Load() (*State, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid state file %s: %w", path, err)
+	}
+	if s.FormatVersion != formatVersion {
+		return nil, fmt.Errorf("state file %s has unsupported format version %d", path, s.FormatVersion)
+	}
+
+	return &s, nil
+}
+
+// Save writes the state, readable only by the current user
+func (s *State) Save() error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	s.SavedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash never leaves a truncated state
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	return nil
+}
+
+// Clear removes the saved state once the installation finishes or is abandoned
+func Clear() error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove state: %w", err)
+	}
+	return nil
+}
+
+// Complete marks a step as done
+func (s *State) Complete(step string) {
+	if !s.Done(step) {
+		s.Completed = append(s.Completed, step)
+	}
+}
+
+// Done reports whether a step has been completed
+func (s *State) Done(step string) bool {
+	for _, completed := range s.Completed {
+		if completed == step {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// useTempConfig points the user config directory at a temporary directory
+// and returns the state file path
+func useTempConfig(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("AppData", home)
+
+	path, err := Path()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRoundTrip(t *testing.T) {
+	path := useTempConfig(t)
+
+	s := New()
+	s.Complete(StepCredentials)
+	s.Complete(StepBoard)
+	s.Complete(StepBoard)
+	s.Board = "nrf52840dk"
+	s.DeviceName = "bench-01"
+	s.CredentialSource = "environment"
+	s.RebootRequired = true
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save() = %v", err)
+	}
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode := info.Mode().Perm(); mode != 0600 {
+			t.Errorf("state file mode = %o, want 600", mode)
+		}
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+	if !slices.Equal(loaded.Completed, []string{StepCredentials, StepBoard}) {
+		t.Errorf("Completed = %q", loaded.Completed)
+	}
+	if !loaded.Done(StepBoard) || loaded.Done(StepDependencies) {
+		t.Errorf("Done() wrong for %q", loaded.Completed)
+	}
+	if loaded.Board != s.Board || loaded.DeviceName != s.DeviceName ||
+		loaded.CredentialSource != s.CredentialSource || !loaded.RebootRequired {
+		t.Errorf("Load() = %+v, want %+v", loaded, s)
+	}
+	if !loaded.SavedAt.Equal(s.SavedAt) || loaded.SavedAt.IsZero() {
+		t.Errorf("SavedAt = %v, want %v", loaded.SavedAt, s.SavedAt)
+	}
+
+	if err := Clear(); err != nil {
+		t.Fatalf("Clear() = %v", err)
+	}
+	if loaded, err := Load(); loaded != nil || err != nil {
+		t.Errorf("Load() after Clear() = %v, %v, want nil, nil", loaded, err)
+	}
+	if err := Clear(); err != nil {
+		t.Errorf("Clear() without a state file = %v", err)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{name: "truncated", data: `{"format_version": 1, "completed": ["cred`, want: "invalid state file"},
+		{name: "not JSON", data: "\x00\x00\x00", want: "invalid state file"},
+		{name: "wrong type", data: `{"format_version": 1, "completed": "board"}`, want: "invalid state file"},
+		{name: "newer format", data: `{"format_version": 2}`, want: "unsupported format version 2"},
+		{name: "no format version", data: `{}`, want: "unsupported format version 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := useTempConfig(t)
+			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(tt.data), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			s, err := Load()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Load() = %v, %v, want an error containing %q", s, err, tt.want)
+			}
+			if s != nil {
+				t.Errorf("Load() returned state %+v with an error", s)
+			}
+		})
+	}
+}
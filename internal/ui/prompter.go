@@ -0,0 +1,161 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// Prompter supplies the answers to interactive prompts
+type Prompter interface {
+	// ReadLine reads one line of input, including the trailing newline
+	ReadLine() (string, error)
+
+	// ReadPassword reads a secret without echoing it
+	ReadPassword() (string, error)
+}
+
+// prompter is the active input source for all Prompt* functions
+var prompter Prompter = newTerminalPrompter()
+
+// SetPrompter replaces the input source used by all prompts
+func SetPrompter(p Prompter) {
+	prompter = p
+}
+
+// terminalPrompter reads from the controlling terminal
+type terminalPrompter struct {
+	reader *bufio.Reader
+}
+
+func newTerminalPrompter() *terminalPrompter {
+	// Try to open /dev/tty for interactive input (works when piped from curl)
+	tty, err := os.Open("/dev/tty")
+	if err == nil {
+		return &terminalPrompter{reader: bufio.NewReader(tty)}
+	}
+	// Fallback to stdin if /dev/tty is not available
+	return &terminalPrompter{reader: bufio.NewReader(os.Stdin)}
+}
+
+// ReadLine reads a line from the terminal
+func (t *terminalPrompter) ReadLine() (string, error) {
+	return t.reader.ReadString('\n')
+}
+
+// ReadPassword reads a line from the terminal with echo disabled
+func (t *terminalPrompter) ReadPassword() (string, error) {
+	// Try to open /dev/tty for password input
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		// Fallback to regular input if /dev/tty not available
+		PrintWarning("Cannot access terminal, reading password as plain text")
+		return t.reader.ReadString('\n')
+	}
+	defer tty.Close()
+
+	fd := int(tty.Fd())
+
+	// Check if it's actually a terminal
+	if !term.IsTerminal(fd) {
+		// Not a terminal, fall back to regular input
+		PrintWarning("Not a terminal, reading password as plain text")
+		return t.reader.ReadString('\n')
+	}
+
+	// Remember the terminal mode so an interrupt can put echo back
+	if oldState, err := term.GetState(fd); err == nil {
+		rememberTerminal(fd, oldState)
+		defer rememberTerminal(-1, nil)
+	}
+
+	// Terminal mode - read password with masking from /dev/tty
+	bytePassword, err := term.ReadPassword(fd)
+	fmt.Fprintln(out) // Add newline after password input
+
+	return string(bytePassword), err
+}
+
+var (
+	echoMu    sync.Mutex
+	echoFd    = -1
+	echoState *term.State // Terminal mode to restore while a password is being read
+)
+
+func rememberTerminal(fd int, state *term.State) {
+	echoMu.Lock()
+	defer echoMu.Unlock()
+	echoFd, echoState = fd, state
+}
+
+// RestoreTerminal turns echo back on if the process is interrupted while
+// reading a password
+func RestoreTerminal() {
+	echoMu.Lock()
+	defer echoMu.Unlock()
+	if echoState != nil {
+		term.Restore(echoFd, echoState)
+		echoFd, echoState = -1, nil
+	}
+}
+
+// ScriptedPrompter answers prompts from a fixed list, for unattended runs
+// and end-to-end tests. Answers are echoed so transcripts read naturally;
+// secrets are masked.
+type ScriptedPrompter struct {
+	answers []string
+	next    int
+}
+
+// NewScriptedPrompter creates a prompter that returns answers in order
+func NewScriptedPrompter(answers []string) *ScriptedPrompter {
+	return &ScriptedPrompter{answers: answers}
+}
+
+// NewScriptedPrompterFromFile reads one answer per line from path
+func NewScriptedPrompterFromFile(path string) (*ScriptedPrompter, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read answers file: %w", err)
+	}
+	text := strings.TrimSuffix(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	return NewScriptedPrompter(strings.Split(text, "\n")), nil
+}
+
+// ReadLine returns the next scripted answer
+func (s *ScriptedPrompter) ReadLine() (string, error) {
+	answer, err := s.pop()
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintln(out, answer)
+	return answer + "\n", nil
+}
+
+// ReadPassword returns the next scripted answer without echoing it
+func (s *ScriptedPrompter) ReadPassword() (string, error) {
+	answer, err := s.pop()
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintln(out, "********")
+	return answer, nil
+}
+
+// Remaining returns the number of unused answers
+func (s *ScriptedPrompter) Remaining() int {
+	return len(s.answers) - s.next
+}
+
+func (s *ScriptedPrompter) pop() (string, error) {
+	if s.next >= len(s.answers) {
+		return "", fmt.Errorf("no scripted answer left (used all %d)", len(s.answers))
+	}
+	answer := s.answers[s.next]
+	s.next++
+	return answer, nil
+}
@@ -1,14 +1,14 @@
 package ui
 
 import (
-	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/HubbleNetwork/hubble-install/internal/logging"
 	"github.com/fatih/color"
-	"golang.org/x/term"
 )
 
 var (
@@ -18,8 +18,23 @@ var (
 	yellow = color.New(color.FgYellow)
 	blue   = color.New(color.FgBlue, color.Bold)
 	bold   = color.New(color.Bold)
+
+	// out receives uncolored output; colored output goes to color.Output
+	out io.Writer = os.Stdout
 )
 
+// UseStderr sends messages and prompts to standard error, keeping standard
+// output free for machine-readable results
+func UseStderr() {
+	color.Output = os.Stderr
+	out = os.Stderr
+}
+
+// PrintBlank prints an empty line
+func PrintBlank() {
+	fmt.Fprintln(out)
+}
+
 // PrintBanner prints the welcome banner
 func PrintBanner() {
 	cyan.Print(`
@@ -31,7 +46,8 @@ func PrintBanner() {
 
 // PrintStep prints a step indicator
 func PrintStep(step string, current, total int) {
-	fmt.Println()
+	logging.Record(logging.KindStep, fmt.Sprintf("[%d] %s", current, step))
+	fmt.Fprintln(out)
 	if total > 0 {
 		blue.Printf("[%d/%d] %s\n", current, total, step)
 	} else {
@@ -41,47 +57,39 @@ func PrintStep(step string, current, total int) {
 
 // PrintSuccess prints a success message
 func PrintSuccess(message string) {
+	logging.Record(logging.KindSuccess, message)
 	green.Printf("✓ %s\n", message)
 }
 
 // PrintError prints an error message
 func PrintError(message string) {
+	logging.Record(logging.KindError, message)
 	red.Printf("✗ %s\n", message)
 }
 
 // PrintWarning prints a warning message
 func PrintWarning(message string) {
+	logging.Record(logging.KindWarning, message)
 	yellow.Printf("⚠ %s\n", message)
 }
 
 // PrintInfo prints an info message
 func PrintInfo(message string) {
+	logging.Record(logging.KindInfo, message)
 	cyan.Printf("ℹ %s\n", message)
 }
 
-// Global reader for interactive input
-var stdinReader *bufio.Reader
-
-func init() {
-	// Try to open /dev/tty for interactive input (works when piped from curl)
-	tty, err := os.Open("/dev/tty")
-	if err == nil {
-		stdinReader = bufio.NewReader(tty)
-	} else {
-		// Fallback to stdin if /dev/tty is not available
-		stdinReader = bufio.NewReader(os.Stdin)
-	}
-}
-
 // PromptInput prompts the user for input
 func PromptInput(prompt string) string {
 	cyan.Printf("? %s: ", prompt)
-	input, err := stdinReader.ReadString('\n')
+	input, err := prompter.ReadLine()
 	if err != nil {
 		// If we can't read from stdin, something is seriously wrong
 		PrintError(fmt.Sprintf("Failed to read input: %v", err))
 		os.Exit(1)
 	}
+	// Free-form answers may be credentials, so only the question is logged
+	logging.Record(logging.KindPrompt, prompt)
 	return strings.TrimSpace(input)
 }
 
@@ -89,44 +97,14 @@ func PromptInput(prompt string) string {
 func PromptPassword(prompt string) string {
 	cyan.Printf("? %s: ", prompt)
 
-	// Try to open /dev/tty for password input
-	tty, err := os.Open("/dev/tty")
-	if err != nil {
-		// Fallback to regular input if /dev/tty not available
-		PrintWarning("Cannot access terminal, reading password as plain text")
-		input, err := stdinReader.ReadString('\n')
-		if err != nil {
-			PrintError(fmt.Sprintf("Failed to read password: %v", err))
-			os.Exit(1)
-		}
-		return strings.TrimSpace(input)
-	}
-	defer tty.Close()
-
-	fd := int(tty.Fd())
-
-	// Check if it's actually a terminal
-	if !term.IsTerminal(fd) {
-		// Not a terminal, fall back to regular input
-		PrintWarning("Not a terminal, reading password as plain text")
-		input, err := stdinReader.ReadString('\n')
-		if err != nil {
-			PrintError(fmt.Sprintf("Failed to read password: %v", err))
-			os.Exit(1)
-		}
-		return strings.TrimSpace(input)
-	}
-
-	// Terminal mode - read password with masking from /dev/tty
-	bytePassword, err := term.ReadPassword(fd)
-	fmt.Println() // Add newline after password input
-
+	password, err := prompter.ReadPassword()
 	if err != nil {
 		PrintError(fmt.Sprintf("Failed to read password: %v", err))
 		os.Exit(1)
 	}
+	logging.Record(logging.KindPrompt, prompt)
 
-	return string(bytePassword)
+	return strings.TrimSpace(password)
 }
 
 // PromptYesNo prompts the user for a yes/no answer
@@ -138,12 +116,13 @@ func PromptYesNo(question string, defaultYes bool) bool {
 
 	for {
 		cyan.Printf("? %s (%s): ", question, defaultStr)
-		response, err := stdinReader.ReadString('\n')
+		response, err := prompter.ReadLine()
 		if err != nil {
 			PrintError(fmt.Sprintf("Failed to read input: %v", err))
 			os.Exit(1)
 		}
 		response = strings.TrimSpace(strings.ToLower(response))
+		logging.Record(logging.KindPrompt, fmt.Sprintf("%s (%s): %s", question, defaultStr, response))
 
 		if response == "" {
 			return defaultYes
@@ -161,25 +140,43 @@ func PromptYesNo(question string, defaultYes bool) bool {
 // PromptOptionalInput prompts for optional input, returns empty string if skipped
 func PromptOptionalInput(prompt string) string {
 	cyan.Printf("? %s (Enter to skip): ", prompt)
-	response, err := stdinReader.ReadString('\n')
+	response, err := prompter.ReadLine()
 	if err != nil {
 		PrintError(fmt.Sprintf("Failed to read input: %v", err))
 		os.Exit(1)
 	}
-	return strings.TrimSpace(response)
+	response = strings.TrimSpace(response)
+	logging.Record(logging.KindPrompt, fmt.Sprintf("%s: %s", prompt, response))
+	return response
+}
+
+// PromptInputWithDefault prompts for input, returning def if the user just presses Enter
+func PromptInputWithDefault(prompt, def string) string {
+	cyan.Printf("? %s (Enter for %s): ", prompt, def)
+	response, err := prompter.ReadLine()
+	if err != nil {
+		PrintError(fmt.Sprintf("Failed to read input: %v", err))
+		os.Exit(1)
+	}
+	response = strings.TrimSpace(response)
+	logging.Record(logging.KindPrompt, fmt.Sprintf("%s: %s", prompt, response))
+	if response == "" {
+		return def
+	}
+	return response
 }
 
 // PromptChoice prompts the user to select from a list of options
 func PromptChoice(prompt string, options []string) int {
-	fmt.Println()
+	fmt.Fprintln(out)
 	cyan.Println(prompt)
 	for i, option := range options {
-		fmt.Printf("%d. %s\n", i+1, option)
+		fmt.Fprintf(out, "%d. %s\n", i+1, option)
 	}
 
 	for {
 		cyan.Printf("? Select (1-%d): ", len(options))
-		response, err := stdinReader.ReadString('\n')
+		response, err := prompter.ReadLine()
 		if err != nil {
 			PrintError(fmt.Sprintf("Failed to read input: %v", err))
 			os.Exit(1)
@@ -189,14 +186,61 @@ func PromptChoice(prompt string, options []string) int {
 		var choice int
 		_, err = fmt.Sscanf(response, "%d", &choice)
 		if err == nil && choice >= 1 && choice <= len(options) {
+			logging.Record(logging.KindPrompt, fmt.Sprintf("%s %s", prompt, options[choice-1]))
 			return choice - 1
 		}
 		PrintWarning(fmt.Sprintf("Please enter a number between 1 and %d", len(options)))
 	}
 }
 
-// PrintCompletionBanner prints the success completion banner
-func PrintCompletionBanner(duration time.Duration, orgID, apiToken, deviceName string) {
+// Check is the outcome of one post-flash verification check
+type Check struct {
+	Name   string
+	Status CheckStatus
+	Detail string
+}
+
+// CheckStatus is whether a check passed, failed or could not be run
+type CheckStatus int
+
+const (
+	CheckPassed CheckStatus = iota
+	CheckFailed
+	CheckSkipped
+)
+
+// Passed reports whether the named check ran and passed
+func Passed(checks []Check, name string) bool {
+	for _, c := range checks {
+		if c.Name == name {
+			return c.Status == CheckPassed
+		}
+	}
+	return false
+}
+
+// PrintChecks prints verification results, one line per check
+func PrintChecks(checks []Check) {
+	for _, c := range checks {
+		switch c.Status {
+		case CheckPassed:
+			green.Printf("  ✓ %s", c.Name)
+		case CheckFailed:
+			red.Printf("  ✗ %s", c.Name)
+		default:
+			yellow.Printf("  - %s", c.Name)
+		}
+		if c.Detail != "" {
+			fmt.Fprintf(out, ": %s", c.Detail)
+		}
+		fmt.Fprintln(out)
+	}
+}
+
+// PrintCompletionBanner prints the success completion banner. broadcasting
+// says whether a packet from the device was actually seen; checks are the
+// verification results, if verification ran.
+func PrintCompletionBanner(duration time.Duration, orgID, apiToken, deviceName string, broadcasting bool, checks []Check) {
 	green.Print(`
 ╔═══════════════════════════════════════════════════════════╗
 ║     ✓ Installation Complete!                              ║
@@ -204,24 +248,38 @@ func PrintCompletionBanner(duration time.Duration, orgID, apiToken, deviceName s
 `)
 
 	// Main message
-	fmt.Println()
+	fmt.Fprintln(out)
 	green.Println("✓  What's next")
-	fmt.Println()
-	fmt.Printf("  • Your device \"%s\" is now broadcasting on the Hubble Terrestrial Network\n", deviceName)
-	fmt.Println()
-	fmt.Println("  • In Sandbox, you will need the Hubble Connect mobile app to scan for device packets")
-	fmt.Println()
-	fmt.Println()
-	fmt.Println("╔══════════════════════════════════════════════════════════════════╗")
-	fmt.Println("║ Return to https://dash.hubble.com to capture device packets!     ║")
-	fmt.Println("╚══════════════════════════════════════════════════════════════════╝")
-	fmt.Println()
+	fmt.Fprintln(out)
+	device := "Your new device"
+	if deviceName != "" {
+		device = fmt.Sprintf("Your device \"%s\"", deviceName)
+	}
+	if broadcasting {
+		fmt.Fprintf(out, "  • %s is broadcasting on the Hubble Terrestrial Network\n", device)
+	} else {
+		fmt.Fprintf(out, "  • %s has been flashed and will broadcast on the Hubble Terrestrial Network once it starts up\n", device)
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "  • In Sandbox, you will need the Hubble Connect mobile app to scan for device packets")
+	fmt.Fprintln(out)
+	if len(checks) > 0 {
+		bold.Println("  Verification")
+		PrintChecks(checks)
+		fmt.Fprintln(out)
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "╔══════════════════════════════════════════════════════════════════╗")
+	fmt.Fprintln(out, "║ Return to https://dash.hubble.com to capture device packets!     ║")
+	fmt.Fprintln(out, "╚══════════════════════════════════════════════════════════════════╝")
+	fmt.Fprintln(out)
 
 	yellow.Println("Need help? Visit https://hubble.com/support/")
 }
 
-// PrintUniflashCompletionBanner prints the completion banner for TI Uniflash boards
-func PrintUniflashCompletionBanner(duration time.Duration, hexFilePath, boardName, deviceName string) {
+// PrintUniflashCompletionBanner prints the completion banner for TI Uniflash
+// boards. imageSummary describes the checked hex file, one item per line.
+func PrintUniflashCompletionBanner(duration time.Duration, hexFilePath, boardName, deviceName string, imageSummary []string) {
 	green.Print(`
 ╔═══════════════════════════════════════════════════════════╗
 ║                  ✓ Hex File Generated!                    ║
@@ -229,19 +287,22 @@ func PrintUniflashCompletionBanner(duration time.Duration, hexFilePath, boardNam
 `)
 
 	// Main message
-	fmt.Println()
+	fmt.Fprintln(out)
 	green.Println("✓  What's next")
-	fmt.Println()
-	fmt.Printf("  • Your new device is named \"%s\"\n", deviceName)
-	fmt.Println()
-	fmt.Printf("  • Your hex file for the %s has been generated:\n", boardName)
-	fmt.Println()
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "  • Your new device is named \"%s\"\n", deviceName)
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "  • Your hex file for the %s has been generated:\n", boardName)
+	fmt.Fprintln(out)
 	bold.Printf("    %s\n", hexFilePath)
-	fmt.Println()
-	fmt.Println("╔══════════════════════════════════════════════════════════════════╗")
-	fmt.Println("║ Return to https://dash.hubble.com to complete UniFlash steps!    ║")
-	fmt.Println("╚══════════════════════════════════════════════════════════════════╝")
-	fmt.Println()
+	for _, line := range imageSummary {
+		fmt.Fprintf(out, "    %s\n", line)
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "╔══════════════════════════════════════════════════════════════════╗")
+	fmt.Fprintln(out, "║ Return to https://dash.hubble.com to complete UniFlash steps!    ║")
+	fmt.Fprintln(out, "╚══════════════════════════════════════════════════════════════════╝")
+	fmt.Fprintln(out)
 
 	yellow.Println("Need help? Visit https://hubble.com/support/")
 }
@@ -270,7 +331,7 @@ func (s *Spinner) Start() {
 		for {
 			select {
 			case <-s.stop:
-				fmt.Print("\r\033[K") // Clear line
+				fmt.Fprint(out, "\r\033[K") // Clear line
 				s.done <- true
 				return
 			default:
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/HubbleNetwork/hubble-install/internal/retry"
+	"github.com/HubbleNetwork/hubble-install/internal/ui"
+)
+
+// Time limits for each installation step. A hung download, package manager or
+// flash fails its step instead of blocking an unattended machine forever.
+const (
+	timeoutCheck          = 2 * time.Minute
+	timeoutCredentials    = 20 * time.Second
+	timeoutDeviceList     = 2 * time.Minute
+	timeoutPackageManager = 30 * time.Minute
+	timeoutDependencies   = 45 * time.Minute
+	timeoutFlash          = 10 * time.Minute
+)
+
+// interruptedExitCode is the conventional exit code after Ctrl-C
+const interruptedExitCode = 130
+
+// interruptGrace is how long a cancelled run gets to kill its commands and
+// clean up before the process exits anyway (e.g. while waiting at a prompt)
+const interruptGrace = 3 * time.Second
+
+// signalContext returns a context cancelled by SIGINT or SIGTERM. Cancelling
+// kills running commands; a second Ctrl-C exits immediately.
+func signalContext() context.Context {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-ctx.Done()
+		stop()
+		ui.RestoreTerminal()
+		fmt.Println()
+		ui.PrintWarning("Interrupted, stopping...")
+
+		time.Sleep(interruptGrace)
+		exitInterrupted()
+	}()
+
+	return ctx
+}
+
+// exitIfInterrupted exits when the run was cancelled, so a Ctrl-C is not
+// reported as a failure of the step that was running
+func exitIfInterrupted(ctx context.Context) {
+	if ctx.Err() != nil {
+		exitInterrupted()
+	}
+}
+
+// interruptOnce keeps the signal watcher and the main flow from both
+// reporting the interruption
+var interruptOnce sync.Once
+
+// exitInterrupted ends a cancelled run; saved progress allows resuming
+func exitInterrupted() {
+	interruptOnce.Do(func() {
+		ui.PrintWarning("Installation interrupted")
+		ui.PrintInfo("Run the installer again to resume where it stopped.")
+		exit(interruptedExitCode)
+	})
+	// Another goroutine is already exiting
+	select {}
+}
+
+// describeStepError adds the time limit to errors caused by a step timing out
+func describeStepError(err error, limit time.Duration) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Sprintf("%v (did not finish within %s)", err, limit)
+	}
+	return err.Error()
+}
+
+// printRegistrationRetryHint tells the user whether running a failed flash
+// or hex generation again could register the device twice
+func printRegistrationRetryHint(err error) {
+	if retry.ClassOf(err) == retry.Ambiguous {
+		ui.PrintWarning("The device may already have been registered with your organization.")
+		ui.PrintInfo("Check the Hubble dashboard before running the installer again; if the device is listed, choose a different name.")
+		return
+	}
+	ui.PrintInfo("Run the installer again to retry from this step.")
+}
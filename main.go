@@ -1,18 +1,110 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"runtime"
 	"strings"
 	"time"
 
 	"github.com/HubbleNetwork/hubble-install/internal/boards"
+	"github.com/HubbleNetwork/hubble-install/internal/bundle"
 	"github.com/HubbleNetwork/hubble-install/internal/config"
+	"github.com/HubbleNetwork/hubble-install/internal/hubbleapi"
+	"github.com/HubbleNetwork/hubble-install/internal/hubbledemo"
+	"github.com/HubbleNetwork/hubble-install/internal/logging"
+	"github.com/HubbleNetwork/hubble-install/internal/netconfig"
+	"github.com/HubbleNetwork/hubble-install/internal/output"
 	"github.com/HubbleNetwork/hubble-install/internal/platform"
+	"github.com/HubbleNetwork/hubble-install/internal/selfupdate"
+	"github.com/HubbleNetwork/hubble-install/internal/state"
 	"github.com/HubbleNetwork/hubble-install/internal/ui"
 )
 
+// Build information, set at build time with -ldflags "-X main.Version=..."
+var (
+	Version = "dev"
+	Commit  = "none"
+	Date    = "unknown"
+)
+
 func main() {
+	// Clean up after an update replaced the running executable
+	selfupdate.RemoveStale()
+
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "bundle":
+			os.Exit(runBundleCommand(os.Args[2:]))
+		case "doctor":
+			os.Exit(runDoctorCommand(os.Args[2:]))
+		case "support-bundle":
+			os.Exit(runSupportBundleCommand(os.Args[2:]))
+		case "devices":
+			os.Exit(runDevicesCommand(os.Args[2:]))
+		case "uninstall":
+			os.Exit(runUninstallCommand(os.Args[2:]))
+		case "version":
+			os.Exit(runVersionCommand(os.Args[2:]))
+		case "self-update":
+			os.Exit(runSelfUpdateCommand(os.Args[2:]))
+		}
+	}
+
+	bundlePath := flag.String("bundle", "", "Install offline using a bundle created with 'hubble-install bundle create'")
+	toolVersion := flag.String("tool-version", "", "pyhubbledemo version to use (default: the version pinned in this build; \"latest\" to unpin)")
+	lockFile := flag.String("lock-file", "", "Lock file pinning the exact pyhubbledemo environment (read if present, written otherwise)")
+	answersFile := flag.String("answers", "", "Answer prompts from a file (one answer per line) instead of the terminal")
+	skipPreflight := flag.Bool("skip-preflight", false, "Skip the network connectivity check")
+	skipCredentialCheck := flag.Bool("skip-credential-check", false, "Don't check the credentials with the Hubble API before installing")
+	apiURL := flag.String("api-url", "", "Hubble API base URL (default: $"+hubbleapi.BaseURLEnv+" or the credentials' API environment)")
+	verify := flag.Bool("verify", false, "After flashing, check that the device is registered and the image was written")
+	waitForPacket := flag.Duration("wait-for-packet", 0, "After flashing, wait this long for the device's first packet (implies --verify)")
+	outputDir := flag.String("output-dir", "", "Directory for generated hex files (default: the current directory)")
+	overwriteFlag := flag.String("overwrite", string(output.Ask), "When a hex file already exists: ask, suffix or fail")
+	nameTemplate := flag.String("name-template", "", "Device naming template, e.g. \"{board}-{probe_serial}-{seq:03}\" (default: $"+NameTemplateEnv+")")
+	network := addNetworkFlags(flag.CommandLine)
+	flag.Parse()
+
+	// Ctrl-C and SIGTERM cancel the run and kill running commands
+	ctx := signalContext()
+
+	// Log this run, with every credential in the environment masked
+	logging.AddSecret(config.EnvironmentSecrets()...)
+	if err := logging.Start(Version); err != nil {
+		ui.PrintWarning(fmt.Sprintf("Could not create log file: %v", err))
+	}
+
+	if *answersFile != "" {
+		answers, err := ui.NewScriptedPrompterFromFile(*answersFile)
+		if err != nil {
+			ui.PrintError(err.Error())
+			exit(1)
+		}
+		ui.SetPrompter(answers)
+	}
+
+	// Network settings must be applied before any download or subprocess
+	if err := netconfig.Configure(*network); err != nil {
+		ui.PrintError(fmt.Sprintf("Network configuration failed: %v", err))
+		exit(1)
+	}
+
+	template, err := parseNameTemplate(*nameTemplate)
+	if err != nil {
+		ui.PrintError(err.Error())
+		exit(1)
+	}
+	overwrite, err := output.ParsePolicy(*overwriteFlag)
+	if err != nil {
+		ui.PrintError(err.Error())
+		exit(1)
+	}
+
 	// Print welcome banner
 	ui.PrintBanner()
 	fmt.Println()
@@ -26,10 +118,13 @@ func main() {
 	fmt.Println("  • Provision your board, or generate a hex file for you to flash")
 	fmt.Println()
 
+	// Offer to pick up an installation interrupted by a reboot or failure
+	progress, resumed := loadProgress()
+
 	// Prompt user to continue
-	if !ui.PromptYesNo("Ready to install?", true) {
+	if !resumed && !ui.PromptYesNo("Ready to install?", true) {
 		ui.PrintWarning("Installation cancelled")
-		os.Exit(0)
+		exit(0)
 	}
 	fmt.Println()
 
@@ -40,22 +135,42 @@ func main() {
 	installer, err := platform.GetInstaller()
 	if err != nil {
 		ui.PrintError(fmt.Sprintf("Platform detection failed: %v", err))
-		os.Exit(1)
+		exit(1)
 	}
 
-	// Check for pending reboot (especially important on Windows)
-	if err := installer.CheckPendingReboot(); err != nil {
-		fmt.Println()
-		ui.PrintWarning("═══════════════════════════════════════════════════════════════")
-		ui.PrintWarning("  SYSTEM REBOOT REQUIRED")
-		ui.PrintWarning("═══════════════════════════════════════════════════════════════")
-		fmt.Println()
-		ui.PrintWarning("A previous installation requires a system reboot before continuing.")
-		ui.PrintInfo(fmt.Sprintf("Reason: %v", err))
-		fmt.Println()
-		ui.PrintInfo("Please reboot your computer and run this installer again.")
-		fmt.Println()
-		os.Exit(2)
+	// Offline mode: everything comes from the bundle
+	var offline *bundle.Bundle
+	if *bundlePath != "" {
+		ui.PrintInfo(fmt.Sprintf("Using offline bundle: %s", *bundlePath))
+		offline, err = bundle.Open(*bundlePath)
+		if err != nil {
+			ui.PrintError(fmt.Sprintf("Could not open bundle: %v", err))
+			exit(1)
+		}
+		if err := offline.Activate(); err != nil {
+			ui.PrintError(fmt.Sprintf("Could not activate bundle: %v", err))
+			exit(1)
+		}
+		ui.PrintSuccess(fmt.Sprintf("Bundle ready (boards: %s)", strings.Join(offline.Manifest.Boards, ", ")))
+
+		// Only the bundled tool version can be installed offline
+		if *toolVersion == "" {
+			*toolVersion = offline.Manifest.ToolVersion
+		}
+	} else if !*skipPreflight {
+		runPreflight()
+	}
+
+	hubbledemo.Configure(*toolVersion, *lockFile)
+
+	// Check for a pending reboot or a login session that predates group changes
+	checkCtx, cancel := context.WithTimeout(ctx, timeoutCheck)
+	err = installer.CheckPendingReboot(checkCtx)
+	cancel()
+	if err != nil {
+		exitIfInterrupted(ctx)
+		printPendingReboot(err)
+		exit(2)
 	}
 
 	// =========================================================================
@@ -65,11 +180,42 @@ func main() {
 	totalSteps := 0
 	ui.PrintStep("Configuring credentials", currentStep, totalSteps)
 
+	if resumed {
+		printCredentialHint(progress.CredentialSource)
+	}
+
 	cfg, preConfigured, err := config.PromptForConfig()
 	if err != nil {
 		ui.PrintError(fmt.Sprintf("Configuration failed: %v", err))
-		os.Exit(1)
+		exit(1)
+	}
+
+	logging.AddSecret(cfg.OrgID, cfg.APIToken)
+	if cfg.APIEnv != config.DefaultAPIEnv {
+		ui.PrintInfo(fmt.Sprintf("API environment: %s", cfg.APIEnv))
+	}
+
+	// Catch a revoked or mistyped token before spending minutes on dependencies.
+	// Without the check there is no API client, so device names aren't checked either.
+	var api *hubbleapi.Client
+	if !*skipCredentialCheck {
+		baseURL, err := apiBaseURL(*apiURL, cfg)
+		if err != nil {
+			ui.PrintError(err.Error())
+			exit(1)
+		}
+		api = hubbleapi.New(baseURL, cfg.OrgID, cfg.APIToken)
+		if err := checkCredentialsOnline(ctx, api); err != nil {
+			ui.PrintError(fmt.Sprintf("Credential check failed: %v", err))
+			printCredentialCheckHint(err)
+			ui.PrintInfo("To install without this check (e.g. against a private API), pass --skip-credential-check.")
+			exit(1)
+		}
 	}
+	naming := &deviceNaming{api: api, probes: installer, template: template}
+	progress.CredentialSource = cfg.Source
+	progress.Complete(state.StepCredentials)
+	saveProgress(progress)
 
 	if preConfigured {
 		fmt.Println()
@@ -93,10 +239,21 @@ func main() {
 		board, err := boards.GetBoard(cfg.Board)
 		if err != nil {
 			ui.PrintError(fmt.Sprintf("Invalid pre-configured board: %v", err))
-			os.Exit(1)
+			exit(1)
 		}
 		selectedBoard = *board
 		ui.PrintSuccess(fmt.Sprintf("Using pre-configured board: %s", selectedBoard.Name))
+	} else if resumed && progress.Done(state.StepBoard) && progress.Board != "" {
+		// Board was chosen before the previous run stopped
+		board, err := boards.GetBoard(progress.Board)
+		if err != nil {
+			ui.PrintError(fmt.Sprintf("Invalid saved board: %v", err))
+			clearProgress()
+			exit(1)
+		}
+		selectedBoard = *board
+		cfg.Board = selectedBoard.ID
+		ui.PrintSuccess(fmt.Sprintf("Using board from previous run: %s", selectedBoard.Name))
 	} else {
 		// Prompt user to select a board
 		boardOptions := make([]string, len(boards.AvailableBoards))
@@ -111,6 +268,16 @@ func main() {
 		ui.PrintSuccess(fmt.Sprintf("Selected: %s", selectedBoard.Name))
 	}
 
+	progress.Board = selectedBoard.ID
+	progress.Complete(state.StepBoard)
+	saveProgress(progress)
+
+	if offline != nil && !offline.SupportsBoard(selectedBoard.ID) {
+		ui.PrintError(fmt.Sprintf("The offline bundle was not created for the %s", selectedBoard.Name))
+		ui.PrintInfo(fmt.Sprintf("Re-create it with: hubble-install bundle create --boards %s", selectedBoard.ID))
+		exit(1)
+	}
+
 	fmt.Println()
 	if selectedBoard.RequiresJLink() {
 		ui.PrintInfo("This board uses SEGGER J-Link for direct flashing.")
@@ -128,16 +295,34 @@ func main() {
 	ui.PrintStep("Checking prerequisites", currentStep, totalSteps)
 
 	requiredDeps := selectedBoard.GetDependencies()
-	missing, err := installer.CheckPrerequisites(requiredDeps)
+	checkCtx, cancel = context.WithTimeout(ctx, timeoutCheck)
+	missing, err := installer.CheckPrerequisites(checkCtx, requiredDeps)
+	cancel()
 	if err != nil {
-		ui.PrintError(fmt.Sprintf("Prerequisites check failed: %v", err))
-		os.Exit(1)
+		exitIfInterrupted(ctx)
+		ui.PrintError(fmt.Sprintf("Prerequisites check failed: %s", describeStepError(err, timeoutCheck)))
+		exit(1)
+	}
+
+	// Package managers aren't used when installing from a bundle
+	if offline != nil {
+		var needed []platform.MissingDependency
+		for _, dep := range missing {
+			if dep.Name != "Homebrew" && dep.Name != "Chocolatey" {
+				needed = append(needed, dep)
+			}
+		}
+		missing = needed
 	}
 
 	totalSteps = 4
 	if len(missing) > 0 {
 		totalSteps++
 	}
+	verifyEnabled := (*verify || *waitForPacket > 0) && selectedBoard.RequiresJLink()
+	if verifyEnabled {
+		totalSteps++
+	}
 
 	if len(missing) > 0 {
 		ui.PrintWarning("Missing dependencies detected:")
@@ -148,7 +333,7 @@ func main() {
 
 		if !ui.PromptYesNo("Would you like to install missing dependencies?", true) {
 			ui.PrintError("Cannot proceed without dependencies")
-			os.Exit(1)
+			exit(1)
 		}
 	} else {
 		ui.PrintSuccess("All prerequisites satisfied")
@@ -161,56 +346,87 @@ func main() {
 		currentStep++
 		ui.PrintStep("Installing dependencies", currentStep, totalSteps)
 
-		// Check if we need to install package manager first
-		needsPackageManager := false
-		for _, dep := range missing {
-			if dep.Name == "Homebrew" {
-				needsPackageManager = true
-				break
+		if offline != nil {
+			names := make([]string, len(missing))
+			for i, dep := range missing {
+				names[i] = dep.Name
+			}
+			installCtx, cancel := context.WithTimeout(ctx, timeoutDependencies)
+			err := offline.InstallDependencies(installCtx, names)
+			cancel()
+			if err != nil {
+				exitIfInterrupted(ctx)
+				ui.PrintError(fmt.Sprintf("Dependency installation failed: %s", describeStepError(err, timeoutDependencies)))
+				exit(1)
+			}
+		} else {
+			// Check if we need to install package manager first
+			needsPackageManager := false
+			for _, dep := range missing {
+				if dep.Name == "Homebrew" {
+					needsPackageManager = true
+					break
+				}
 			}
-		}
 
-		if needsPackageManager {
-			if err := installer.InstallPackageManager(); err != nil {
-				ui.PrintError(fmt.Sprintf("Package manager installation failed: %v", err))
-				os.Exit(1)
+			if needsPackageManager {
+				installCtx, cancel := context.WithTimeout(ctx, timeoutPackageManager)
+				err := installer.InstallPackageManager(installCtx)
+				cancel()
+				if err != nil {
+					exitIfInterrupted(ctx)
+					ui.PrintError(fmt.Sprintf("Package manager installation failed: %s", describeStepError(err, timeoutPackageManager)))
+					exit(1)
+				}
 			}
-		}
 
-		// Install board-specific dependencies
-		if err := installer.InstallDependencies(requiredDeps); err != nil {
-			// Check if this is a reboot required error
-			if strings.Contains(err.Error(), "requires a system reboot") || strings.Contains(err.Error(), "RebootRequired") {
-				fmt.Println()
-				ui.PrintWarning("═══════════════════════════════════════════════════════════════")
-				ui.PrintWarning("  SYSTEM REBOOT REQUIRED")
-				ui.PrintWarning("═══════════════════════════════════════════════════════════════")
-				fmt.Println()
-				ui.PrintSuccess("Dependencies were installed successfully!")
-				fmt.Println()
-				ui.PrintWarning("However, system components were updated that require a reboot")
-				ui.PrintWarning("before you can continue.")
-				fmt.Println()
-				ui.PrintInfo("What to do next:")
-				ui.PrintInfo("  1. Reboot your computer")
-				ui.PrintInfo("  2. Run this installer again after rebooting")
-				ui.PrintInfo("  3. The installer will detect what's already installed and continue")
-				fmt.Println()
-				ui.PrintInfo("Note: If PowerShell doesn't work after reboot, use Command Prompt (cmd.exe)")
-				fmt.Println()
-				os.Exit(2) // Exit code 2 indicates reboot required
+			// Install board-specific dependencies
+			installCtx, cancel := context.WithTimeout(ctx, timeoutDependencies)
+			err := installer.InstallDependencies(installCtx, requiredDeps)
+			cancel()
+			if err != nil {
+				exitIfInterrupted(ctx)
+				// Check if this is a reboot required error
+				var reboot *platform.RebootRequiredError
+				if errors.As(err, &reboot) {
+					fmt.Println()
+					ui.PrintWarning("═══════════════════════════════════════════════════════════════")
+					ui.PrintWarning("  SYSTEM REBOOT REQUIRED")
+					ui.PrintWarning("═══════════════════════════════════════════════════════════════")
+					fmt.Println()
+					progress.Complete(state.StepDependencies)
+					progress.RebootRequired = true
+					saveProgress(progress)
+
+					ui.PrintSuccess("Dependencies were installed successfully!")
+					fmt.Println()
+					ui.PrintWarning("However, system components were updated that require a reboot")
+					ui.PrintWarning("before you can continue.")
+					fmt.Println()
+					ui.PrintInfo("What to do next:")
+					ui.PrintInfo("  1. Reboot your computer")
+					ui.PrintInfo("  2. Run this installer again after rebooting")
+					ui.PrintInfo("  3. Choose to resume - your board and progress have been saved")
+					fmt.Println()
+					ui.PrintInfo("Note: If PowerShell doesn't work after reboot, use Command Prompt (cmd.exe)")
+					fmt.Println()
+					exit(2) // Exit code 2 indicates reboot required
+				}
+				ui.PrintError(fmt.Sprintf("Dependency installation failed: %s", describeStepError(err, timeoutDependencies)))
+				exit(1)
 			}
-			ui.PrintError(fmt.Sprintf("Dependency installation failed: %v", err))
-			os.Exit(1)
 		}
 
 		ui.PrintSuccess("All dependencies installed")
 	}
 
+	progress.Complete(state.StepDependencies)
+	saveProgress(progress)
+
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
 		ui.PrintError(fmt.Sprintf("Invalid configuration: %v", err))
-		os.Exit(1)
+		exit(1)
 	}
 
 	// =========================================================================
@@ -222,46 +438,115 @@ func main() {
 		// J-Link path: Direct flash
 		if !ui.PromptYesNo(fmt.Sprintf("Would you like to flash your %s now?", selectedBoard.Name), true) {
 			ui.PrintWarning("Flashing skipped. You can flash later using:")
-			fmt.Printf("  uv tool run --from pyhubbledemo hubbledemo flash %s -o %s -t <your_token>\n", cfg.Board, cfg.OrgID)
-			os.Exit(0)
+			printFlashLaterHint(cfg.Board, cfg.OrgID)
+			clearProgress()
+			exit(0)
 		}
 
-		// Prompt for optional device name
-		deviceName := ui.PromptOptionalInput("What should the device name be?")
+		deviceName := promptDeviceName(ctx, naming, progress, resumed, cfg)
 
 		ui.PrintStep("Flashing board", currentStep, totalSteps)
-		result, err := installer.FlashBoard(cfg.OrgID, cfg.APIToken, cfg.Board, deviceName)
+		flashedAt := time.Now()
+		flashCtx, cancel := context.WithTimeout(ctx, timeoutFlash)
+		result, err := installer.FlashBoard(flashCtx, cfg.OrgID, cfg.APIToken, cfg.Board, deviceName)
+		cancel()
 		if err != nil {
-			ui.PrintError(fmt.Sprintf("Board flashing failed: %v", err))
-			os.Exit(1)
+			exitIfInterrupted(ctx)
+			ui.PrintError(fmt.Sprintf("Board flashing failed: %s", describeStepError(err, timeoutFlash)))
+			printRegistrationRetryHint(err)
+			exit(1)
+		}
+
+		ui.PrintInfo(fmt.Sprintf("Firmware tool: %s %s", hubbledemo.Package, result.ToolVersion))
+		clearProgress()
+
+		deviceName = result.DeviceName
+		var checks []ui.Check
+		if verifyEnabled {
+			currentStep++
+			ui.PrintStep("Verifying device", currentStep, totalSteps)
+			verifier := &deviceVerifier{api: api, probe: installer, board: &selectedBoard, packetTimeout: *waitForPacket}
+			var device *hubbleapi.Device
+			device, checks = verifier.verify(ctx, deviceName, flashedAt)
+			if device != nil {
+				deviceName = device.Name
+			}
+			if anyFailed(checks) {
+				ui.PrintError("Verification failed: the board was flashed, but the checks above did not pass.")
+				ui.PrintInfo("Check the board's USB connection and run the installer again, or see https://hubble.com/support/")
+				exit(1)
+			}
 		}
 
 		// Print J-Link completion banner
 		duration := time.Since(startTime)
-		ui.PrintCompletionBanner(duration, cfg.OrgID, cfg.APIToken, result.DeviceName)
+		ui.PrintCompletionBanner(duration, cfg.OrgID, cfg.APIToken, deviceName, ui.Passed(checks, checkPacket), checks)
 
 	} else {
 		// Uniflash path: Generate hex file
 		if !ui.PromptYesNo(fmt.Sprintf("Would you like to generate the hex file for your %s now?", selectedBoard.Name), true) {
 			ui.PrintWarning("Hex generation skipped. You can generate later using:")
-			fmt.Printf("  uv tool run --from pyhubbledemo hubbledemo flash %s -o %s -t <your_token>\n", cfg.Board, cfg.OrgID)
-			os.Exit(0)
+			printFlashLaterHint(cfg.Board, cfg.OrgID)
+			clearProgress()
+			exit(0)
 		}
 
-		// Prompt for optional device name
-		deviceName := ui.PromptOptionalInput("What should the device name be?")
+		deviceName := promptDeviceName(ctx, naming, progress, resumed, cfg)
 
 		ui.PrintStep("Generating hex file", currentStep, totalSteps)
-		result, err := installer.GenerateHexFile(cfg.OrgID, cfg.APIToken, cfg.Board, deviceName)
+		hexFilePath, err := chooseHexPath(*outputDir, overwrite, cfg.Board, deviceName)
+		if err != nil {
+			ui.PrintError(err.Error())
+			exit(1)
+		}
+		flashCtx, cancel := context.WithTimeout(ctx, timeoutFlash)
+		result, err := installer.GenerateHexFile(flashCtx, cfg.OrgID, cfg.APIToken, cfg.Board, deviceName, hexFilePath)
+		cancel()
 		if err != nil {
-			ui.PrintError(fmt.Sprintf("Hex file generation failed: %v", err))
-			os.Exit(1)
+			exitIfInterrupted(ctx)
+			ui.PrintError(fmt.Sprintf("Hex file generation failed: %s", describeStepError(err, timeoutFlash)))
+			printRegistrationRetryHint(err)
+			exit(1)
+		}
+
+		ui.PrintInfo(fmt.Sprintf("Firmware tool: %s %s", hubbledemo.Package, result.ToolVersion))
+		clearProgress()
+
+		summary := result.Image.Summary()
+		if sidecar := writeHexMetadata(result, cfg.Board, deviceName); sidecar != "" {
+			summary = append(summary, fmt.Sprintf("Details: %s", sidecar))
 		}
 
 		// Print Uniflash completion banner
 		duration := time.Since(startTime)
-		ui.PrintUniflashCompletionBanner(duration, result.HexFilePath, selectedBoard.Name, deviceName)
+		ui.PrintUniflashCompletionBanner(duration, result.HexFilePath, selectedBoard.Name, deviceName, summary)
 	}
 
-	os.Exit(0)
+	exit(0)
+}
+
+// exit ends the run, pointing at the log file when it did not succeed
+func exit(code int) {
+	logging.Record(logging.KindInfo, fmt.Sprintf("Exiting with code %d", code))
+	logging.Close()
+	if code != 0 && logging.Path() != "" {
+		fmt.Println()
+		ui.PrintInfo(fmt.Sprintf("A log of this run was saved to: %s", logging.Path()))
+	}
+	os.Exit(code)
+}
+
+// printFlashLaterHint prints the commands that flash the board by hand. The
+// token is read into a variable so it stays out of the shell history; unlike
+// the installer's own runs it is on hubbledemo's command line while it runs.
+func printFlashLaterHint(board, orgID string) {
+	tokenArg := fmt.Sprintf(`"$%s"`, hubbledemo.TokenEnv)
+	if runtime.GOOS == "windows" {
+		fmt.Printf("  $env:%s = Read-Host \"API token\"\n", hubbledemo.TokenEnv)
+		tokenArg = "$env:" + hubbledemo.TokenEnv
+	} else {
+		// Works in bash and zsh, whose "read -p" options differ
+		fmt.Printf("  printf 'API token: '; read -rs %[1]s; export %[1]s\n", hubbledemo.TokenEnv)
+	}
+	fmt.Printf("  uv tool run --from %s hubbledemo flash %s -o %s -t %s\n", hubbledemo.Spec(), board, orgID, tokenArg)
 }
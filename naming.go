@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/HubbleNetwork/hubble-install/internal/config"
+	"github.com/HubbleNetwork/hubble-install/internal/devicename"
+	"github.com/HubbleNetwork/hubble-install/internal/hubbleapi"
+	"github.com/HubbleNetwork/hubble-install/internal/platform"
+	"github.com/HubbleNetwork/hubble-install/internal/ui"
+)
+
+// NameTemplateEnv supplies a device naming template when --name-template isn't given
+const NameTemplateEnv = "HUBBLE_NAME_TEMPLATE"
+
+// deviceNaming holds what device names are generated and checked with
+type deviceNaming struct {
+	api      *hubbleapi.Client    // nil when names can't be checked online
+	probes   platform.ProbeFinder // Finds the J-Link serial for {probe_serial}
+	template *devicename.Template // nil without a naming template
+
+	probeSerial string
+}
+
+// templateName is a device name expanded from a template, with the sequence
+// number to save once the name is used
+type templateName struct {
+	name     string
+	template string
+	seq      int
+}
+
+// parseNameTemplate reads the naming template from the flag or NameTemplateEnv
+func parseNameTemplate(flagValue string) (*devicename.Template, error) {
+	text := flagValue
+	if text == "" {
+		text = strings.TrimSpace(os.Getenv(NameTemplateEnv))
+	}
+	if text == "" {
+		return nil, nil
+	}
+	return devicename.ParseTemplate(text)
+}
+
+// expand fills in a naming template, skipping names already in existing
+func (n *deviceNaming) expand(ctx context.Context, t *devicename.Template, cfg *config.Config, existing []string) (*templateName, error) {
+	values := devicename.Values{Board: cfg.Board, Date: time.Now()}
+	if t.Uses("probe_serial") {
+		serial, err := n.findProbeSerial(ctx)
+		if err != nil {
+			return nil, err
+		}
+		values.ProbeSerial = serial
+	}
+	if t.Uses("user") {
+		current, err := user.Current()
+		if err != nil {
+			return nil, fmt.Errorf("naming template uses {user}: %w", err)
+		}
+		values.User = devicename.UserName(current.Username)
+	}
+	if t.Uses("seq") {
+		seq, err := devicename.NextSeq(t.String())
+		if err != nil {
+			return nil, err
+		}
+		values.Seq = seq
+	}
+
+	name, seq, err := t.Name(values, existing)
+	if err != nil {
+		return nil, err
+	}
+	if !t.Uses("seq") {
+		seq = 0
+	}
+	return &templateName{name: name, template: t.String(), seq: seq}, nil
+}
+
+// findProbeSerial returns the serial of the one connected J-Link probe
+func (n *deviceNaming) findProbeSerial(ctx context.Context) (string, error) {
+	if n.probeSerial != "" {
+		return n.probeSerial, nil
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, timeoutCheck)
+	defer cancel()
+	serials, err := n.probes.ProbeSerials(probeCtx)
+	exitIfInterrupted(ctx)
+	switch {
+	case err != nil:
+		return "", fmt.Errorf("naming template uses {probe_serial}: %w", err)
+	case len(serials) == 0:
+		return "", fmt.Errorf("naming template uses {probe_serial} but no J-Link probe is connected")
+	case len(serials) > 1:
+		return "", fmt.Errorf("naming template uses {probe_serial} but %d J-Link probes are connected (%s); connect only the board to flash", len(serials), strings.Join(serials, ", "))
+	}
+	n.probeSerial = serials[0]
+	return n.probeSerial, nil
+}
+
+// suggest returns the default device name: the naming template if there is
+// one, otherwise an unused "<board>-<n>" when the organization's devices are
+// known. It returns nil when the firmware tool should pick the name.
+func (n *deviceNaming) suggest(ctx context.Context, cfg *config.Config, existing []string, listed bool) *templateName {
+	if n.template != nil {
+		suggestion, err := n.expand(ctx, n.template, cfg, existing)
+		if err == nil {
+			return suggestion
+		}
+		ui.PrintWarning(err.Error())
+	}
+	if listed {
+		return &templateName{name: devicename.Suggest(cfg.DevicePrefix+cfg.Board, existing)}
+	}
+	return nil
+}
+
+// saveSeq advances the template's counter once a name from it is used
+func (t *templateName) saveSeq() {
+	if t == nil || t.template == "" || t.seq == 0 {
+		return
+	}
+	if err := devicename.SaveSeq(t.template, t.seq); err != nil {
+		ui.PrintWarning(fmt.Sprintf("Could not save the device name counter: %v", err))
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/HubbleNetwork/hubble-install/internal/netconfig"
+	"github.com/HubbleNetwork/hubble-install/internal/ui"
+)
+
+// addNetworkFlags registers the proxy/CA flags shared by every command that downloads
+func addNetworkFlags(fs *flag.FlagSet) *netconfig.Settings {
+	s := &netconfig.Settings{}
+	fs.StringVar(&s.Proxy, "proxy", "", "HTTP(S) proxy URL (default: $HTTPS_PROXY)")
+	fs.StringVar(&s.NoProxy, "no-proxy", "", "Comma-separated hosts that bypass the proxy (default: $NO_PROXY)")
+	fs.StringVar(&s.CABundle, "ca-bundle", "", "PEM file with extra trusted CA certificates (default: $HUBBLE_CA_BUNDLE)")
+	fs.StringVar(&s.IndexURL, "index-url", "", "Python package index mirror for uv/pip")
+	fs.StringVar(&s.DownloadMirror, "download-mirror", "", "Mirror of the pinned third-party downloads (default: $"+netconfig.DownloadMirrorEnv+")")
+	return s
+}
+
+// runPreflight checks connectivity and explains TLS interception failures.
+// It only warns; the installer still attempts the downloads afterwards.
+func runPreflight() {
+	results := netconfig.Preflight(netconfig.PreflightURLs)
+
+	intercepted := false
+	for _, result := range results {
+		if result.Err == nil {
+			continue
+		}
+		if result.TLSInterception {
+			intercepted = true
+			msg := fmt.Sprintf("Untrusted TLS certificate for %s", result.URL)
+			if result.Issuer != "" {
+				msg += fmt.Sprintf(" (issued by %s)", result.Issuer)
+			}
+			ui.PrintWarning(msg)
+		} else {
+			ui.PrintWarning(fmt.Sprintf("Could not reach %s: %v", result.URL, result.Err))
+		}
+	}
+
+	if intercepted {
+		fmt.Println()
+		ui.PrintInfo("Your network appears to inspect TLS traffic, so downloads will fail.")
+		ui.PrintInfo("Export your organization's CA certificate as PEM and run:")
+		ui.PrintInfo("  hubble-install --ca-bundle /path/to/corp-ca.pem")
+		fmt.Println()
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/HubbleNetwork/hubble-install/internal/output"
+	"github.com/HubbleNetwork/hubble-install/internal/platform"
+	"github.com/HubbleNetwork/hubble-install/internal/ui"
+)
+
+// chooseHexPath returns where the hex file for the device is written: a
+// file named after the device (or the board) in dir, which defaults to the
+// current directory and is created if needed
+func chooseHexPath(dir string, policy output.Policy, board, deviceName string) (string, error) {
+	if dir == "" {
+		dir = "."
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid output directory: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	base := board
+	if deviceName != "" {
+		base = deviceName
+	}
+	name := output.SafeFilename(base)
+	if name != base {
+		ui.PrintInfo(fmt.Sprintf("Naming the hex file %s.hex, since %q can't be used as a file name", name, base))
+	}
+
+	return output.Choose(dir, name, ".hex", policy, func(path string) bool {
+		return ui.PromptYesNo(fmt.Sprintf("%s already exists. Overwrite it?", path), false)
+	})
+}
+
+// writeHexMetadata records how the hex file was made in a JSON file next to
+// it and returns that file's path, or "" if it couldn't be written
+func writeHexMetadata(result *platform.FlashResult, board, deviceName string) string {
+	err := output.WriteSidecar(result.HexFilePath, output.Metadata{
+		File:             filepath.Base(result.HexFilePath),
+		Board:            board,
+		DeviceName:       deviceName,
+		CreatedAt:        time.Now().UTC(),
+		ToolVersion:      result.ToolVersion,
+		InstallerVersion: Version,
+		SHA256:           result.Image.SHA256,
+	})
+	if err != nil {
+		ui.PrintWarning(fmt.Sprintf("Could not write hex file details: %v", err))
+		return ""
+	}
+	return output.SidecarPath(result.HexFilePath)
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/HubbleNetwork/hubble-install/internal/boards"
+	"github.com/HubbleNetwork/hubble-install/internal/config"
+	"github.com/HubbleNetwork/hubble-install/internal/devicename"
+	"github.com/HubbleNetwork/hubble-install/internal/platform"
+	"github.com/HubbleNetwork/hubble-install/internal/state"
+	"github.com/HubbleNetwork/hubble-install/internal/ui"
+)
+
+// loadProgress offers to resume an unfinished installation. It returns the
+// state to record progress in and whether the user chose to resume.
+func loadProgress() (*state.State, bool) {
+	saved, err := state.Load()
+	if err != nil {
+		ui.PrintWarning(fmt.Sprintf("Ignoring saved installation progress: %v", err))
+		clearProgress()
+		return state.New(), false
+	}
+	if saved == nil {
+		return state.New(), false
+	}
+
+	ui.PrintInfo("A previous installation did not finish:")
+	if board, err := boards.GetBoard(saved.Board); err == nil {
+		fmt.Printf("  • Board: %s\n", board.Name)
+	}
+	if saved.DeviceName != "" {
+		fmt.Printf("  • Device name: %s\n", saved.DeviceName)
+	}
+	if saved.CredentialSource != "" {
+		fmt.Printf("  • Credentials: %s\n", describeSource(saved.CredentialSource))
+	}
+	if len(saved.Completed) > 0 {
+		fmt.Printf("  • Completed: %s\n", strings.Join(saved.Completed, ", "))
+	}
+	if saved.RebootRequired {
+		fmt.Println("  • Stopped for a system reboot")
+	}
+	fmt.Printf("  • Saved: %s\n", saved.SavedAt.Local().Format("2006-01-02 15:04"))
+	fmt.Println()
+
+	if ui.PromptYesNo("Resume where it stopped?", true) {
+		saved.RebootRequired = false
+		return saved, true
+	}
+
+	clearProgress()
+	return state.New(), false
+}
+
+// printCredentialHint explains how credentials are obtained again on resume,
+// since secrets are never saved
+func printCredentialHint(source string) {
+	current := config.DetectSource()
+	switch {
+	case source == config.SourcePrompt:
+		ui.PrintInfo("Your API token is never saved, so please enter your credentials again.")
+	case current != source:
+		ui.PrintWarning(fmt.Sprintf("Credentials came from %s last time, but it is not set now.", describeSource(source)))
+	}
+}
+
+// describeSource names a credential source for display
+func describeSource(source string) string {
+	switch source {
+	case config.SourceCredentials:
+		return "HUBBLE_CREDENTIALS"
+	case config.SourceEnvironment:
+		return "HUBBLE_ORG_ID and HUBBLE_API_TOKEN"
+	default:
+		return "entered at the prompt"
+	}
+}
+
+// saveProgress records progress; failures only cost the ability to resume
+func saveProgress(progress *state.State) {
+	if err := progress.Save(); err != nil {
+		ui.PrintWarning(fmt.Sprintf("Could not save installation progress: %v", err))
+	}
+}
+
+// clearProgress forgets a finished or abandoned installation
+func clearProgress() {
+	if err := state.Clear(); err != nil {
+		ui.PrintWarning(fmt.Sprintf("Could not remove saved installation progress: %v", err))
+	}
+}
+
+// promptDeviceName asks for the device name, reusing the one from a resumed
+// run if it is still valid. Names are validated and checked against the
+// organization's devices;
+// pressing Enter takes the naming template's name or an unused
+// "<board>-<n>" name. An answer containing placeholders is expanded like a
+// template. A device name prefix from the credentials is added to the name.
+func promptDeviceName(ctx context.Context, naming *deviceNaming, progress *state.State, resumed bool, cfg *config.Config) string {
+	if resumed && progress.DeviceName != "" {
+		// The state file may have been edited or written by another version
+		if err := devicename.Validate(progress.DeviceName); err != nil {
+			ui.PrintWarning(fmt.Sprintf("Not reusing the device name from the previous run: %v", err))
+		} else {
+			ui.PrintSuccess(fmt.Sprintf("Using device name from previous run: %s", progress.DeviceName))
+			return progress.DeviceName
+		}
+	}
+
+	existing, listed := existingDeviceNames(ctx, naming.api)
+	suggestion := naming.suggest(ctx, cfg, existing, listed)
+	prompt := "What should the device name be?"
+	if cfg.DevicePrefix != "" {
+		prompt = fmt.Sprintf("What should the device name be? (it will start with %q)", cfg.DevicePrefix)
+	}
+
+	var deviceName string
+	var chosen *templateName
+	for {
+		// Without a suggestion, an empty name lets the firmware tool pick one
+		if suggestion != nil {
+			deviceName = ui.PromptInputWithDefault(prompt, suggestion.name)
+		} else {
+			deviceName = strings.TrimSpace(ui.PromptOptionalInput(prompt))
+			if deviceName == "" && cfg.DevicePrefix != "" {
+				deviceName = cfg.DevicePrefix + cfg.Board
+			}
+		}
+		if deviceName == "" {
+			break
+		}
+
+		chosen = nil
+		if suggestion != nil && deviceName == suggestion.name {
+			chosen = suggestion
+		} else if devicename.IsTemplate(deviceName) {
+			t, err := devicename.ParseTemplate(deviceName)
+			if err == nil {
+				chosen, err = naming.expand(ctx, t, cfg, existing)
+			}
+			if err != nil {
+				ui.PrintWarning(err.Error())
+				continue
+			}
+			deviceName = chosen.name
+		}
+		if cfg.DevicePrefix != "" && !strings.HasPrefix(deviceName, cfg.DevicePrefix) {
+			deviceName = cfg.DevicePrefix + deviceName
+		}
+
+		if err := devicename.Validate(deviceName); err != nil {
+			ui.PrintWarning(err.Error())
+			continue
+		}
+		if devicename.Contains(existing, deviceName) {
+			ui.PrintWarning(fmt.Sprintf("A device named %q already exists in your organization", deviceName))
+			if !ui.PromptYesNo("Use this name anyway?", false) {
+				continue
+			}
+		}
+		break
+	}
+
+	if deviceName != "" {
+		ui.PrintInfo(fmt.Sprintf("Device name: %s", deviceName))
+	}
+	chosen.saveSeq()
+	progress.DeviceName = deviceName
+	saveProgress(progress)
+	return deviceName
+}
+
+// printPendingReboot explains a reboot or new login session that is needed
+// before installing
+func printPendingReboot(err error) {
+	var reboot *platform.RebootRequiredError
+	sessionOnly := errors.As(err, &reboot) && reboot.SessionOnly
+
+	fmt.Println()
+	ui.PrintWarning("═══════════════════════════════════════════════════════════════")
+	if sessionOnly {
+		ui.PrintWarning("  NEW LOGIN SESSION REQUIRED")
+	} else {
+		ui.PrintWarning("  SYSTEM REBOOT REQUIRED")
+	}
+	ui.PrintWarning("═══════════════════════════════════════════════════════════════")
+	fmt.Println()
+	if sessionOnly {
+		ui.PrintWarning("Group membership changes only take effect in a new login session.")
+	} else {
+		ui.PrintWarning("This system requires a reboot before continuing.")
+	}
+	ui.PrintInfo(fmt.Sprintf("Reason: %v", err))
+	fmt.Println()
+	if sessionOnly {
+		ui.PrintInfo("Please log out and back in (or reboot) and run this installer again.")
+	} else {
+		ui.PrintInfo("Please reboot your computer and run this installer again.")
+	}
+	fmt.Println()
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/HubbleNetwork/hubble-install/internal/config"
+	"github.com/HubbleNetwork/hubble-install/internal/diagnostics"
+	"github.com/HubbleNetwork/hubble-install/internal/logging"
+	"github.com/HubbleNetwork/hubble-install/internal/netconfig"
+	"github.com/HubbleNetwork/hubble-install/internal/platform"
+	"github.com/HubbleNetwork/hubble-install/internal/runner"
+	"github.com/HubbleNetwork/hubble-install/internal/ui"
+)
+
+// runDoctorCommand handles "hubble-install doctor" and returns the exit code
+func runDoctorCommand(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	offline := fs.Bool("offline", false, "Skip network connectivity checks")
+	network := addNetworkFlags(fs)
+	fs.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report, err := collectReport(ctx, *network, !*offline)
+	if err != nil {
+		ui.PrintError(err.Error())
+		return 1
+	}
+
+	report.WriteText(os.Stdout)
+	return 0
+}
+
+// runSupportBundleCommand handles "hubble-install support-bundle" and returns the exit code
+func runSupportBundleCommand(args []string) int {
+	fs := flag.NewFlagSet("support-bundle", flag.ExitOnError)
+	output := fs.String("output", "", "Archive to write (default: hubble-support-<timestamp>.tar.gz)")
+	offline := fs.Bool("offline", false, "Skip network connectivity checks")
+	network := addNetworkFlags(fs)
+	fs.Parse(args)
+
+	if *output == "" {
+		*output = fmt.Sprintf("hubble-support-%s.tar.gz", time.Now().Format("20060102-150405"))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ui.PrintInfo("Collecting diagnostics...")
+	report, err := collectReport(ctx, *network, !*offline)
+	if err != nil {
+		ui.PrintError(err.Error())
+		return 1
+	}
+
+	files, err := diagnostics.WriteSupportBundle(*output, report)
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Could not write support bundle: %v", err))
+		os.Remove(*output)
+		return 1
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Support bundle written to %s", *output))
+	for _, name := range files {
+		fmt.Printf("  • %s\n", name)
+	}
+	fmt.Println()
+	ui.PrintInfo("Credentials and tokens have been replaced with " + logging.Redacted + ".")
+	ui.PrintInfo("Attach this file to your GitHub issue or support request.")
+	return 0
+}
+
+// collectReport applies network settings and gathers the diagnostics report
+func collectReport(ctx context.Context, network netconfig.Settings, checkNetwork bool) (*diagnostics.Report, error) {
+	if err := netconfig.Configure(network); err != nil {
+		return nil, fmt.Errorf("network configuration failed: %w", err)
+	}
+
+	// Mask any credentials in the environment wherever they show up
+	logging.AddSecret(config.EnvironmentSecrets()...)
+
+	r := runner.New()
+	// Diagnostics are still useful on platforms the installer doesn't support
+	var reboot platform.RebootChecker
+	if installer, err := platform.NewInstaller(runtime.GOOS, r); err == nil {
+		reboot = installer
+	}
+	return diagnostics.Collect(ctx, r, reboot, Version, checkNetwork), nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/HubbleNetwork/hubble-install/internal/installed"
+	"github.com/HubbleNetwork/hubble-install/internal/pinned"
+	"github.com/HubbleNetwork/hubble-install/internal/runner"
+	"github.com/HubbleNetwork/hubble-install/internal/ui"
+)
+
+// runUninstallCommand handles "hubble-install uninstall" and returns the exit code
+func runUninstallCommand(args []string) int {
+	fs := flag.NewFlagSet("uninstall", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "Show what would be removed without removing anything")
+	yes := fs.Bool("yes", false, "Remove without asking for confirmation")
+	fs.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	record, err := installed.Load()
+	if err != nil {
+		ui.PrintError(err.Error())
+		return 1
+	}
+
+	r := runner.New()
+	steps, err := installed.Plan(record, findUV(r, record))
+	if err != nil {
+		ui.PrintError(err.Error())
+		return 1
+	}
+
+	if len(record.Items) == 0 {
+		ui.PrintInfo("The installer has not installed any dependencies on this machine.")
+	} else {
+		ui.PrintInfo("The installer added these dependencies:")
+		for _, item := range record.Items {
+			fmt.Printf("  • %s (%s)\n", item.Name, item.InstalledAt.Local().Format("2006-01-02"))
+		}
+	}
+	fmt.Println()
+	ui.PrintInfo("Uninstall will:")
+	for _, step := range steps {
+		fmt.Printf("  • %s\n", step)
+	}
+	fmt.Println()
+
+	if *dryRun {
+		ui.PrintInfo("Dry run: nothing was removed.")
+		return 0
+	}
+	if !*yes && !ui.PromptYesNo("Remove these items?", false) {
+		ui.PrintInfo("Uninstall cancelled")
+		return 0
+	}
+
+	failed := 0
+	for _, step := range steps {
+		if ctx.Err() != nil {
+			ui.PrintWarning("Uninstall interrupted")
+			return interruptedExitCode
+		}
+		if step.LastResort && failed > 0 {
+			ui.PrintWarning("Keeping the install record so uninstall can be run again")
+			continue
+		}
+		if step.Manual != "" {
+			ui.PrintWarning(step.Manual)
+			continue
+		}
+
+		if err := runUninstallStep(ctx, r, step); err != nil {
+			ui.PrintError(fmt.Sprintf("%s failed: %v", step.Description, err))
+			failed++
+			continue
+		}
+		if step.Item != "" {
+			if err := installed.Remove(step.Item); err != nil {
+				ui.PrintWarning(fmt.Sprintf("Could not update the install record: %v", err))
+			}
+		}
+		ui.PrintSuccess(step.Description)
+	}
+
+	if failed > 0 {
+		ui.PrintError(fmt.Sprintf("%d step(s) failed; fix the errors above and run uninstall again", failed))
+		return 1
+	}
+	ui.PrintSuccess("Uninstall complete")
+	return 0
+}
+
+// runUninstallStep runs a step's command and deletes its paths
+func runUninstallStep(ctx context.Context, r runner.Runner, step installed.Step) error {
+	if step.Command != nil {
+		cmd := *step.Command
+		if step.Script != "" {
+			script, cleanup, err := pinned.DownloadTemp(ctx, r, step.Script)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+			cmd.Args = append(append([]string(nil), cmd.Args...), script)
+		}
+		if cmd.Stdout == nil {
+			cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		}
+		if err := r.Run(ctx, &cmd); err != nil {
+			return err
+		}
+	}
+	for _, path := range step.Paths {
+		if err := r.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findUV locates uv on PATH or where the installer put it
+func findUV(r runner.Runner, record *installed.Record) string {
+	if path, err := r.LookPath("uv"); err == nil {
+		return path
+	}
+	for _, item := range record.Items {
+		if item.Method == installed.MethodUVScript && item.Path != "" {
+			if _, err := r.Stat(item.Path); err == nil {
+				return item.Path
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"github.com/HubbleNetwork/hubble-install/internal/hubbledemo"
+	"github.com/HubbleNetwork/hubble-install/internal/netconfig"
+	"github.com/HubbleNetwork/hubble-install/internal/runner"
+	"github.com/HubbleNetwork/hubble-install/internal/selfupdate"
+	"github.com/HubbleNetwork/hubble-install/internal/ui"
+)
+
+// runVersionCommand handles "hubble-install version" and returns the exit code
+func runVersionCommand(args []string) int {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	fs.Parse(args)
+
+	tool := hubbledemo.DefaultVersion
+	if tool == "" {
+		tool = "latest (not pinned)"
+	}
+
+	fmt.Printf("hubble-install %s\n", Version)
+	fmt.Printf("  commit:       %s\n", Commit)
+	fmt.Printf("  built:        %s\n", Date)
+	fmt.Printf("  go:           %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Printf("  pyhubbledemo: %s\n", tool)
+	return 0
+}
+
+// runSelfUpdateCommand handles "hubble-install self-update" and returns the exit code
+func runSelfUpdateCommand(args []string) int {
+	fs := flag.NewFlagSet("self-update", flag.ExitOnError)
+	check := fs.Bool("check", false, "Only report whether an update is available")
+	force := fs.Bool("force", false, "Replace development builds and reinstall the current version")
+	baseURL := fs.String("base-url", "", "Release location (default: $"+selfupdate.BaseURLEnv+" or the latest GitHub release)")
+	network := addNetworkFlags(fs)
+	fs.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := netconfig.Configure(*network); err != nil {
+		ui.PrintError(fmt.Sprintf("Network configuration failed: %v", err))
+		return 1
+	}
+
+	r := runner.New()
+	base := *baseURL
+	if base == "" {
+		base = r.Getenv(selfupdate.BaseURLEnv)
+	}
+	if base == "" {
+		base = selfupdate.DefaultBaseURL
+	}
+	base = strings.TrimSuffix(base, "/")
+
+	ui.PrintInfo(fmt.Sprintf("Checking %s for updates...", base))
+	index, err := selfupdate.FetchIndex(ctx, r, base)
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Could not check for updates: %v", err))
+		return 1
+	}
+
+	// Versions only move forward, so an old signed index can't be replayed
+	cmp, err := selfupdate.Compare(index.Version, Version)
+	switch {
+	case err != nil && !*force:
+		ui.PrintWarning(fmt.Sprintf("This is a development build (%s); run with --force to replace it with %s", Version, index.Version))
+		return 1
+	case err == nil && cmp < 0:
+		ui.PrintInfo(fmt.Sprintf("Installed version %s is newer than the latest release %s", Version, index.Version))
+		return 0
+	case err == nil && cmp == 0 && !*force:
+		ui.PrintSuccess(fmt.Sprintf("hubble-install %s is up to date", Version))
+		return 0
+	}
+
+	if *check {
+		ui.PrintInfo(fmt.Sprintf("Update available: %s → %s", Version, index.Version))
+		ui.PrintInfo("Run 'hubble-install self-update' to install it.")
+		return 0
+	}
+
+	exe, err := selfupdate.Executable()
+	if err != nil {
+		ui.PrintError(err.Error())
+		return 1
+	}
+
+	ui.PrintInfo(fmt.Sprintf("Downloading hubble-install %s...", index.Version))
+	newPath, err := selfupdate.Download(ctx, r, base, index, filepath.Dir(exe))
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Update failed: %v", err))
+		if errors.Is(err, os.ErrPermission) {
+			ui.PrintInfo(fmt.Sprintf("%s is not writable; run the update with sudo or as an administrator.", filepath.Dir(exe)))
+		}
+		return 1
+	}
+	if err := selfupdate.Replace(exe, newPath); err != nil {
+		os.Remove(newPath)
+		ui.PrintError(fmt.Sprintf("Update failed: %v", err))
+		return 1
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Updated %s to %s", exe, index.Version))
+	return 0
+}
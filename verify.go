@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/HubbleNetwork/hubble-install/internal/boards"
+	"github.com/HubbleNetwork/hubble-install/internal/hubbleapi"
+	"github.com/HubbleNetwork/hubble-install/internal/platform"
+	"github.com/HubbleNetwork/hubble-install/internal/ui"
+)
+
+// Names of the post-flash checks shown in the summary
+const (
+	checkRegistered = "Device registered"
+	checkImage      = "Firmware image"
+	checkPacket     = "First packet received"
+)
+
+// Post-flash verification timing. The device record can take a moment to
+// appear after the firmware tool registers it, and the server's clock may
+// differ from ours, so devices created shortly before the flash count too.
+const (
+	registrationAttempts = 3
+	verifyPollInterval   = 5 * time.Second
+	creationClockSkew    = 5 * time.Minute
+)
+
+// badImageError means the image read back from the board can't be valid,
+// as opposed to the read itself failing
+type badImageError struct {
+	reason string
+}
+
+func (e *badImageError) Error() string {
+	return e.reason
+}
+
+// deviceVerifier runs the optional checks after a board has been flashed
+type deviceVerifier struct {
+	api           *hubbleapi.Client     // nil when the API can't be used
+	probe         platform.MemoryReader // Reads the image back; nil for boards without a probe
+	board         *boards.Board
+	packetTimeout time.Duration // How long to wait for the first packet; 0 skips the wait
+}
+
+// verify checks that the device is registered, that the board holds a
+// plausible image, and optionally that the device has been heard from. It
+// returns the device record if one was found.
+func (v *deviceVerifier) verify(ctx context.Context, deviceName string, flashedAt time.Time) (*hubbleapi.Device, []ui.Check) {
+	var checks []ui.Check
+
+	device, err := v.findRegistered(ctx, deviceName, flashedAt)
+	exitIfInterrupted(ctx)
+	switch {
+	case v.api == nil:
+		checks = append(checks, ui.Check{Name: checkRegistered, Status: ui.CheckSkipped, Detail: "the credential check was skipped"})
+	case err != nil:
+		checks = append(checks, ui.Check{Name: checkRegistered, Status: ui.CheckFailed, Detail: err.Error()})
+	default:
+		checks = append(checks, ui.Check{Name: checkRegistered, Status: ui.CheckPassed, Detail: fmt.Sprintf("%s (ID %s)", device.Name, device.ID)})
+	}
+
+	if v.probe != nil && v.board.JLinkDevice != "" {
+		err := v.checkImage(ctx)
+		exitIfInterrupted(ctx)
+		var bad *badImageError
+		switch {
+		case err == nil:
+			checks = append(checks, ui.Check{Name: checkImage, Status: ui.CheckPassed, Detail: "vector table read back through the probe is valid"})
+		case errors.As(err, &bad):
+			checks = append(checks, ui.Check{Name: checkImage, Status: ui.CheckFailed, Detail: err.Error()})
+		default:
+			checks = append(checks, ui.Check{Name: checkImage, Status: ui.CheckSkipped, Detail: err.Error()})
+		}
+	}
+
+	if v.packetTimeout > 0 {
+		switch {
+		case device == nil:
+			checks = append(checks, ui.Check{Name: checkPacket, Status: ui.CheckSkipped, Detail: "the device record was not found"})
+		default:
+			seen, err := v.waitForPacket(ctx, device.ID)
+			exitIfInterrupted(ctx)
+			if err != nil {
+				checks = append(checks, ui.Check{Name: checkPacket, Status: ui.CheckFailed, Detail: err.Error()})
+			} else {
+				checks = append(checks, ui.Check{Name: checkPacket, Status: ui.CheckPassed, Detail: "at " + seen.Local().Format("15:04:05")})
+			}
+		}
+	}
+
+	ui.PrintChecks(checks)
+	return device, checks
+}
+
+// findRegistered looks for the device created by the flash: the newest one
+// with the given name, or the newest one created since the flash when the
+// firmware tool chose the name
+func (v *deviceVerifier) findRegistered(ctx context.Context, deviceName string, flashedAt time.Time) (*hubbleapi.Device, error) {
+	if v.api == nil {
+		return nil, nil
+	}
+
+	since := flashedAt.Add(-creationClockSkew)
+	for attempt := 1; ; attempt++ {
+		listCtx, cancel := context.WithTimeout(ctx, timeoutDeviceList)
+		devices, err := v.api.ListDevices(listCtx)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+
+		var newest *hubbleapi.Device
+		for i, d := range devices {
+			if d.CreatedAt.Before(since) || (deviceName != "" && !strings.EqualFold(d.Name, deviceName)) {
+				continue
+			}
+			if newest == nil || d.CreatedAt.After(newest.CreatedAt) {
+				newest = &devices[i]
+			}
+		}
+		if newest != nil {
+			return newest, nil
+		}
+		if attempt == registrationAttempts {
+			if deviceName != "" {
+				return nil, fmt.Errorf("no device named %q was created in your organization", deviceName)
+			}
+			return nil, errors.New("no new device was created in your organization")
+		}
+		if err := waitFor(ctx, verifyPollInterval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// checkImage reads the vector table at the start of flash and checks that
+// it holds an initial stack pointer in RAM and a Thumb reset handler in flash
+func (v *deviceVerifier) checkImage(ctx context.Context) error {
+	readCtx, cancel := context.WithTimeout(ctx, timeoutCheck)
+	defer cancel()
+	words, err := v.probe.ReadMemory(readCtx, v.board.JLinkDevice, v.board.FlashStart, 2)
+	if err != nil {
+		return fmt.Errorf("could not read the image back: %w", err)
+	}
+
+	sp, reset := words[0], words[1]
+	ramEnd := uint64(v.board.RAMStart) + uint64(v.board.RAMSize)
+	flashEnd := uint64(v.board.FlashStart) + uint64(v.board.FlashSize)
+	switch {
+	case sp == 0xFFFFFFFF && reset == 0xFFFFFFFF:
+		return &badImageError{"flash is erased; the image was not written"}
+	case sp <= v.board.RAMStart || uint64(sp) > ramEnd:
+		return &badImageError{fmt.Sprintf("vector table has stack pointer 0x%08X outside RAM", sp)}
+	case reset&1 == 0 || reset < v.board.FlashStart || uint64(reset) >= flashEnd:
+		return &badImageError{fmt.Sprintf("vector table has reset handler 0x%08X outside flash", reset)}
+	}
+	return nil
+}
+
+// waitForPacket polls the device record until it reports a packet
+func (v *deviceVerifier) waitForPacket(ctx context.Context, id string) (time.Time, error) {
+	ui.PrintInfo(fmt.Sprintf("Waiting up to %s for the first packet from the device...", v.packetTimeout))
+	ui.PrintInfo("In Sandbox, open the Hubble Connect mobile app near the board so it can pick up packets.")
+
+	waitCtx, cancel := context.WithTimeout(ctx, v.packetTimeout)
+	defer cancel()
+	for {
+		device, err := v.api.GetDevice(waitCtx, id)
+		if err == nil && device.LastSeen != nil {
+			return *device.LastSeen, nil
+		}
+		if err != nil && waitCtx.Err() == nil {
+			return time.Time{}, err
+		}
+		if waitFor(waitCtx, verifyPollInterval) != nil {
+			return time.Time{}, fmt.Errorf("no packet within %s", v.packetTimeout)
+		}
+	}
+}
+
+// waitFor pauses for d or until ctx is done
+func waitFor(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// anyFailed reports whether a check ran and failed
+func anyFailed(checks []ui.Check) bool {
+	for _, c := range checks {
+		if c.Status == ui.CheckFailed {
+			return true
+		}
+	}
+	return false
+}